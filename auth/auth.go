@@ -0,0 +1,301 @@
+// Package auth issues short-lived access tokens backed by server-side
+// sessions, and rotates the refresh tokens that renew them.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+var (
+	// ErrInvalidToken is returned for access tokens that fail validation.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrSessionRevoked is returned when the token's session was logged out
+	// or has expired.
+	ErrSessionRevoked = errors.New("session has been revoked")
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked
+	// refresh tokens.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token
+	// is presented again. The session is revoked, since one of the two
+	// holders of the token is not its owner.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+const (
+	DefaultIssuer   = "sinkedin"
+	DefaultAudience = "sinkedin-api"
+)
+
+type Config struct {
+	Keys Keyring
+	// Issuer and Audience are stamped on every access token and required to
+	// match when one is validated.
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew when checking exp, nbf and iat; defaults
+	// to 30 seconds.
+	Leeway time.Duration
+	// AccessTTL is how long an access token is valid; defaults to 15 minutes.
+	AccessTTL time.Duration
+	// RefreshTTL is how long a session lasts without being refreshed;
+	// defaults to 30 days.
+	RefreshTTL time.Duration
+}
+
+// Claims are the contents of an access token.
+type Claims struct {
+	UserID    uint `json:"userId"`
+	SessionID uint `json:"sid"`
+	jwt.StandardClaims
+}
+
+// Tokens is the pair handed to a client at login and on every refresh.
+type Tokens struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+type Service struct {
+	sessions store.SessionStore
+	cfg      Config
+	now      func() time.Time
+}
+
+// New validates the configuration and returns the service. It fails when no
+// usable signing key is configured.
+func New(sessions store.SessionStore, cfg Config) (*Service, error) {
+	if err := cfg.Keys.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = DefaultIssuer
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = DefaultAudience
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = 30 * time.Second
+	}
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = 15 * time.Minute
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = 30 * 24 * time.Hour
+	}
+	return &Service{sessions: sessions, cfg: cfg, now: time.Now}, nil
+}
+
+// Login opens a new session for the user and returns its first tokens.
+func (s *Service) Login(userID uint, userAgent, ipAddress string) (*Tokens, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	session := models.Session{
+		UserID:           userID,
+		RefreshTokenHash: hashSecret(secret),
+		UserAgent:        truncate(userAgent, 255),
+		IPAddress:        truncate(ipAddress, 64),
+		ExpiresAt:        now.Add(s.cfg.RefreshTTL),
+		LastUsedAt:       now,
+	}
+	if err := s.sessions.Create(&session); err != nil {
+		return nil, err
+	}
+
+	return s.issue(&session, secret)
+}
+
+// Refresh exchanges a refresh token for a new token pair, rotating the
+// refresh token so each one can be used only once.
+func (s *Service) Refresh(refreshToken string) (*Tokens, error) {
+	sessionID, secret, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessions.GetByID(sessionID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !session.Active(s.now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	hash := hashSecret(secret)
+	if session.PreviousTokenHash != "" && equalHashes(hash, session.PreviousTokenHash) {
+		if err := s.sessions.Revoke(session.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if !equalHashes(hash, session.RefreshTokenHash) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rotated, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	session.ExpiresAt = s.now().Add(s.cfg.RefreshTTL)
+	err = s.sessions.Rotate(session.ID, session.RefreshTokenHash, hashSecret(rotated), session.ExpiresAt)
+	if errors.Is(err, store.ErrConflict) {
+		// Another request rotated the token first
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(session, rotated)
+}
+
+// Logout revokes a single session.
+func (s *Service) Logout(sessionID uint) error {
+	return s.sessions.Revoke(sessionID)
+}
+
+// LogoutAll revokes every session of the user, logging out all devices.
+func (s *Service) LogoutAll(userID uint) error {
+	return s.sessions.RevokeAll(userID)
+}
+
+// Authenticate validates an access token and checks that its session is
+// still active. Only HS256 tokens signed by a key in the keyring, carrying
+// the expected issuer and audience and a current exp and nbf, are accepted.
+func (s *Service) Authenticate(accessToken string) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.Parser{
+		ValidMethods:         []string{jwt.SigningMethodHS256.Alg()},
+		SkipClaimsValidation: true,
+	}
+	_, err := parser.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		secret, ok := s.cfg.Keys.Lookup(kid)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return secret, nil
+	})
+	if err != nil || s.validateClaims(claims) != nil {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := s.sessions.GetByID(claims.SessionID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrSessionRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != claims.UserID || !session.Active(s.now()) {
+		return nil, ErrSessionRevoked
+	}
+
+	return claims, nil
+}
+
+// validateClaims checks the registered claims, all of which are required.
+func (s *Service) validateClaims(claims *Claims) error {
+	now := s.now()
+	leeway := int64(s.cfg.Leeway / time.Second)
+
+	switch {
+	case claims.UserID == 0 || claims.SessionID == 0:
+		return errors.New("missing subject claims")
+	case claims.Issuer != s.cfg.Issuer:
+		return errors.New("unexpected issuer")
+	case claims.Audience != s.cfg.Audience:
+		return errors.New("unexpected audience")
+	case claims.ExpiresAt == 0 || now.Unix() > claims.ExpiresAt+leeway:
+		return errors.New("token is expired")
+	case claims.NotBefore == 0 || now.Unix() < claims.NotBefore-leeway:
+		return errors.New("token is not valid yet")
+	case claims.IssuedAt > now.Unix()+leeway:
+		return errors.New("token was issued in the future")
+	}
+	return nil
+}
+
+func (s *Service) issue(session *models.Session, secret string) (*Tokens, error) {
+	now := s.now()
+	expiresAt := now.Add(s.cfg.AccessTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    s.cfg.Issuer,
+			Audience:  s.cfg.Audience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	})
+	token.Header["kid"] = s.cfg.Keys.Active.ID
+
+	accessToken, err := token.SignedString(s.cfg.Keys.Active.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: strconv.FormatUint(uint64(session.ID), 10) + "." + secret,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// Refresh tokens have the form "<session id>.<random secret>".
+func parseRefreshToken(token string) (uint, string, bool) {
+	idPart, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil {
+		return 0, "", false
+	}
+	return uint(id), secret, true
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func equalHashes(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"sinkedin/models"
+	"sinkedin/store/memstore"
+)
+
+func testKey(id string) Key {
+	return Key{ID: id, Secret: []byte(id + "-0123456789abcdef0123456789abcdef")}
+}
+
+func testKeyring(activeID string, retiredIDs ...string) Keyring {
+	keyring := Keyring{Active: testKey(activeID)}
+	for _, id := range retiredIDs {
+		keyring.Retired = append(keyring.Retired, testKey(id))
+	}
+	return keyring
+}
+
+func newTestService(t *testing.T) (*Service, uint, *time.Time) {
+	t.Helper()
+
+	s := memstore.New()
+	user := models.User{Name: "Alice", Username: "alice", Email: "alice@example.com", Password: "x"}
+	if err := s.Users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	svc, err := New(s.Sessions, Config{Keys: testKeyring("k1"), AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.now = func() time.Time { return now }
+	return svc, user.ID, &now
+}
+
+func TestAuthenticate(t *testing.T) {
+	svc, userID, _ := newTestService(t)
+
+	tokens, err := svc.Login(userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := svc.Authenticate(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if claims.UserID != userID || claims.SessionID == 0 {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	other, err := New(svc.sessions, Config{Keys: Keyring{Active: Key{ID: "k1", Secret: []byte("another-secret-another-secret-00")}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Authenticate(tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with another secret: err = %v", err)
+	}
+}
+
+func TestExpiredSessionCannotRefresh(t *testing.T) {
+	svc, userID, now := newTestService(t)
+
+	tokens, err := svc.Login(userID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	if _, err := svc.Refresh(tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh of expired session: err = %v", err)
+	}
+}
+
+func TestRefreshExtendsSession(t *testing.T) {
+	svc, userID, now := newTestService(t)
+
+	tokens, err := svc.Login(userID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Refreshing every 45 minutes keeps a one-hour session alive
+	for i := 0; i < 3; i++ {
+		*now = now.Add(45 * time.Minute)
+		tokens, err = svc.Refresh(tokens.RefreshToken)
+		if err != nil {
+			t.Fatalf("refresh %d: %v", i, err)
+		}
+	}
+}
+
+func TestNewRequiresSigningKey(t *testing.T) {
+	s := memstore.New()
+	if _, err := New(s.Sessions, Config{}); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("no key: err = %v", err)
+	}
+	short := Keyring{Active: Key{ID: "k1", Secret: []byte("short")}}
+	if _, err := New(s.Sessions, Config{Keys: short}); err == nil {
+		t.Error("short secret accepted")
+	}
+}
+
+// sign issues a token for a fresh session with the given claims and header
+// tweaks, signed with the key.
+func sign(t *testing.T, svc *Service, userID uint, method jwt.SigningMethod, key interface{}, edit func(*jwt.Token, *Claims)) string {
+	t.Helper()
+
+	session := models.Session{UserID: userID, RefreshTokenHash: "x", ExpiresAt: svc.now().Add(time.Hour)}
+	if err := svc.sessions.Create(&session); err != nil {
+		t.Fatal(err)
+	}
+
+	now := svc.now()
+	claims := Claims{
+		UserID:    userID,
+		SessionID: session.ID,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    DefaultIssuer,
+			Audience:  DefaultAudience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(time.Minute).Unix(),
+		},
+	}
+	token := jwt.NewWithClaims(method, &claims)
+	token.Header["kid"] = "k1"
+	if edit != nil {
+		edit(token, &claims)
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestAuthenticateRejectsInvalidTokens(t *testing.T) {
+	svc, userID, now := newTestService(t)
+	secret := testKey("k1").Secret
+
+	if _, err := svc.Authenticate(sign(t, svc, userID, jwt.SigningMethodHS256, secret, nil)); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+
+	cases := map[string]string{
+		"alg none": sign(t, svc, userID, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, nil),
+		"HS512":    sign(t, svc, userID, jwt.SigningMethodHS512, secret, nil),
+		"missing kid": sign(t, svc, userID, jwt.SigningMethodHS256, secret, func(tok *jwt.Token, _ *Claims) {
+			delete(tok.Header, "kid")
+		}),
+		"unknown kid": sign(t, svc, userID, jwt.SigningMethodHS256, secret, func(tok *jwt.Token, _ *Claims) {
+			tok.Header["kid"] = "k9"
+		}),
+		"wrong issuer": sign(t, svc, userID, jwt.SigningMethodHS256, secret, func(_ *jwt.Token, c *Claims) {
+			c.Issuer = "someone-else"
+		}),
+		"missing audience": sign(t, svc, userID, jwt.SigningMethodHS256, secret, func(_ *jwt.Token, c *Claims) {
+			c.Audience = ""
+		}),
+		"missing exp": sign(t, svc, userID, jwt.SigningMethodHS256, secret, func(_ *jwt.Token, c *Claims) {
+			c.ExpiresAt = 0
+		}),
+		"missing nbf": sign(t, svc, userID, jwt.SigningMethodHS256, secret, func(_ *jwt.Token, c *Claims) {
+			c.NotBefore = 0
+		}),
+		"not yet valid": sign(t, svc, userID, jwt.SigningMethodHS256, secret, func(_ *jwt.Token, c *Claims) {
+			c.NotBefore = now.Add(time.Hour).Unix()
+		}),
+		"expired": sign(t, svc, userID, jwt.SigningMethodHS256, secret, func(_ *jwt.Token, c *Claims) {
+			c.ExpiresAt = now.Add(-time.Hour).Unix()
+		}),
+		"malformed": "not.a.token",
+	}
+	for name, token := range cases {
+		if _, err := svc.Authenticate(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestAuthenticateRejectsMalformedClaims(t *testing.T) {
+	svc, _, now := newTestService(t)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": "alice",
+		"sid":    1,
+		"iss":    DefaultIssuer,
+		"aud":    DefaultAudience,
+		"nbf":    now.Unix(),
+		"exp":    now.Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(testKey("k1").Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Authenticate(signed); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("string userId: err = %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	svc, userID, _ := newTestService(t)
+
+	tokens, err := svc.Login(userID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// k2 takes over signing while tokens signed with k1 stay valid
+	svc.cfg.Keys = testKeyring("k2", "k1")
+	if _, err := svc.Authenticate(tokens.AccessToken); err != nil {
+		t.Errorf("token signed with retired key: %v", err)
+	}
+
+	rotated, err := svc.Refresh(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := new(jwt.Parser).ParseUnverified(rotated.AccessToken, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := parsed.Header["kid"]; kid != "k2" {
+		t.Errorf("new token kid = %v, want k2", kid)
+	}
+
+	// Once k1 is dropped its tokens are rejected
+	svc.cfg.Keys = testKeyring("k2")
+	if _, err := svc.Authenticate(tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with dropped key: err = %v", err)
+	}
+}
+
+func TestKeyringFromEnv(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_KEYS", "")
+	if _, err := KeyringFromEnv(); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("empty environment: err = %v", err)
+	}
+
+	t.Setenv("JWT_KEYS", "old:"+string(testKey("old").Secret)+",new:"+string(testKey("new").Secret))
+	t.Setenv("JWT_ACTIVE_KID", "new")
+	keyring, err := KeyringFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyring.Active.ID != "new" || len(keyring.Retired) != 1 || keyring.Retired[0].ID != "old" {
+		t.Errorf("keyring = %+v", keyring)
+	}
+
+	t.Setenv("JWT_ACTIVE_KID", "missing")
+	if _, err := KeyringFromEnv(); err == nil {
+		t.Error("unknown active kid accepted")
+	}
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MinSecretLength is the shortest HMAC secret accepted, matching the output
+// size of SHA-256.
+const MinSecretLength = 32
+
+// ErrNoSigningKey is returned when no usable signing secret is configured.
+var ErrNoSigningKey = errors.New("auth: no JWT signing key configured")
+
+// Key is an HMAC secret identified by the kid header of the tokens it signs.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring holds the key new tokens are signed with and the retired keys that
+// are still accepted until the tokens they signed have expired.
+type Keyring struct {
+	Active  Key
+	Retired []Key
+}
+
+// Lookup returns the secret for the given kid, if it is active or retired.
+func (k Keyring) Lookup(id string) ([]byte, bool) {
+	if id == "" {
+		return nil, false
+	}
+	if k.Active.ID == id {
+		return k.Active.Secret, true
+	}
+	for _, key := range k.Retired {
+		if key.ID == id {
+			return key.Secret, true
+		}
+	}
+	return nil, false
+}
+
+// Validate checks that there is an active key, that kids are unique and
+// non-empty, and that every secret is long enough.
+func (k Keyring) Validate() error {
+	if k.Active.ID == "" || len(k.Active.Secret) == 0 {
+		return ErrNoSigningKey
+	}
+
+	seen := map[string]bool{}
+	for _, key := range append([]Key{k.Active}, k.Retired...) {
+		if key.ID == "" {
+			return errors.New("auth: every key needs a kid")
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("auth: duplicate kid %q", key.ID)
+		}
+		seen[key.ID] = true
+		if len(key.Secret) < MinSecretLength {
+			return fmt.Errorf("auth: key %q is shorter than %d bytes", key.ID, MinSecretLength)
+		}
+	}
+	return nil
+}
+
+// KeyringFromEnv builds the keyring from the environment. JWT_KEYS lists
+// comma separated kid:secret pairs and JWT_ACTIVE_KID picks the one used for
+// signing; the rest are retired. Without JWT_KEYS, JWT_SECRET is used as the
+// single active key with kid "default".
+func KeyringFromEnv() (Keyring, error) {
+	rawKeys := strings.TrimSpace(os.Getenv("JWT_KEYS"))
+	if rawKeys == "" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return Keyring{}, ErrNoSigningKey
+		}
+		keyring := Keyring{Active: Key{ID: "default", Secret: []byte(secret)}}
+		return keyring, keyring.Validate()
+	}
+
+	activeID := strings.TrimSpace(os.Getenv("JWT_ACTIVE_KID"))
+	if activeID == "" {
+		return Keyring{}, errors.New("auth: JWT_ACTIVE_KID is required when JWT_KEYS is set")
+	}
+
+	var keyring Keyring
+	for _, entry := range strings.Split(rawKeys, ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return Keyring{}, fmt.Errorf("auth: JWT_KEYS entry %q is not kid:secret", entry)
+		}
+		key := Key{ID: id, Secret: []byte(secret)}
+		if id == activeID {
+			keyring.Active = key
+		} else {
+			keyring.Retired = append(keyring.Retired, key)
+		}
+	}
+	if keyring.Active.ID == "" {
+		return Keyring{}, fmt.Errorf("auth: JWT_ACTIVE_KID %q is not in JWT_KEYS", activeID)
+	}
+
+	return keyring, keyring.Validate()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"sinkedin/models"
+	"sinkedin/store/gormstore"
+)
+
+const usage = `usage: admin <command> <username>
+
+commands:
+  grant       give the user the admin role, letting them moderate reports
+  revoke      take the admin role away again`
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	role := models.AdminRole
+	switch os.Args[1] {
+	case "grant":
+	case "revoke":
+		role = models.UserRole
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load environment variables from .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("Error loading .env file, using default values")
+	}
+
+	models.SetupDB()
+	s := gormstore.New(models.DB)
+
+	user, err := s.Users.GetByUsername(os.Args[2])
+	if err != nil {
+		log.Fatalf("Finding %s: %v", os.Args[2], err)
+	}
+	if err := s.Moderation.SetRole(user.ID, role); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%s is now a %s", user.Username, role)
+}
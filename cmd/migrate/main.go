@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+	"sinkedin/migrations"
+	"sinkedin/models"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [n]    roll back the last n migrations (default 1)
+  status      list migrations and whether they are applied`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load environment variables from .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("Error loading .env file, using default values")
+	}
+
+	models.SetupDB()
+
+	switch os.Args[1] {
+	case "up":
+		ran, err := migrations.Up(models.DB)
+		for _, m := range ran {
+			log.Printf("Applied %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(ran) == 0 {
+			log.Println("Schema is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			n, err := strconv.Atoi(os.Args[2])
+			if err != nil || n < 1 {
+				log.Fatalf("Invalid step count %q", os.Args[2])
+			}
+			steps = n
+		}
+		reverted, err := migrations.Down(models.DB, steps)
+		for _, m := range reverted {
+			log.Printf("Reverted %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(reverted) == 0 {
+			log.Println("Nothing to roll back")
+		}
+
+	case "status":
+		statuses, err := migrations.Statuses(models.DB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
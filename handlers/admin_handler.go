@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+	"sinkedin/moderation"
+	"sinkedin/store"
+)
+
+// AdminHandler serves the moderation queue. Its routes sit behind
+// middleware.RequireAdmin.
+type AdminHandler struct {
+	moderation *moderation.Service
+	actions    store.ModerationStore
+}
+
+func NewAdminHandler(s *store.Store, moderation *moderation.Service) *AdminHandler {
+	return &AdminHandler{moderation: moderation, actions: s.Moderation}
+}
+
+// respondModerationError maps the moderation service's errors to responses,
+// falling back to 500 with the given message.
+func respondModerationError(c *gin.Context, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case errors.Is(err, moderation.ErrResolved):
+		c.JSON(http.StatusConflict, gin.H{"error": "Report is already resolved"})
+	case errors.Is(err, moderation.ErrInvalidAction):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Action does not apply to this target"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// GetReports pages through the moderation queue, newest first. The status
+// query parameter narrows it to open, triaged, actioned or dismissed reports.
+func (h *AdminHandler) GetReports(c *gin.Context) {
+	status := models.ReportStatus(c.Query("status"))
+	switch status {
+	case "", models.ReportOpen, models.ReportTriaged, models.ReportActioned, models.ReportDismissed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	reports, err := h.moderation.Reports(status, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+	respondPage(c, reports)
+}
+
+// GetReport returns a report with the actions taken on it.
+func (h *AdminHandler) GetReport(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	report, actions, err := h.moderation.Report(id)
+	if err != nil {
+		respondModerationError(c, err, "Report not found", "Failed to fetch report")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report, "actions": actions})
+}
+
+func (h *AdminHandler) TriageReport(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	report, err := h.moderation.Triage(c.GetUint("userId"), id)
+	if err != nil {
+		respondModerationError(c, err, "Report not found", "Failed to triage report")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+type DismissReportInput struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+func (h *AdminHandler) DismissReport(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	// The note is optional, and so is the body
+	var input DismissReportInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.moderation.Dismiss(c.GetUint("userId"), id, input.Note)
+	if err != nil {
+		respondModerationError(c, err, "Report not found", "Failed to dismiss report")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+type ModerationActionInput struct {
+	Action models.ModerationActionType `json:"action" binding:"required,oneof=hide unhide remove warn suspend unsuspend"`
+	Note   string                      `json:"note" binding:"max=1000"`
+	// Days is how long a suspension lasts; zero uses the default.
+	Days int `json:"days" binding:"min=0,max=3650"`
+}
+
+// ActOnReport acts on the target of the report in the path, resolving the
+// report when the action deals with the target.
+func (h *AdminHandler) ActOnReport(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	var input ModerationActionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.act(c, input, moderation.Request{ReportID: &id}, "Report not found")
+}
+
+type TargetActionInput struct {
+	ModerationActionInput
+	TargetType models.ReportTargetType `json:"targetType" binding:"required,oneof=post comment user"`
+	TargetID   uint                    `json:"targetId" binding:"required"`
+}
+
+// Act acts on a post, comment or user directly, without a report. Any open
+// reports on the target are resolved all the same.
+func (h *AdminHandler) Act(c *gin.Context) {
+	var input TargetActionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := moderation.Request{TargetType: input.TargetType, TargetID: input.TargetID}
+	h.act(c, input.ModerationActionInput, req, "Target not found")
+}
+
+func (h *AdminHandler) act(c *gin.Context, input ModerationActionInput, req moderation.Request, notFound string) {
+	req.Action = input.Action
+	req.Note = input.Note
+	req.Duration = time.Duration(input.Days) * 24 * time.Hour
+
+	action, err := h.moderation.Act(c.GetUint("userId"), req)
+	if err != nil {
+		respondModerationError(c, err, notFound, "Failed to apply moderation action")
+		return
+	}
+	c.JSON(http.StatusCreated, action)
+}
+
+// GetActions pages through the audit trail, newest first. It can be narrowed
+// to one report with reportId, or to one target with targetType and targetId.
+func (h *AdminHandler) GetActions(c *gin.Context) {
+	var filter store.ActionFilter
+	if raw := c.Query("reportId"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reportId"})
+			return
+		}
+		filter.ReportID = uint(id)
+	}
+	if raw := c.Query("targetType"); raw != "" {
+		filter.TargetType = models.ReportTargetType(raw)
+		switch filter.TargetType {
+		case models.ReportedPost, models.ReportedComment, models.ReportedUser:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid targetType"})
+			return
+		}
+		id, err := strconv.ParseUint(c.Query("targetId"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid targetId"})
+			return
+		}
+		filter.TargetID = uint(id)
+	}
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	actions, err := h.actions.Actions(filter, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation actions"})
+		return
+	}
+	respondPage(c, actions)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
+	"sinkedin/store"
+	"sinkedin/timeline"
+)
+
+type BlockHandler struct {
+	blocks        store.BlockStore
+	users         store.UserStore
+	timelines     *timeline.Service
+	notifications *notifications.Service
+	events        *realtime.Hub
+}
+
+func NewBlockHandler(s *store.Store, timelines *timeline.Service, notifications *notifications.Service, events *realtime.Hub) *BlockHandler {
+	return &BlockHandler{blocks: s.Blocks, users: s.Users, timelines: timelines, notifications: notifications, events: events}
+}
+
+// refreshHidden tells the users' open event streams who is now hidden from
+// them. A failed lookup is logged and leaves the streams as they were until
+// they reconnect.
+func (h *BlockHandler) refreshHidden(userIDs ...uint) {
+	for _, id := range userIDs {
+		hidden, err := h.blocks.HiddenIDs(id)
+		if err != nil {
+			log.Printf("refreshing hidden users of user %d failed: %v", id, err)
+			continue
+		}
+		h.events.Ignore(id, hidden)
+	}
+}
+
+// target looks up the user named in the path, responding with 404 when they
+// do not exist and 400 when they are the caller.
+func (h *BlockHandler) target(c *gin.Context) (*models.User, bool) {
+	user, err := h.users.GetByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	if user.ID == c.GetUint("userId") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot block or mute yourself"})
+		return nil, false
+	}
+	return user, true
+}
+
+// Block blocks the user for the caller and removes the follows between them
+// in both directions.
+func (h *BlockHandler) Block(c *gin.Context) {
+	user, ok := h.target(c)
+	if !ok {
+		return
+	}
+	userId := c.GetUint("userId")
+
+	removed, err := h.blocks.Block(userId, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+	if removed.Following {
+		h.timelines.Unfollowed(userId, user.ID)
+		h.notifications.Unfollowed(userId, user.ID)
+	}
+	if removed.FollowedBy {
+		h.timelines.Unfollowed(user.ID, userId)
+		h.notifications.Unfollowed(user.ID, userId)
+	}
+	h.refreshHidden(userId, user.ID)
+	// Neither may watch the other's posts any longer
+	h.events.Recheck(realtime.UserTopic(userId))
+	h.events.Recheck(realtime.UserTopic(user.ID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Blocked successfully"})
+}
+
+// Unblock lifts the caller's block on the user. Their open event streams stop
+// skipping each other's activity, but the post topics the block dropped stay
+// dropped: clients subscribe to them again, over the stream or the
+// subscriptions endpoint, without having to reconnect.
+func (h *BlockHandler) Unblock(c *gin.Context) {
+	user, ok := h.target(c)
+	if !ok {
+		return
+	}
+
+	userId := c.GetUint("userId")
+
+	removed, err := h.blocks.Unblock(userId, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+		return
+	}
+	h.refreshHidden(userId, user.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unblocked successfully"})
+}
+
+// GetBlocked lists the users the caller has blocked, most recent first.
+func (h *BlockHandler) GetBlocked(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	users, err := h.blocks.Blocked(c.GetUint("userId"), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blocked users"})
+		return
+	}
+
+	respondPage(c, users)
+}
+
+// Mute hides the user's posts and comments from the caller's feeds, comment
+// listings and hashtag listings. The muted user is not told. Muting leaves
+// the caller's post topics alone, as mutes only filter listings; their
+// streams just skip the muted user's activity until they unmute.
+func (h *BlockHandler) Mute(c *gin.Context) {
+	user, ok := h.target(c)
+	if !ok {
+		return
+	}
+
+	userId := c.GetUint("userId")
+
+	if err := h.blocks.Mute(userId, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mute user"})
+		return
+	}
+	h.refreshHidden(userId)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Muted successfully"})
+}
+
+func (h *BlockHandler) Unmute(c *gin.Context) {
+	user, ok := h.target(c)
+	if !ok {
+		return
+	}
+
+	userId := c.GetUint("userId")
+
+	removed, err := h.blocks.Unmute(userId, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmute user"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not muted"})
+		return
+	}
+	h.refreshHidden(userId)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unmuted successfully"})
+}
+
+// GetMuted lists the users the caller has muted, most recent first.
+func (h *BlockHandler) GetMuted(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	users, err := h.blocks.Muted(c.GetUint("userId"), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch muted users"})
+		return
+	}
+
+	respondPage(c, users)
+}
@@ -1,131 +1,431 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
+	"sinkedin/store"
 )
 
+type CommentHandler struct {
+	comments      store.CommentStore
+	posts         store.PostStore
+	users         store.UserStore
+	blocks        store.BlockStore
+	notifications *notifications.Service
+	events        *realtime.Hub
+	viewer        viewerState
+}
+
+func NewCommentHandler(s *store.Store, notifications *notifications.Service, events *realtime.Hub) *CommentHandler {
+	return &CommentHandler{comments: s.Comments, posts: s.Posts, users: s.Users, blocks: s.Blocks, notifications: notifications, events: events, viewer: newViewerState(s)}
+}
+
+// CreateCommentInput is validated by validateCommentBody and
+// resolveCommentPost rather than binding tags, so that every problem is
+// reported per field.
 type CreateCommentInput struct {
-	PostID    *uint    `json:"postId"`
-	ParentID  *uint    `json:"parentId"`
-	Content   string   `json:"content" binding:"required"`
-	Type      string   `json:"type" binding:"required"`
-	Tags      []string `json:"tags"`
-	Hashtags  []string `json:"hashtags"`
+	PostID   *uint              `json:"postId"`
+	ParentID *uint              `json:"parentId"`
+	Content  string             `json:"content"`
+	Type     string             `json:"type"`
+	Gif      *models.GifPayload `json:"gif"`
+	Tags     []string           `json:"tags"`
+	Hashtags []string           `json:"hashtags"`
 }
 
-func CreateComment(c *gin.Context) {
+func (h *CommentHandler) CreateComment(c *gin.Context) {
 	var input CreateCommentInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	errs := validateCommentBody(models.CommentType(input.Type), input.Content, input.Gif)
+	postID, refErrs, err := h.resolveCommentPost(input.PostID, input.ParentID)
+	if err != nil && !errors.Is(err, errMissingReference) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
+	if errors.Is(err, errMissingReference) {
+		respondFieldErrors(c, http.StatusNotFound, "Post or parent comment not found", refErrs)
+		return
+	}
+	if errs = append(errs, refErrs...); len(errs) > 0 {
+		respondFieldErrors(c, http.StatusBadRequest, "Invalid comment", errs)
+		return
+	}
+
 	userId := c.GetUint("userId")
+	if !h.checkCanReply(c, userId, postID, input.ParentID) {
+		return
+	}
+
+	content, ok := resolveContent(c, h.users, h.blocks, userId, contentInput{input.Content, input.Hashtags, input.Tags}, nil)
+	if !ok {
+		return
+	}
+
 	comment := models.Comment{
 		UserID:          userId,
-		PostID:          input.PostID,
+		PostID:          &postID,
 		ParentCommentID: input.ParentID,
 		Type:            models.CommentType(input.Type),
 		Content:         input.Content,
+		Entities:        content.Entities,
+		Gif:             input.Gif,
 	}
 
-	tx := models.DB.Begin()
-
-	if err := tx.Create(&comment).Error; err != nil {
-		tx.Rollback()
+	if err := h.comments.Create(&comment, content.Hashtags, content.TagUserIDs); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post or parent comment not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
+	h.notifications.Commented(comment)
+	// Anyone able to see the post may watch its topic, so the comments of
+	// private users are left for their followers to fetch
+	if !comment.User.IsPrivate {
+		h.events.PublishFrom(userId, realtime.PostTopic(postID), realtime.CommentEvent, comment)
+	}
+	h.notifications.Tagged(userId, models.CommentTarget, comment.ID, content.TagUserIDs)
 
-	// Handle hashtags
-	if len(input.Hashtags) > 0 {
-		comment.ContainsHashtag = true
-		for _, hashtagName := range input.Hashtags {
-			var hashtag models.Hashtag
-			if err := tx.Where("name = ?", hashtagName).FirstOrCreate(&hashtag, models.Hashtag{Name: hashtagName}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process hashtags"})
-				return
-			}
-			if err := tx.Create(&models.CommentHashtag{CommentID: comment.ID, HashtagID: hashtag.ID}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate hashtags"})
-				return
-			}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// respondInvisible responds with 404 and the message for content the caller
+// may not see, or with 500 when checking failed.
+func respondInvisible(c *gin.Context, err error, message string) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check visibility"})
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": message})
+}
+
+// checkPostVisible responds with 404 and returns false when the caller may
+// not see the post, which hides its comments as well.
+func (h *CommentHandler) checkPostVisible(c *gin.Context, viewerID, postID uint) bool {
+	post, err := h.posts.GetByID(postID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return false
+	}
+	visible, err := h.viewer.canSeePost(viewerID, *post)
+	if err != nil || !visible {
+		respondInvisible(c, err, "Post not found")
+		return false
+	}
+	return true
+}
+
+// checkCommentVisible responds with 404 and returns false when the caller
+// may not see the comment or the post it belongs to.
+func (h *CommentHandler) checkCommentVisible(c *gin.Context, viewerID uint, comment *models.Comment) bool {
+	var post *models.Post
+	if comment.PostID != nil {
+		var err error
+		if post, err = h.posts.GetByID(*comment.PostID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return false
 		}
 	}
+	visible, err := h.viewer.canSeeComment(viewerID, *comment, post)
+	if err != nil || !visible {
+		respondInvisible(c, err, "Comment not found")
+		return false
+	}
+	return true
+}
 
-	// Handle tags
-	if len(input.Tags) > 0 {
-		comment.ContainsTag = true
-		for _, username := range input.Tags {
-			var taggedUser models.User
-			if err := tx.Where("username = ?", username).First(&taggedUser).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid tagged user"})
-				return
-			}
-			if err := tx.Create(&models.CommentTag{CommentID: comment.ID, UserID: taggedUser.ID}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate tags"})
-				return
-			}
+// checkCanReply responds with 403 and returns false when the post's reply
+// policy leaves the user out, or when the post's author or the parent
+// comment's author has blocked the user or been blocked by them.
+func (h *CommentHandler) checkCanReply(c *gin.Context, userID, postID uint, parentID *uint) bool {
+	post, err := h.posts.GetByID(postID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return false
+	}
+	// Content the user may not see cannot be replied to either. Blocks are
+	// checked below, to be refused rather than hidden
+	if visible, err := h.viewer.allowsPost(userID, *post); err != nil || !visible {
+		respondInvisible(c, err, "Post not found")
+		return false
+	}
+	allowed, err := h.viewer.mayReply(userID, *post)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return false
+	}
+	if !allowed {
+		message := "Replies to this post are restricted"
+		if post.ReplyPolicy == models.NoReplies {
+			message = "Replies to this post are turned off"
 		}
+		c.JSON(http.StatusForbidden, gin.H{"error": message})
+		return false
+	}
+	authorIDs := []uint{post.UserID}
+	if parentID != nil {
+		parent, err := h.comments.GetByID(*parentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+			return false
+		}
+		authorIDs = append(authorIDs, parent.UserID)
 	}
 
-	// Update comment count on parent
-	if comment.PostID != nil {
-		tx.Model(&models.Post{}).Where("id = ?", *comment.PostID).UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1))
+	blocking, err := h.blocks.Blocking(userID, authorIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return false
+	}
+	if len(blocking) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot reply to a blocked user"})
+		return false
 	}
-	if comment.ParentCommentID != nil {
-		tx.Model(&models.Comment{}).Where("id = ?", *comment.ParentCommentID).UpdateColumn("comment_count", gorm.Expr("comment_count + ?", 1))
+	return true
+}
+
+func (h *CommentHandler) GetPostComments(c *gin.Context) {
+	postId, ok := parseID(c, "postId")
+	if !ok {
+		return
 	}
 
-	tx.Commit()
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
 
-	// Load the complete comment with associations
-	models.DB.Preload("User").Preload("Tags").Preload("Hashtags").First(&comment, comment.ID)
+	userId := c.GetUint("userId")
+	if !h.checkPostVisible(c, userId, postId) {
+		return
+	}
 
-	c.JSON(http.StatusCreated, comment)
+	comments, err := h.comments.ListByPost(postId, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+	if comments.Items, err = h.viewer.visibleComments(userId, comments.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+	if err := h.viewer.annotateComments(userId, comments.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	respondPage(c, comments)
 }
 
-func GetPostComments(c *gin.Context) {
-	postId := c.Param("postId")
+func (h *CommentHandler) GetComment(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
 
-	var comments []models.Comment
-	if err := models.DB.Preload("User").Preload("Tags").Preload("Hashtags").
-		Where("post_id = ? AND parent_comment_id IS NULL", postId).
-		Order("created_at desc").Find(&comments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+	comment, err := h.comments.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+	userId := c.GetUint("userId")
+	if !h.checkCommentVisible(c, userId, comment) {
+		return
+	}
+
+	comments := []models.Comment{*comment}
+	if err := h.viewer.annotateComments(userId, comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment"})
 		return
 	}
 
-	c.JSON(http.StatusOK, comments)
+	c.JSON(http.StatusOK, comments[0])
 }
 
-func GetComment(c *gin.Context) {
-	id := c.Param("id")
+// GetCommentReplies lists the direct replies to a comment, newest first.
+func (h *CommentHandler) GetCommentReplies(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
 
-	var comment models.Comment
-	if err := models.DB.Preload("User").Preload("Tags").Preload("Hashtags").
-		Preload("ParentComment").First(&comment, id).Error; err != nil {
+	comment, err := h.comments.GetByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 		return
 	}
+	userId := c.GetUint("userId")
+	if !h.checkCommentVisible(c, userId, comment) {
+		return
+	}
 
-	c.JSON(http.StatusOK, comment)
+	replies, err := h.comments.ListReplies(id, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch replies"})
+		return
+	}
+	if replies.Items, err = h.viewer.visibleComments(userId, replies.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch replies"})
+		return
+	}
+	if err := h.viewer.annotateComments(userId, replies.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch replies"})
+		return
+	}
+
+	respondPage(c, replies)
+}
+
+// commentThreadResponse is a comment with its nested replies. When
+// moreReplies is set, the rest can be fetched from /api/comments/:id/replies,
+// starting at nextCursor if it is present.
+type commentThreadResponse struct {
+	models.Comment
+	Replies     []commentThreadResponse `json:"replies"`
+	MoreReplies bool                    `json:"moreReplies"`
+	NextCursor  *string                 `json:"nextCursor"`
+}
+
+// GetCommentThread returns a comment with its replies nested below it. The
+// depth query parameter bounds how many levels are included and replies how
+// many of the newest replies each comment shows.
+func (h *CommentHandler) GetCommentThread(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	opts, ok := parseThreadOptions(c)
+	if !ok {
+		return
+	}
+
+	thread, err := h.comments.Thread(id, opts)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment thread"})
+		return
+	}
+	userId := c.GetUint("userId")
+	if !h.checkCommentVisible(c, userId, &thread.Comment) {
+		return
+	}
+
+	// Annotate every comment in the thread with one batch, leaving out the
+	// replies the caller may not see along with the replies below them
+	var authors []models.User
+	var gather func(t store.CommentThread)
+	gather = func(t store.CommentThread) {
+		for _, reply := range t.Replies {
+			authors = append(authors, reply.Comment.User)
+			gather(reply)
+		}
+	}
+	gather(*thread)
+	audience, err := h.viewer.audience(userId, authors, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment thread"})
+		return
+	}
+	var flat []models.Comment
+	var collect func(t *store.CommentThread)
+	collect = func(t *store.CommentThread) {
+		flat = append(flat, t.Comment)
+		visible := t.Replies[:0]
+		for _, reply := range t.Replies {
+			if audience.canSeeComment(reply.Comment) {
+				visible = append(visible, reply)
+			}
+		}
+		t.Replies = visible
+		for i := range t.Replies {
+			collect(&t.Replies[i])
+		}
+	}
+	collect(thread)
+	if err := h.viewer.annotateComments(userId, flat); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comment thread"})
+		return
+	}
+	annotated := make(map[uint]models.Comment, len(flat))
+	for _, comment := range flat {
+		annotated[comment.ID] = comment
+	}
+
+	var respond func(t store.CommentThread) commentThreadResponse
+	respond = func(t store.CommentThread) commentThreadResponse {
+		node := commentThreadResponse{
+			Comment:     annotated[t.Comment.ID],
+			Replies:     make([]commentThreadResponse, 0, len(t.Replies)),
+			MoreReplies: t.HasMore,
+		}
+		for _, reply := range t.Replies {
+			node.Replies = append(node.Replies, respond(reply))
+		}
+		if t.Next != nil {
+			next := t.Next.Encode()
+			node.NextCursor = &next
+		}
+		return node
+	}
+
+	c.JSON(http.StatusOK, respond(*thread))
 }
 
-func UpdateComment(c *gin.Context) {
-	id := c.Param("id")
+// parseThreadOptions reads the depth and replies query parameters, clamping
+// them to the allowed maximums and responding with 400 when one is malformed.
+func parseThreadOptions(c *gin.Context) (store.ThreadOptions, bool) {
+	opts := store.ThreadOptions{Depth: store.DefaultThreadDepth, Replies: store.DefaultThreadReplies}
+
+	if raw := c.Query("depth"); raw != "" {
+		depth, err := strconv.Atoi(raw)
+		if err != nil || depth < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid depth"})
+			return opts, false
+		}
+		opts.Depth = min(depth, store.MaxThreadDepth)
+	}
+
+	if raw := c.Query("replies"); raw != "" {
+		replies, err := strconv.Atoi(raw)
+		if err != nil || replies < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid replies limit"})
+			return opts, false
+		}
+		opts.Replies = min(replies, store.MaxThreadReplies)
+	}
+
+	return opts, true
+}
+
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
 	userId := c.GetUint("userId")
 
-	var comment models.Comment
-	if err := models.DB.First(&comment, id).Error; err != nil {
+	comment, err := h.comments.GetByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 		return
 	}
@@ -141,19 +441,41 @@ func UpdateComment(c *gin.Context) {
 		return
 	}
 
+	if errs := validateCommentBody(models.CommentType(input.Type), input.Content, input.Gif); len(errs) > 0 {
+		respondFieldErrors(c, http.StatusBadRequest, "Invalid comment", errs)
+		return
+	}
+
+	// Omitted tags or hashtags keep the ones that were given explicitly
+	previous := previousContent{Entities: comment.Entities, Hashtags: comment.Hashtags, Tags: comment.Tags}
+	content, ok := resolveContent(c, h.users, h.blocks, userId, contentInput{input.Content, input.Hashtags, input.Tags}, &previous)
+	if !ok {
+		return
+	}
+
 	comment.Content = input.Content
+	comment.Entities = content.Entities
 	comment.Type = models.CommentType(input.Type)
+	comment.Gif = input.Gif
 
-	models.DB.Save(&comment)
+	tagged, err := h.comments.Update(comment, content.Hashtags, content.TagUserIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+	h.notifications.Tagged(comment.UserID, models.CommentTarget, comment.ID, tagged)
 	c.JSON(http.StatusOK, comment)
 }
 
-func DeleteComment(c *gin.Context) {
-	id := c.Param("id")
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
 	userId := c.GetUint("userId")
 
-	var comment models.Comment
-	if err := models.DB.First(&comment, id).Error; err != nil {
+	comment, err := h.comments.GetByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 		return
 	}
@@ -163,22 +485,10 @@ func DeleteComment(c *gin.Context) {
 		return
 	}
 
-	tx := models.DB.Begin()
-
-	// Update comment count on parent
-	if comment.PostID != nil {
-		tx.Model(&models.Post{}).Where("id = ?", *comment.PostID).UpdateColumn("comment_count", gorm.Expr("comment_count - ?", 1))
-	}
-	if comment.ParentCommentID != nil {
-		tx.Model(&models.Comment{}).Where("id = ?", *comment.ParentCommentID).UpdateColumn("comment_count", gorm.Expr("comment_count - ?", 1))
-	}
-
-	if err := tx.Delete(&comment).Error; err != nil {
-		tx.Rollback()
+	if err := h.comments.Delete(comment); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
 		return
 	}
 
-	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
 }
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+const (
+	maxCommentLength = 500
+	maxGifURLLength  = 2048
+	maxGifDimension  = 4096
+	// maxParentChain bounds the walk up a reply chain when deriving the post
+	// of a reply whose ancestors predate PostID being stored on replies.
+	maxParentChain = 100
+)
+
+// fieldError describes why a single request field was rejected.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// respondFieldErrors writes the structured error response used for request
+// validation failures.
+func respondFieldErrors(c *gin.Context, status int, message string, errs []fieldError) {
+	c.JSON(status, gin.H{"error": message, "fields": errs})
+}
+
+// validateCommentBody checks the type, content and GIF payload shared by
+// comment creation and updates.
+func validateCommentBody(commentType models.CommentType, content string, gif *models.GifPayload) []fieldError {
+	var errs []fieldError
+
+	switch commentType {
+	case models.NormalComment:
+		if strings.TrimSpace(content) == "" {
+			errs = append(errs, fieldError{"content", "is required"})
+		}
+		if gif != nil {
+			errs = append(errs, fieldError{"gif", "is only allowed on gif comments"})
+		}
+	case models.GifComment:
+		if gif == nil {
+			errs = append(errs, fieldError{"gif", "is required for gif comments"})
+		} else {
+			errs = append(errs, validateGif(gif)...)
+		}
+	case "":
+		errs = append(errs, fieldError{"type", "is required"})
+	default:
+		errs = append(errs, fieldError{"type", `must be "normal" or "gif"`})
+	}
+
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		errs = append(errs, fieldError{"content", "must be at most 500 characters"})
+	}
+	return errs
+}
+
+func validateGif(gif *models.GifPayload) []fieldError {
+	var errs []fieldError
+	if gif.URL == "" {
+		errs = append(errs, fieldError{"gif.url", "is required"})
+	} else if !isWebURL(gif.URL) {
+		errs = append(errs, fieldError{"gif.url", "must be an http or https URL"})
+	}
+	if gif.PreviewURL != "" && !isWebURL(gif.PreviewURL) {
+		errs = append(errs, fieldError{"gif.previewUrl", "must be an http or https URL"})
+	}
+	if gif.Width < 1 || gif.Width > maxGifDimension {
+		errs = append(errs, fieldError{"gif.width", "must be between 1 and 4096"})
+	}
+	if gif.Height < 1 || gif.Height > maxGifDimension {
+		errs = append(errs, fieldError{"gif.height", "must be between 1 and 4096"})
+	}
+	if len(gif.Provider) > 50 {
+		errs = append(errs, fieldError{"gif.provider", "must be at most 50 characters"})
+	}
+	return errs
+}
+
+func isWebURL(raw string) bool {
+	if len(raw) > maxGifURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// errMissingReference is returned by resolveCommentPost when the post or
+// parent comment does not exist.
+var errMissingReference = errors.New("missing reference")
+
+// resolveCommentPost works out which post a new comment belongs to. Replies
+// take the post of their parent chain; a postId given alongside parentId must
+// agree with it. It returns field errors for orphans and cross-post replies,
+// and errMissingReference with field errors when a referenced post or comment
+// does not exist.
+func (h *CommentHandler) resolveCommentPost(postID, parentID *uint) (uint, []fieldError, error) {
+	if postID == nil && parentID == nil {
+		return 0, []fieldError{{"postId", "postId or parentId is required"}}, nil
+	}
+
+	if parentID == nil {
+		if _, err := h.posts.GetByID(*postID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return 0, []fieldError{{"postId", "post does not exist"}}, errMissingReference
+			}
+			return 0, nil, err
+		}
+		return *postID, nil, nil
+	}
+
+	derived, err := h.postOfComment(*parentID)
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, []fieldError{{"parentId", "parent comment does not exist"}}, errMissingReference
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if postID != nil && *postID != derived {
+		return 0, []fieldError{{"parentId", "parent comment belongs to a different post"}}, nil
+	}
+
+	if _, err := h.posts.GetByID(derived); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return 0, []fieldError{{"parentId", "parent comment's post no longer exists"}}, errMissingReference
+		}
+		return 0, nil, err
+	}
+	return derived, nil, nil
+}
+
+// postOfComment follows the parent chain up from a comment until it finds the
+// post it belongs to.
+func (h *CommentHandler) postOfComment(commentID uint) (uint, error) {
+	id := commentID
+	for i := 0; i < maxParentChain; i++ {
+		comment, err := h.comments.GetByID(id)
+		if err != nil {
+			return 0, err
+		}
+		if comment.PostID != nil {
+			return *comment.PostID, nil
+		}
+		if comment.ParentCommentID == nil {
+			return 0, store.ErrNotFound
+		}
+		id = *comment.ParentCommentID
+	}
+	return 0, store.ErrNotFound
+}
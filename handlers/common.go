@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/store"
+)
+
+// parseID reads a numeric path parameter, responding with 400 when it is not
+// a valid ID.
+func parseID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// resolveTags looks up the IDs of the tagged usernames, responding with 400
+// when one of them does not exist.
+func resolveTags(c *gin.Context, users store.UserStore, usernames []string) ([]uint, bool) {
+	ids := make([]uint, 0, len(usernames))
+	for _, username := range usernames {
+		user, err := users.GetByUsername(username)
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tagged user"})
+			return nil, false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process tags"})
+			return nil, false
+		}
+		ids = append(ids, user.ID)
+	}
+	return ids, true
+}
+
+// checkNotBlocked responds with 403 and returns false when the caller and
+// otherID have blocked each other, in either direction.
+func checkNotBlocked(c *gin.Context, blocks store.BlockStore, userID, otherID uint, message string) bool {
+	blocking, err := blocks.Blocking(userID, []uint{otherID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check blocks"})
+		return false
+	}
+	if blocking[otherID] {
+		c.JSON(http.StatusForbidden, gin.H{"error": message})
+		return false
+	}
+	return true
+}
+
+// pageResponse is the envelope shared by every paginated listing.
+type pageResponse[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+// parsePage reads the limit and cursor query parameters, responding with 400
+// when either is malformed.
+func parsePage(c *gin.Context) (store.PageRequest, bool) {
+	page := store.PageRequest{Limit: store.DefaultPageLimit}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return page, false
+		}
+		if limit > store.MaxPageLimit {
+			limit = store.MaxPageLimit
+		}
+		page.Limit = limit
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := store.DecodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return page, false
+		}
+		page.After = cursor
+	}
+
+	return page, true
+}
+
+func respondPage[T any](c *gin.Context, page store.Page[T]) {
+	response := pageResponse[T]{Data: page.Items}
+	if page.Next != nil {
+		next := page.Next.Encode()
+		response.NextCursor = &next
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parseBoolQuery reads an optional boolean query parameter, responding with
+// 400 when it is malformed.
+func parseBoolQuery(c *gin.Context, name string) (*bool, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return nil, false
+	}
+	return &value, true
+}
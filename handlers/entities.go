@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+	"sinkedin/store"
+	"sinkedin/textparse"
+)
+
+// contentInput is the part of a post or comment request that determines its
+// hashtags and tagged users: the #hashtags and @mentions written in the
+// content plus the explicit hashtags and tags arrays.
+type contentInput struct {
+	Content  string
+	Hashtags []string
+	Tags     []string
+}
+
+// resolvedContent is the outcome of resolveContent, ready to hand to a store.
+type resolvedContent struct {
+	Entities   []models.Entity
+	Hashtags   []string
+	TagUserIDs []uint
+}
+
+// previousContent describes a post or comment before an edit. Hashtags and
+// tags that came from its old content are dropped unless the new content
+// still has them, while ones that were only given explicitly are kept.
+type previousContent struct {
+	Entities []models.Entity
+	Hashtags []models.Hashtag
+	Tags     []models.User
+}
+
+// resolveContent extracts the entities in the content and merges them with
+// the explicit arrays. Mentions of unknown users are not linked, but an
+// unknown username in the tags array is rejected. Users the author has
+// blocked or been blocked by are never tagged: naming one in the tags array
+// is rejected with 403 and mentions of them are not linked. When editing,
+// previous is the current state; omitted arrays then keep their explicit
+// entries. It responds with 400, 403 or 500 and returns false on failure.
+func resolveContent(c *gin.Context, users store.UserStore, blocks store.BlockStore, authorID uint, input contentInput, previous *previousContent) (resolvedContent, bool) {
+	var resolved resolvedContent
+
+	explicitHashtags := make([]string, 0, len(input.Hashtags))
+	for _, name := range input.Hashtags {
+		normalized, ok := textparse.NormalizeHashtag(name)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hashtag"})
+			return resolved, false
+		}
+		explicitHashtags = append(explicitHashtags, normalized)
+	}
+
+	explicitTags, ok := resolveTags(c, users, input.Tags)
+	if !ok {
+		return resolved, false
+	}
+
+	entities, err := linkMentions(users, textparse.Extract(input.Content))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process content"})
+		return resolved, false
+	}
+
+	blocking, err := blocks.Blocking(authorID, unique(append(explicitTags, entityMentions(entities)...)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process tags"})
+		return resolved, false
+	}
+	for _, id := range explicitTags {
+		if blocking[id] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot tag a blocked user"})
+			return resolved, false
+		}
+	}
+	resolved.Entities = unlinkMentions(entities, blocking)
+
+	if previous != nil {
+		if input.Hashtags == nil {
+			explicitHashtags = without(hashtagNames(previous.Hashtags), entityHashtags(previous.Entities))
+		}
+		if input.Tags == nil {
+			// Users blocked since the last edit are dropped
+			kept := without(userIDs(previous.Tags), entityMentions(previous.Entities))
+			blocked, err := blocks.Blocking(authorID, kept)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process tags"})
+				return resolved, false
+			}
+			explicitTags = make([]uint, 0, len(kept))
+			for _, id := range kept {
+				if !blocked[id] {
+					explicitTags = append(explicitTags, id)
+				}
+			}
+		}
+	}
+
+	resolved.Hashtags = unique(append(explicitHashtags, entityHashtags(resolved.Entities)...))
+	resolved.TagUserIDs = unique(append(explicitTags, entityMentions(resolved.Entities)...))
+	return resolved, true
+}
+
+// unlinkMentions drops the mentions of the given users, leaving their names
+// as plain text.
+func unlinkMentions(entities []models.Entity, users map[uint]bool) []models.Entity {
+	if len(users) == 0 {
+		return entities
+	}
+	linked := make([]models.Entity, 0, len(entities))
+	for _, entity := range entities {
+		if entity.Type != models.MentionEntity || !users[entity.UserID] {
+			linked = append(linked, entity)
+		}
+	}
+	return linked
+}
+
+// linkMentions sets the user ID of each mention, dropping mentions of users
+// that do not exist.
+func linkMentions(users store.UserStore, entities []models.Entity) ([]models.Entity, error) {
+	ids := map[string]uint{}
+	linked := make([]models.Entity, 0, len(entities))
+	for _, entity := range entities {
+		if entity.Type == models.MentionEntity {
+			id, seen := ids[entity.Text]
+			if !seen {
+				user, err := users.GetByUsername(entity.Text)
+				if err != nil && !errors.Is(err, store.ErrNotFound) {
+					return nil, err
+				}
+				if user != nil {
+					id = user.ID
+				}
+				ids[entity.Text] = id
+			}
+			if id == 0 {
+				continue
+			}
+			entity.UserID = id
+		}
+		linked = append(linked, entity)
+	}
+	return linked, nil
+}
+
+func entityHashtags(entities []models.Entity) []string {
+	var names []string
+	for _, entity := range entities {
+		if entity.Type == models.HashtagEntity {
+			names = append(names, entity.Text)
+		}
+	}
+	return names
+}
+
+func entityMentions(entities []models.Entity) []uint {
+	var ids []uint
+	for _, entity := range entities {
+		if entity.Type == models.MentionEntity && entity.UserID != 0 {
+			ids = append(ids, entity.UserID)
+		}
+	}
+	return ids
+}
+
+func hashtagNames(hashtags []models.Hashtag) []string {
+	names := make([]string, 0, len(hashtags))
+	for _, hashtag := range hashtags {
+		names = append(names, hashtag.Name)
+	}
+	return names
+}
+
+func userIDs(users []models.User) []uint {
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+// unique drops repeated values, keeping the first occurrence of each. It
+// never returns nil, so the stores treat the result as a full replacement.
+func unique[T comparable](values []T) []T {
+	seen := make(map[T]bool, len(values))
+	result := make([]T, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// without returns the values not in drop.
+func without[T comparable](values, drop []T) []T {
+	dropped := make(map[T]bool, len(drop))
+	for _, v := range drop {
+		dropped[v] = true
+	}
+	result := make([]T, 0, len(values))
+	for _, v := range values {
+		if !dropped[v] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
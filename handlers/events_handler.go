@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"sinkedin/realtime"
+	"sinkedin/store"
+	"sinkedin/timeline"
+)
+
+// eventWriteTimeout bounds how long a write to a stream may block, so that a
+// client that stopped reading is dropped.
+const eventWriteTimeout = 10 * time.Second
+
+type EventsHandler struct {
+	hub       *realtime.Hub
+	timelines *timeline.Service
+	posts     store.PostStore
+	blocks    store.BlockStore
+	viewer    viewerState
+}
+
+// NewEventsHandler also has the hub recheck subscriptions against the same
+// rules as subscribing.
+func NewEventsHandler(s *store.Store, hub *realtime.Hub, timelines *timeline.Service) *EventsHandler {
+	h := &EventsHandler{hub: hub, timelines: timelines, posts: s.Posts, blocks: s.Blocks, viewer: newViewerState(s)}
+	hub.Authorize(h.authorized)
+	return h
+}
+
+// controlEvent builds an event about the stream itself. Its data is always
+// a plain map, which cannot fail to encode.
+func controlEvent(eventType string, data gin.H) realtime.Event {
+	e, _ := realtime.NewEvent("", eventType, data)
+	return e
+}
+
+// checkTopics accepts the topics clients may subscribe to themselves, which
+// are those of existing posts the viewer may see. It returns the status and
+// message to reject them with otherwise.
+func (h *EventsHandler) checkTopics(viewerID uint, topics []string) (int, string) {
+	for _, t := range topics {
+		kind, id, err := realtime.ParseTopic(t)
+		if err != nil {
+			return http.StatusBadRequest, "Invalid topic " + t
+		}
+		if kind != realtime.PostKind {
+			return http.StatusBadRequest, "Cannot subscribe to " + t
+		}
+		post, err := h.posts.GetByID(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return http.StatusNotFound, "Post not found"
+			}
+			return http.StatusInternalServerError, "Failed to subscribe"
+		}
+		visible, err := h.viewer.canSeePost(viewerID, *post)
+		if err != nil {
+			return http.StatusInternalServerError, "Failed to subscribe"
+		}
+		if !visible {
+			return http.StatusNotFound, "Post not found"
+		}
+	}
+	return 0, ""
+}
+
+// authorized reports whether the user may go on receiving a topic they
+// subscribed to.
+func (h *EventsHandler) authorized(userID uint, topic string) (bool, error) {
+	switch status, message := h.checkTopics(userID, []string{topic}); status {
+	case 0:
+		return true, nil
+	case http.StatusInternalServerError:
+		return false, errors.New(message)
+	}
+	return false, nil
+}
+
+func (h *EventsHandler) subscribe(sub *realtime.Subscription, topics []string) (int, string) {
+	if status, message := h.checkTopics(sub.UserID, topics); status != 0 {
+		return status, message
+	}
+	if err := sub.Subscribe(topics...); err != nil {
+		return http.StatusBadRequest, "Too many topics"
+	}
+	return 0, ""
+}
+
+// connect subscribes the caller to their own events, their live feed and the
+// comma-separated topics in the topics query parameter, skipping events
+// caused by users hidden from them. It responds with an error when that
+// fails.
+func (h *EventsHandler) connect(c *gin.Context) (*realtime.Subscription, bool) {
+	userID := c.GetUint("userId")
+	var topics []string
+	if raw := c.Query("topics"); raw != "" {
+		topics = strings.Split(raw, ",")
+	}
+
+	feed, err := h.timelines.FeedTopics(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open event stream"})
+		return nil, false
+	}
+	hidden, err := h.blocks.HiddenIDs(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open event stream"})
+		return nil, false
+	}
+	sub, err := h.hub.Connect(userID, feed...)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to open event stream"})
+		return nil, false
+	}
+	sub.Ignore(hidden)
+	if status, message := h.subscribe(sub, topics); status != 0 {
+		sub.Close()
+		c.JSON(status, gin.H{"error": message})
+		return nil, false
+	}
+	return sub, true
+}
+
+func readyEvent(sub *realtime.Subscription) realtime.Event {
+	return controlEvent(realtime.ReadyEvent, gin.H{"connectionId": sub.ID, "topics": sub.Topics()})
+}
+
+// endEvent tells the client why the server ended the subscription.
+func endEvent(sub *realtime.Subscription) realtime.Event {
+	message := "Stream closed"
+	if err := sub.Err(); err != nil {
+		message = err.Error()
+	}
+	return controlEvent(realtime.ErrorEvent, gin.H{"error": message})
+}
+
+// pump writes the subscription's events, and a heartbeat whenever the stream
+// has been idle for the hub's interval, until the client goes away, the
+// subscription ends or a write fails. Other events to write may be fed in
+// through extra.
+func (h *EventsHandler) pump(sub *realtime.Subscription, gone <-chan struct{}, extra <-chan realtime.Event, write func(realtime.Event) error) {
+	if write(readyEvent(sub)) != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.hub.Heartbeat())
+	defer heartbeat.Stop()
+	for {
+		var e realtime.Event
+		select {
+		case <-gone:
+			return
+		case <-sub.Done():
+			write(endEvent(sub))
+			return
+		case e = <-sub.Events():
+		case e = <-extra:
+		case <-heartbeat.C:
+			e = controlEvent(realtime.HeartbeatEvent, nil)
+		}
+		if write(e) != nil {
+			return
+		}
+		heartbeat.Reset(h.hub.Heartbeat())
+	}
+}
+
+// Stream sends the caller's events as Server-Sent Events. The ready event
+// carries the connection ID that UpdateSubscriptions takes.
+func (h *EventsHandler) Stream(c *gin.Context) {
+	sub, ok := h.connect(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	rc := http.NewResponseController(c.Writer)
+	h.pump(sub, c.Request.Context().Done(), nil, func(e realtime.Event) error {
+		// Not every writer supports deadlines; those that do not never block
+		_ = rc.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", e.Type, payload); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+}
+
+type UpdateSubscriptionsInput struct {
+	Subscribe   []string `json:"subscribe"`
+	Unsubscribe []string `json:"unsubscribe"`
+}
+
+// UpdateSubscriptions changes the topics of one of the caller's open
+// connections, for SSE clients that cannot send over the stream itself.
+func (h *EventsHandler) UpdateSubscriptions(c *gin.Context) {
+	var input UpdateSubscriptionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, ok := h.hub.Lookup(c.Param("connection"), c.GetUint("userId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+		return
+	}
+
+	sub.Unsubscribe(input.Unsubscribe...)
+	if status, message := h.subscribe(sub, input.Subscribe); status != 0 {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"topics": sub.Topics()})
+}
+
+// socketMessage is a request sent by a WebSocket client: subscribe or
+// unsubscribe with topics, or ping.
+type socketMessage struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics"`
+}
+
+// WebSocket sends the caller's events over a WebSocket, on which the client
+// may also change its subscriptions.
+func (h *EventsHandler) WebSocket(c *gin.Context) {
+	sub, ok := h.connect(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	// Tokens never travel in cookies, so cross-origin connections are safe
+	// and the origin is not checked
+	server := websocket.Server{Handler: func(ws *websocket.Conn) { h.serveSocket(ws, sub) }}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *EventsHandler) serveSocket(ws *websocket.Conn, sub *realtime.Subscription) {
+	replies := make(chan realtime.Event)
+	gone := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		defer close(gone)
+		for {
+			var msg socketMessage
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				return
+			}
+			select {
+			case replies <- h.handleSocketMessage(sub, msg):
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	h.pump(sub, gone, replies, func(e realtime.Event) error {
+		if err := ws.SetWriteDeadline(time.Now().Add(eventWriteTimeout)); err != nil {
+			return err
+		}
+		return websocket.JSON.Send(ws, e)
+	})
+}
+
+func (h *EventsHandler) handleSocketMessage(sub *realtime.Subscription, msg socketMessage) realtime.Event {
+	switch msg.Action {
+	case "subscribe":
+		if status, message := h.subscribe(sub, msg.Topics); status != 0 {
+			return controlEvent(realtime.ErrorEvent, gin.H{"error": message})
+		}
+	case "unsubscribe":
+		sub.Unsubscribe(msg.Topics...)
+	case "ping":
+		return controlEvent(realtime.HeartbeatEvent, nil)
+	default:
+		return controlEvent(realtime.ErrorEvent, gin.H{"error": "Unknown action " + msg.Action})
+	}
+	return controlEvent(realtime.SubscribedEvent, gin.H{"topics": sub.Topics()})
+}
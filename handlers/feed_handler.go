@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/store"
+	"sinkedin/timeline"
+)
+
+type FeedHandler struct {
+	timelines *timeline.Service
+	viewer    viewerState
+}
+
+func NewFeedHandler(s *store.Store, timelines *timeline.Service) *FeedHandler {
+	return &FeedHandler{timelines: timelines, viewer: newViewerState(s)}
+}
+
+// GetFeed returns the caller's home timeline: their own posts and posts from
+// the users they follow, newest first. Pass includeTagged=true to also see
+// posts the caller was tagged in.
+func (h *FeedHandler) GetFeed(c *gin.Context) {
+	userId := c.GetUint("userId")
+	includeTagged := c.Query("includeTagged") == "true"
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	posts, err := h.timelines.Feed(userId, includeTagged, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+		return
+	}
+	if posts.Items, err = h.viewer.visiblePosts(userId, posts.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+		return
+	}
+	if err := h.viewer.annotatePosts(userId, posts.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+		return
+	}
+
+	respondPage(c, posts)
+}
@@ -1,19 +1,35 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/store"
+	"sinkedin/timeline"
 )
 
-func ToggleFollow(c *gin.Context) {
+type FollowHandler struct {
+	follows       store.FollowStore
+	requests      store.FollowRequestStore
+	users         store.UserStore
+	blocks        store.BlockStore
+	timelines     *timeline.Service
+	notifications *notifications.Service
+}
+
+func NewFollowHandler(s *store.Store, timelines *timeline.Service, notifications *notifications.Service) *FollowHandler {
+	return &FollowHandler{follows: s.Follows, requests: s.FollowRequests, users: s.Users, blocks: s.Blocks, timelines: timelines, notifications: notifications}
+}
+
+func (h *FollowHandler) ToggleFollow(c *gin.Context) {
 	username := c.Param("username")
 	followerId := c.GetUint("userId")
 
-	var targetUser models.User
-	if err := models.DB.Where("username = ?", username).First(&targetUser).Error; err != nil {
+	targetUser, err := h.users.GetByUsername(username)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -23,89 +39,219 @@ func ToggleFollow(c *gin.Context) {
 		return
 	}
 
-	var follow models.Follow
-	result := models.DB.Where("follower_id = ? AND following_id = ?", followerId, targetUser.ID).First(&follow)
-
-	tx := models.DB.Begin()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		// Create new follow
-		follow = models.Follow{
-			FollowerID:  followerId,
-			FollowingID: targetUser.ID,
-		}
+	// Blocking removed any follow between the two, so this can only be a
+	// new follow
+	if !checkNotBlocked(c, h.blocks, followerId, targetUser.ID, "Cannot follow a blocked user") {
+		return
+	}
 
-		if err := tx.Create(&follow).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+	// Following a private user takes their approval, so a new follow becomes
+	// a request and toggling again cancels it
+	if targetUser.IsPrivate {
+		if done := h.request(c, followerId, targetUser.ID); done {
 			return
 		}
+	}
 
-		// Update follower/following counts
-		tx.Model(&models.User{}).Where("id = ?", followerId).UpdateColumn("following_count", gorm.Expr("following_count + ?", 1))
-		tx.Model(&models.User{}).Where("id = ?", targetUser.ID).UpdateColumn("followers_count", gorm.Expr("followers_count + ?", 1))
+	following, err := h.follows.Toggle(followerId, targetUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle follow"})
+		return
+	}
 
-		tx.Commit()
+	if following {
+		h.timelines.Followed(followerId, targetUser.ID)
+		h.notifications.Followed(followerId, targetUser.ID)
 		c.JSON(http.StatusCreated, gin.H{"message": "Following successfully"})
 	} else {
-		// Remove existing follow
-		if err := tx.Delete(&follow).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
-			return
-		}
+		h.timelines.Unfollowed(followerId, targetUser.ID)
+		h.notifications.Unfollowed(followerId, targetUser.ID)
+		c.JSON(http.StatusOK, gin.H{"message": "Unfollowed successfully"})
+	}
+}
 
-		// Update follower/following counts
-		tx.Model(&models.User{}).Where("id = ?", followerId).UpdateColumn("following_count", gorm.Expr("following_count - ?", 1))
-		tx.Model(&models.User{}).Where("id = ?", targetUser.ID).UpdateColumn("followers_count", gorm.Expr("followers_count - ?", 1))
+// request handles following a private user and reports whether it
+// responded. When the user already follows the target it leaves the unfollow
+// to ToggleFollow.
+func (h *FollowHandler) request(c *gin.Context, requesterID, targetID uint) bool {
+	relationships, err := h.follows.Relationships(requesterID, []uint{targetID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle follow"})
+		return true
+	}
+	if relationships[targetID].Following {
+		return false
+	}
 
-		tx.Commit()
-		c.JSON(http.StatusOK, gin.H{"message": "Unfollowed successfully"})
+	cancelled, err := h.requests.Cancel(requesterID, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle follow"})
+		return true
+	}
+	if cancelled {
+		h.notifications.FollowRequestCancelled(requesterID, targetID)
+		c.JSON(http.StatusOK, gin.H{"message": "Follow request cancelled"})
+		return true
+	}
+
+	request, err := h.requests.Create(requesterID, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request follow"})
+		return true
 	}
+	h.notifications.FollowRequested(requesterID, targetID)
+	c.JSON(http.StatusAccepted, gin.H{"message": "Follow requested", "request": request})
+	return true
 }
 
-func GetFollowers(c *gin.Context) {
+// checkCanList responds with 403 and returns false when the caller may not
+// list the follows of user: those of a private user are only visible to the
+// user and their followers.
+func (h *FollowHandler) checkCanList(c *gin.Context, user *models.User) bool {
+	viewerID := c.GetUint("userId")
+	if !user.IsPrivate || viewerID == user.ID {
+		return true
+	}
+	relationships, err := h.follows.Relationships(viewerID, []uint{user.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check follow"})
+		return false
+	}
+	if !relationships[user.ID].Following {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This account is private"})
+		return false
+	}
+	return true
+}
+
+func (h *FollowHandler) GetFollowers(c *gin.Context) {
 	username := c.Param("username")
 
-	var user models.User
-	if err := models.DB.Where("username = ?", username).First(&user).Error; err != nil {
+	user, err := h.users.GetByUsername(username)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
+	if !h.checkCanList(c, user) {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
 
-	var followers []models.User
-	if err := models.DB.Table("users").
-		Select("users.*").
-		Joins("JOIN follows ON users.id = follows.follower_id").
-		Where("follows.following_id = ?", user.ID).
-		Order("follows.created_at desc").
-		Find(&followers).Error; err != nil {
+	followers, err := h.follows.Followers(user.ID, page)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch followers"})
 		return
 	}
 
-	c.JSON(http.StatusOK, followers)
+	respondPage(c, followers)
 }
 
-func GetFollowing(c *gin.Context) {
+func (h *FollowHandler) GetFollowing(c *gin.Context) {
 	username := c.Param("username")
 
-	var user models.User
-	if err := models.DB.Where("username = ?", username).First(&user).Error; err != nil {
+	user, err := h.users.GetByUsername(username)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
+	if !h.checkCanList(c, user) {
+		return
+	}
 
-	var following []models.User
-	if err := models.DB.Table("users").
-		Select("users.*").
-		Joins("JOIN follows ON users.id = follows.following_id").
-		Where("follows.follower_id = ?", user.ID).
-		Order("follows.created_at desc").
-		Find(&following).Error; err != nil {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	following, err := h.follows.Following(user.ID, page)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch following"})
 		return
 	}
 
-	c.JSON(http.StatusOK, following)
+	respondPage(c, following)
+}
+
+// GetFollowRequests pages through the pending requests to follow the caller.
+func (h *FollowHandler) GetFollowRequests(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	requests, err := h.requests.Incoming(c.GetUint("userId"), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch follow requests"})
+		return
+	}
+
+	respondPage(c, requests)
+}
+
+// ApproveFollowRequest accepts a pending request to follow the caller, which
+// makes the requester a follower.
+func (h *FollowHandler) ApproveFollowRequest(c *gin.Context) {
+	request, ok := h.incomingRequest(c)
+	if !ok {
+		return
+	}
+
+	accepted, err := h.requests.Accept(request.ID)
+	if err != nil {
+		respondDecideError(c, err)
+		return
+	}
+	h.timelines.Followed(accepted.RequesterID, accepted.TargetID)
+	h.notifications.FollowAccepted(accepted.RequesterID, accepted.TargetID)
+
+	c.JSON(http.StatusOK, accepted)
+}
+
+// RejectFollowRequest declines a pending request to follow the caller. The
+// requester is not told, and may ask again.
+func (h *FollowHandler) RejectFollowRequest(c *gin.Context) {
+	request, ok := h.incomingRequest(c)
+	if !ok {
+		return
+	}
+
+	rejected, err := h.requests.Reject(request.ID)
+	if err != nil {
+		respondDecideError(c, err)
+		return
+	}
+	h.notifications.FollowRequestCancelled(rejected.RequesterID, rejected.TargetID)
+
+	c.JSON(http.StatusOK, rejected)
+}
+
+// incomingRequest loads the follow request named by the id parameter,
+// responding with 404 unless it was made to the caller.
+func (h *FollowHandler) incomingRequest(c *gin.Context) (*models.FollowRequest, bool) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	request, err := h.requests.GetByID(id)
+	if err != nil || request.TargetID != c.GetUint("userId") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Follow request not found"})
+		return nil, false
+	}
+	return request, true
+}
+
+func respondDecideError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, store.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Follow request was already decided"})
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Follow request not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decide follow request"})
+	}
 }
@@ -2,39 +2,294 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"sinkedin/models"
+	"sinkedin/store"
+	"sinkedin/textparse"
+	"sinkedin/trending"
 )
 
-func GetTrendingHashtags(c *gin.Context) {
-	var hashtags []models.Hashtag
-	if err := models.DB.Order("counter desc").Limit(10).Find(&hashtags).Error; err != nil {
+type HashtagHandler struct {
+	hashtags store.HashtagStore
+	posts    store.PostStore
+	trending *trending.Service
+	viewer   viewerState
+}
+
+func NewHashtagHandler(s *store.Store, trending *trending.Service) *HashtagHandler {
+	return &HashtagHandler{hashtags: s.Hashtags, posts: s.Posts, trending: trending, viewer: newViewerState(s)}
+}
+
+const trendingLimit = 10
+
+// trendingHashtagResponse is a hashtag with its standing in a trending window.
+type trendingHashtagResponse struct {
+	models.Hashtag
+	Window       string  `json:"window"`
+	Rank         int     `json:"rank"`
+	Score        float64 `json:"score"`
+	Uses         int     `json:"uses"`
+	PreviousUses int     `json:"previousUses"`
+}
+
+// GetTrendingHashtags ranks hashtags by recent, growing usage over the window
+// query parameter: 1h, 24h (the default) or 7d.
+func (h *HashtagHandler) GetTrendingHashtags(c *gin.Context) {
+	window, err := trending.ParseWindow(c.DefaultQuery("window", trending.DefaultWindow))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window"})
+		return
+	}
+
+	entries, err := h.trending.Trending(window, trendingLimit)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trending hashtags"})
 		return
 	}
 
+	hashtags := make([]trendingHashtagResponse, 0, len(entries))
+	for _, entry := range entries {
+		hashtags = append(hashtags, trendingHashtagResponse{
+			Hashtag:      entry.Hashtag,
+			Window:       entry.Window,
+			Rank:         entry.Rank,
+			Score:        entry.Score,
+			Uses:         entry.Uses,
+			PreviousUses: entry.PreviousUses,
+		})
+	}
+
 	c.JSON(http.StatusOK, hashtags)
 }
 
-func GetHashtagPosts(c *gin.Context) {
-	hashtagName := c.Param("name")
+// hashtagParam looks up the hashtag named by the name path parameter,
+// responding with 404 and returning nil when there is none.
+func (h *HashtagHandler) hashtagParam(c *gin.Context) *models.Hashtag {
+	name, ok := textparse.NormalizeHashtag(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hashtag not found"})
+		return nil
+	}
 
-	var hashtag models.Hashtag
-	if err := models.DB.Where("name = ?", hashtagName).First(&hashtag).Error; err != nil {
+	hashtag, err := h.hashtags.GetByName(name)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Hashtag not found"})
+		return nil
+	}
+	return hashtag
+}
+
+const hashtagDetailsLimit = 5
+
+type hashtagContributor struct {
+	User models.User `json:"user"`
+	Uses int         `json:"uses"`
+}
+
+type relatedHashtag struct {
+	models.Hashtag
+	Uses int `json:"uses"`
+}
+
+// hashtagDetailsResponse is a hashtag with a summary of how it is used.
+// Related hashtags are the ones most often used alongside it.
+type hashtagDetailsResponse struct {
+	models.Hashtag
+	PostCount       int                  `json:"postCount"`
+	CommentCount    int                  `json:"commentCount"`
+	FirstUsedAt     *time.Time           `json:"firstUsedAt"`
+	TopContributors []hashtagContributor `json:"topContributors"`
+	Related         []relatedHashtag     `json:"related"`
+	FollowedByMe    bool                 `json:"followedByMe"`
+}
+
+func (h *HashtagHandler) GetHashtag(c *gin.Context) {
+	hashtag := h.hashtagParam(c)
+	if hashtag == nil {
 		return
 	}
 
-	var posts []models.Post
-	if err := models.DB.Preload("User").Preload("Tags").Preload("Hashtags").
-		Joins("JOIN post_hashtags ON posts.id = post_hashtags.post_id").
-		Where("post_hashtags.hashtag_id = ?", hashtag.ID).
-		Order("posts.created_at desc").
-		Find(&posts).Error; err != nil {
+	// Contributors hidden from the caller are left out, so enough are
+	// fetched to fill the list without them
+	hidden, err := h.viewer.hiddenIDs(c.GetUint("userId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag"})
+		return
+	}
+	details, err := h.hashtags.Details(hashtag.ID, hashtagDetailsLimit+len(hidden))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag"})
+		return
+	}
+
+	response := hashtagDetailsResponse{
+		Hashtag:         *hashtag,
+		PostCount:       details.PostCount,
+		CommentCount:    details.CommentCount,
+		FirstUsedAt:     details.FirstUsedAt,
+		TopContributors: make([]hashtagContributor, 0, len(details.TopContributors)),
+		Related:         make([]relatedHashtag, 0, len(details.Related)),
+	}
+	for _, contributor := range details.TopContributors {
+		if !hidden[contributor.User.ID] && len(response.TopContributors) < hashtagDetailsLimit {
+			response.TopContributors = append(response.TopContributors, hashtagContributor{contributor.User, contributor.Uses})
+		}
+	}
+	for _, related := range details.Related {
+		if len(response.Related) < hashtagDetailsLimit {
+			response.Related = append(response.Related, relatedHashtag{related.Hashtag, related.Uses})
+		}
+	}
+
+	if userId := c.GetUint("userId"); userId != 0 {
+		followed, err := h.hashtags.FollowedIDs(userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag"})
+			return
+		}
+		response.FollowedByMe = slices.Contains(followed, hashtag.ID)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *HashtagHandler) GetHashtagPosts(c *gin.Context) {
+	hashtag := h.hashtagParam(c)
+	if hashtag == nil {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	posts, err := h.posts.ListByHashtag(hashtag.ID, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
+	userId := c.GetUint("userId")
+	if posts.Items, err = h.viewer.visiblePosts(userId, posts.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
+	if err := h.viewer.annotatePosts(userId, posts.Items); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
 		return
 	}
 
-	c.JSON(http.StatusOK, posts)
+	respondPage(c, posts)
+}
+
+// hashtagItemResponse is one entry of a hashtag's mixed listing, tagged with
+// its type.
+type hashtagItemResponse struct {
+	Type    string          `json:"type"`
+	Post    *models.Post    `json:"post,omitempty"`
+	Comment *models.Comment `json:"comment,omitempty"`
+}
+
+// GetHashtagContent lists the posts and comments using the hashtag together,
+// newest first.
+func (h *HashtagHandler) GetHashtagContent(c *gin.Context) {
+	hashtag := h.hashtagParam(c)
+	if hashtag == nil {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	content, err := h.hashtags.ListContent(hashtag.ID, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag content"})
+		return
+	}
+
+	// Drop what the caller may not see, then annotate the posts and comments
+	// in one batch each
+	userId := c.GetUint("userId")
+	var posts []models.Post
+	var comments []models.Comment
+	for _, item := range content.Items {
+		if item.Post != nil {
+			posts = append(posts, *item.Post)
+		} else {
+			comments = append(comments, *item.Comment)
+		}
+	}
+	if posts, err = h.viewer.visiblePosts(userId, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag content"})
+		return
+	}
+	if comments, err = h.viewer.visibleComments(userId, comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag content"})
+		return
+	}
+	if err := h.viewer.annotatePosts(userId, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag content"})
+		return
+	}
+	if err := h.viewer.annotateComments(userId, comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hashtag content"})
+		return
+	}
+
+	// The visible posts and comments kept their order, so merging them back
+	// only takes comparing against the next of each
+	items := make([]hashtagItemResponse, 0, len(posts)+len(comments))
+	for _, item := range content.Items {
+		if item.Post != nil && len(posts) > 0 && posts[0].ID == item.Post.ID {
+			items = append(items, hashtagItemResponse{Type: "post", Post: &posts[0]})
+			posts = posts[1:]
+		} else if item.Comment != nil && len(comments) > 0 && comments[0].ID == item.Comment.ID {
+			items = append(items, hashtagItemResponse{Type: "comment", Comment: &comments[0]})
+			comments = comments[1:]
+		}
+	}
+	respondPage(c, store.Page[hashtagItemResponse]{Items: items, Next: content.Next})
+}
+
+// ToggleFollowHashtag follows or unfollows the hashtag for the caller. Posts
+// using a followed hashtag appear in the caller's feed.
+func (h *HashtagHandler) ToggleFollowHashtag(c *gin.Context) {
+	hashtag := h.hashtagParam(c)
+	if hashtag == nil {
+		return
+	}
+
+	following, err := h.hashtags.ToggleFollow(c.GetUint("userId"), hashtag.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle follow"})
+		return
+	}
+
+	if following {
+		c.JSON(http.StatusCreated, gin.H{"message": "Following successfully"})
+	} else {
+		c.JSON(http.StatusOK, gin.H{"message": "Unfollowed successfully"})
+	}
+}
+
+// GetFollowedHashtags lists the hashtags the caller follows, most recently
+// followed first.
+func (h *HashtagHandler) GetFollowedHashtags(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	hashtags, err := h.hashtags.Followed(c.GetUint("userId"), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch followed hashtags"})
+		return
+	}
+
+	respondPage(c, hashtags)
 }
@@ -1,88 +1,163 @@
 package handlers
 
-import (	"net/http"
-	"strconv"
+import (
+	"errors"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
+	"sinkedin/store"
 )
 
-func ToggleLike(c *gin.Context) {
-	likeType := models.LikeType(c.Param("type"))
-	parentIdStr := c.Param("id")
-	parentId64, err := strconv.ParseUint(parentIdStr, 10, 32)
+type LikeHandler struct {
+	likes         store.LikeStore
+	posts         store.PostStore
+	comments      store.CommentStore
+	blocks        store.BlockStore
+	notifications *notifications.Service
+	events        *realtime.Hub
+	viewer        viewerState
+}
+
+func NewLikeHandler(s *store.Store, notifications *notifications.Service, events *realtime.Hub) *LikeHandler {
+	return &LikeHandler{likes: s.Likes, posts: s.Posts, comments: s.Comments, blocks: s.Blocks, notifications: notifications, events: events, viewer: newViewerState(s)}
+}
+
+// likeCountEvent is published to the post's topic when the like count of
+// the post or one of its comments changes.
+type likeCountEvent struct {
+	TargetType models.LikeType `json:"targetType"`
+	TargetID   uint            `json:"targetId"`
+	PostID     uint            `json:"postId"`
+	LikeCount  int             `json:"likeCount"`
+}
+
+// publishLikeCount tells the post's watchers the new count after the liker
+// liked or unliked, skipping those the liker is hidden from.
+func (h *LikeHandler) publishLikeCount(likerID uint, likeType models.LikeType, parentID uint) {
+	event := likeCountEvent{TargetType: likeType, TargetID: parentID}
+	if likeType == models.PostLike {
+		post, err := h.posts.GetByID(parentID)
+		if err != nil {
+			return
+		}
+		event.PostID, event.LikeCount = post.ID, post.LikeCount
+	} else {
+		comment, err := h.comments.GetByID(parentID)
+		if err != nil || comment.PostID == nil {
+			return
+		}
+		event.PostID, event.LikeCount = *comment.PostID, comment.LikeCount
+	}
+	h.events.PublishFrom(likerID, realtime.PostTopic(event.PostID), realtime.LikeEvent, event)
+}
+
+// target returns the author of the post or comment and whether the viewer
+// may see it, which for a comment takes seeing its post as well. Blocks are
+// left to ToggleLike, which still lets a like from before one be withdrawn.
+func (h *LikeHandler) target(viewerID uint, likeType models.LikeType, parentID uint) (models.User, bool, error) {
+	if likeType == models.PostLike {
+		post, err := h.posts.GetByID(parentID)
+		if err != nil {
+			return models.User{}, false, err
+		}
+		visible, err := h.viewer.allowsPost(viewerID, *post)
+		return post.User, visible, err
+	}
+	comment, err := h.comments.GetByID(parentID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
-		return
+		return models.User{}, false, err
 	}
-	parentId := uint(parentId64)
-	userId := c.GetUint("userId")
+	var post *models.Post
+	if comment.PostID != nil {
+		if post, err = h.posts.GetByID(*comment.PostID); err != nil {
+			return models.User{}, false, err
+		}
+	}
+	visible, err := h.viewer.allowsComment(viewerID, *comment, post)
+	return comment.User, visible, err
+}
 
+// checkTarget responds with an error and returns false unless the like
+// target exists and the viewer may see it.
+func (h *LikeHandler) checkTarget(c *gin.Context, viewerID uint, likeType models.LikeType, parentID uint) (models.User, bool) {
 	if likeType != models.PostLike && likeType != models.CommentLike {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid like type"})
-		return
+		return models.User{}, false
 	}
+	author, visible, err := h.target(viewerID, likeType, parentID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && !visible) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Like target not found"})
+		return models.User{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find like target"})
+		return models.User{}, false
+	}
+	return author, true
+}
 
-	var like models.Like
-	result := models.DB.Where("user_id = ? AND parent_id = ? AND type = ?", userId, parentId, likeType).First(&like)
-
-	tx := models.DB.Begin()
-
-	if result.Error == gorm.ErrRecordNotFound {
-		// Create new like
-		like = models.Like{
-			UserID:   userId,
-			ParentID: uint(parentId),
-			Type:     likeType,
-		}
+func (h *LikeHandler) ToggleLike(c *gin.Context) {
+	likeType := models.LikeType(c.Param("type"))
+	parentId, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	userId := c.GetUint("userId")
 
-		if err := tx.Create(&like).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create like"})
-			return
-		}
+	author, ok := h.checkTarget(c, userId, likeType, parentId)
+	if !ok {
+		return
+	}
+	// A block stops new likes but still lets an existing one be withdrawn
+	existing, err := h.likes.Liked(userId, likeType, []uint{parentId})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle like"})
+		return
+	}
+	if !existing[parentId] && !checkNotBlocked(c, h.blocks, userId, author.ID, "Cannot like a blocked user's content") {
+		return
+	}
 
-		// Increment like count
-		if likeType == models.PostLike {
-			tx.Model(&models.Post{}).Where("id = ?", parentId).UpdateColumn("like_count", gorm.Expr("like_count + ?", 1))
-		} else {
-			tx.Model(&models.Comment{}).Where("id = ?", parentId).UpdateColumn("like_count", gorm.Expr("like_count + ?", 1))
-		}
+	liked, err := h.likes.Toggle(userId, parentId, likeType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to toggle like"})
+		return
+	}
 
-		tx.Commit()
+	h.publishLikeCount(userId, likeType, parentId)
+	if liked {
+		h.notifications.Liked(userId, parentId, likeType)
 		c.JSON(http.StatusCreated, gin.H{"message": "Liked successfully"})
 	} else {
-		// Remove existing like
-		if err := tx.Delete(&like).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove like"})
-			return
-		}
-
-		// Decrement like count
-		if likeType == models.PostLike {
-			tx.Model(&models.Post{}).Where("id = ?", parentId).UpdateColumn("like_count", gorm.Expr("like_count - ?", 1))
-		} else {
-			tx.Model(&models.Comment{}).Where("id = ?", parentId).UpdateColumn("like_count", gorm.Expr("like_count - ?", 1))
-		}
-
-		tx.Commit()
+		h.notifications.Unliked(userId, parentId, likeType)
 		c.JSON(http.StatusOK, gin.H{"message": "Unliked successfully"})
 	}
 }
 
-func GetLikes(c *gin.Context) {
+func (h *LikeHandler) GetLikes(c *gin.Context) {
 	likeType := models.LikeType(c.Param("type"))
-	parentId := c.Param("id")
+	parentId, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
 
-	var likes []models.Like
-	if err := models.DB.Preload("User").
-		Where("parent_id = ? AND type = ?", parentId, likeType).
-		Order("created_at desc").Find(&likes).Error; err != nil {
+	if _, ok := h.checkTarget(c, c.GetUint("userId"), likeType, parentId); !ok {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	likes, err := h.likes.List(parentId, likeType, page)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch likes"})
 		return
 	}
 
-	c.JSON(http.StatusOK, likes)
+	respondPage(c, likes)
 }
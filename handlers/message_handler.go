@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/messaging"
+	"sinkedin/store"
+)
+
+type MessageHandler struct {
+	messaging *messaging.Service
+	users     store.UserStore
+}
+
+func NewMessageHandler(s *store.Store, messaging *messaging.Service) *MessageHandler {
+	return &MessageHandler{messaging: messaging, users: s.Users}
+}
+
+// respondMessagingError maps the messaging service's errors to responses,
+// falling back to 500 with the given message.
+func respondMessagingError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, messaging.ErrNotMember):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	case errors.Is(err, messaging.ErrNotSender):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to change this message"})
+	case errors.Is(err, messaging.ErrBlocked):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot message a blocked user"})
+	case errors.Is(err, messaging.ErrNotMutual):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only message users who follow you back"})
+	case errors.Is(err, messaging.ErrInvalidMembers):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation members"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+type StartConversationInput struct {
+	Usernames []string `json:"usernames" binding:"required,min=1"`
+	Title     string   `json:"title" binding:"max=100"`
+	Group     bool     `json:"group"`
+}
+
+// StartConversation opens a conversation with the given users. Starting a
+// one-to-one conversation that already exists returns it with 200.
+func (h *MessageHandler) StartConversation(c *gin.Context) {
+	var input StartConversationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	memberIDs := make([]uint, 0, len(input.Usernames))
+	for _, username := range input.Usernames {
+		user, err := h.users.GetByUsername(username)
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User " + username + " not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start conversation"})
+			return
+		}
+		memberIDs = append(memberIDs, user.ID)
+	}
+
+	conversation, created, err := h.messaging.Start(c.GetUint("userId"), memberIDs, input.Group, input.Title)
+	if err != nil {
+		respondMessagingError(c, err, "Failed to start conversation")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, conversation)
+}
+
+// GetConversations lists the caller's conversations by latest activity.
+func (h *MessageHandler) GetConversations(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	conversations, err := h.messaging.List(c.GetUint("userId"), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+		return
+	}
+	respondPage(c, conversations)
+}
+
+func (h *MessageHandler) GetConversation(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	conversation, err := h.messaging.Get(c.GetUint("userId"), id)
+	if err != nil {
+		respondMessagingError(c, err, "Failed to fetch conversation")
+		return
+	}
+	c.JSON(http.StatusOK, conversation)
+}
+
+// GetMessages lists the conversation's messages, newest first.
+func (h *MessageHandler) GetMessages(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	messages, err := h.messaging.Messages(c.GetUint("userId"), id, page)
+	if err != nil {
+		respondMessagingError(c, err, "Failed to fetch messages")
+		return
+	}
+	respondPage(c, messages)
+}
+
+type MessageInput struct {
+	Content string `json:"content" binding:"required,max=2000"`
+}
+
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	var input MessageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.messaging.Send(c.GetUint("userId"), id, input.Content)
+	if err != nil {
+		respondMessagingError(c, err, "Failed to send message")
+		return
+	}
+	c.JSON(http.StatusCreated, message)
+}
+
+func (h *MessageHandler) UpdateMessage(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	messageID, ok := parseID(c, "messageId")
+	if !ok {
+		return
+	}
+	var input MessageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.messaging.Edit(c.GetUint("userId"), id, messageID, input.Content)
+	if err != nil {
+		respondMessagingError(c, err, "Failed to update message")
+		return
+	}
+	c.JSON(http.StatusOK, message)
+}
+
+func (h *MessageHandler) DeleteMessage(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	messageID, ok := parseID(c, "messageId")
+	if !ok {
+		return
+	}
+
+	if err := h.messaging.Delete(c.GetUint("userId"), id, messageID); err != nil {
+		respondMessagingError(c, err, "Failed to delete message")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted successfully"})
+}
+
+type MarkConversationReadInput struct {
+	// MessageID is the last message read; zero or absent means the latest.
+	MessageID uint `json:"messageId"`
+}
+
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	var input MarkConversationReadInput
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	member, err := h.messaging.MarkRead(c.GetUint("userId"), id, input.MessageID)
+	if err != nil {
+		respondMessagingError(c, err, "Failed to mark conversation read")
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
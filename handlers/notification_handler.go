@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/store"
+)
+
+type NotificationHandler struct {
+	notifications *notifications.Service
+}
+
+func NewNotificationHandler(notifications *notifications.Service) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+type notificationResponse struct {
+	models.Notification
+	Summary string `json:"summary"`
+}
+
+// GetNotifications lists the caller's notifications by latest activity;
+// unread=true leaves out the ones already read.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	unread, ok := parseBoolQuery(c, "unread")
+	if !ok {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.notifications.List(c.GetUint("userId"), unread != nil && *unread, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	items := make([]notificationResponse, len(list.Items))
+	for i, n := range list.Items {
+		items[i] = notificationResponse{Notification: n, Summary: notifications.Summary(n)}
+	}
+	respondPage(c, store.Page[notificationResponse]{Items: items, Next: list.Next})
+}
+
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	count, err := h.notifications.UnreadCount(c.GetUint("userId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+type MarkReadInput struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+// MarkRead marks the given notifications as read. IDs that are not the
+// caller's or are already read are skipped.
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var input MarkReadInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.notifications.MarkRead(c.GetUint("userId"), input.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	updated, err := h.notifications.MarkAllRead(c.GetUint("userId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
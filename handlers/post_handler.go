@@ -5,18 +5,40 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
+	"sinkedin/store"
+	"sinkedin/timeline"
 )
 
+type PostHandler struct {
+	posts         store.PostStore
+	users         store.UserStore
+	blocks        store.BlockStore
+	timelines     *timeline.Service
+	notifications *notifications.Service
+	events        *realtime.Hub
+	viewer        viewerState
+}
+
+func NewPostHandler(s *store.Store, timelines *timeline.Service, notifications *notifications.Service, events *realtime.Hub) *PostHandler {
+	return &PostHandler{posts: s.Posts, users: s.Users, blocks: s.Blocks, timelines: timelines, notifications: notifications, events: events, viewer: newViewerState(s)}
+}
+
+// CreatePostInput also serves updates, where an omitted visibility or reply
+// policy keeps the post's current one.
 type CreatePostInput struct {
-	Content    string   `json:"content" binding:"required"`
-	ImageURL   string   `json:"imageURL"`
-	Tags       []string `json:"tags"`
-	Hashtags   []string `json:"hashtags"`
-	IsQuote    bool     `json:"isQuote"`
-	QuoteLines string   `json:"quoteLines"`
+	Content     string                `json:"content" binding:"required"`
+	ImageURL    string                `json:"imageURL"`
+	Tags        []string              `json:"tags"`
+	Hashtags    []string              `json:"hashtags"`
+	IsQuote     bool                  `json:"isQuote"`
+	QuoteLines  string                `json:"quoteLines"`
+	Visibility  models.PostVisibility `json:"visibility" binding:"omitempty,oneof=public followers mentioned private"`
+	ReplyPolicy models.ReplyPolicy    `json:"replyPolicy" binding:"omitempty,oneof=everyone followers mentioned nobody"`
 }
 
-func CreatePost(c *gin.Context) {
+func (h *PostHandler) CreatePost(c *gin.Context) {
 	var input CreatePostInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -24,100 +46,101 @@ func CreatePost(c *gin.Context) {
 	}
 
 	userId := c.GetUint("userId")
-	post := models.Post{
-		UserID:     userId,
-		Content:    input.Content,
-		ImageURL:   input.ImageURL,
-		HasImage:   input.ImageURL != "",
-		IsQuote:    input.IsQuote,
-		QuoteLines: input.QuoteLines,
+	content, ok := resolveContent(c, h.users, h.blocks, userId, contentInput{input.Content, input.Hashtags, input.Tags}, nil)
+	if !ok {
+		return
 	}
 
-	// Start a transaction
-	tx := models.DB.Begin()
+	post := models.Post{
+		UserID:      userId,
+		Content:     input.Content,
+		Entities:    content.Entities,
+		ImageURL:    input.ImageURL,
+		HasImage:    input.ImageURL != "",
+		IsQuote:     input.IsQuote,
+		QuoteLines:  input.QuoteLines,
+		Visibility:  input.Visibility,
+		ReplyPolicy: input.ReplyPolicy,
+	}
+	if post.Visibility == "" {
+		post.Visibility = models.PublicVisibility
+	}
+	if post.ReplyPolicy == "" {
+		post.ReplyPolicy = models.EveryoneReplies
+	}
 
-	if err := tx.Create(&post).Error; err != nil {
-		tx.Rollback()
+	if err := h.posts.Create(&post, content.Hashtags, content.TagUserIDs); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
-
-	// Handle hashtags
-	if len(input.Hashtags) > 0 {
-		post.HasHashtag = true
-		for _, hashtagName := range input.Hashtags {
-			var hashtag models.Hashtag
-			// Find or create hashtag
-			if err := tx.Where("name = ?", hashtagName).FirstOrCreate(&hashtag, models.Hashtag{Name: hashtagName}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process hashtags"})
-				return
-			}
-			// Increment counter
-			tx.Model(&hashtag).UpdateColumn("counter", hashtag.Counter+1)
-			// Create association
-			if err := tx.Create(&models.PostHashtag{PostID: post.ID, HashtagID: hashtag.ID}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate hashtags"})
-				return
-			}
-		}
+	h.timelines.PostCreated(post)
+	// Drafts tell nobody, not even the users they tag
+	if post.Visibility != models.PrivateVisibility {
+		h.notifications.Tagged(userId, models.PostTarget, post.ID, content.TagUserIDs)
 	}
 
-	// Handle tags
-	if len(input.Tags) > 0 {
-		post.HasTag = true
-		for _, username := range input.Tags {
-			var taggedUser models.User
-			if err := tx.Where("username = ?", username).First(&taggedUser).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid tagged user"})
-				return
-			}
-			if err := tx.Create(&models.PostTag{PostID: post.ID, UserID: taggedUser.ID}).Error; err != nil {
-				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate tags"})
-				return
-			}
-		}
-	}
-
-	tx.Commit()
-
-	// Load the complete post with associations
-	models.DB.Preload("User").Preload("Tags").Preload("Hashtags").First(&post, post.ID)
-
 	c.JSON(http.StatusCreated, post)
 }
 
-func GetPosts(c *gin.Context) {
-	var posts []models.Post
-	if err := models.DB.Preload("User").Preload("Tags").Preload("Hashtags").Order("created_at desc").Find(&posts).Error; err != nil {
+func (h *PostHandler) GetPosts(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	posts, err := h.posts.List(page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
+	userId := c.GetUint("userId")
+	if posts.Items, err = h.viewer.visiblePosts(userId, posts.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
+	if err := h.viewer.annotatePosts(userId, posts.Items); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
 		return
 	}
 
-	c.JSON(http.StatusOK, posts)
+	respondPage(c, posts)
 }
 
-func GetPost(c *gin.Context) {
-	id := c.Param("id")
+func (h *PostHandler) GetPost(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
 
-	var post models.Post
-	if err := models.DB.Preload("User").Preload("Tags").Preload("Hashtags").First(&post, id).Error; err != nil {
+	post, err := h.posts.GetByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
+	userId := c.GetUint("userId")
+	if visible, err := h.viewer.canSeePost(userId, *post); err != nil || !visible {
+		respondInvisible(c, err, "Post not found")
+		return
+	}
 
-	c.JSON(http.StatusOK, post)
+	posts := []models.Post{*post}
+	if err := h.viewer.annotatePosts(userId, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts[0])
 }
 
-func UpdatePost(c *gin.Context) {
-	id := c.Param("id")
+func (h *PostHandler) UpdatePost(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
 	userId := c.GetUint("userId")
 
-	var post models.Post
-	if err := models.DB.First(&post, id).Error; err != nil {
+	post, err := h.posts.GetByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
@@ -133,22 +156,62 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
+	// Omitted tags or hashtags keep the ones that were given explicitly
+	previous := previousContent{Entities: post.Entities, Hashtags: post.Hashtags, Tags: post.Tags}
+	content, ok := resolveContent(c, h.users, h.blocks, userId, contentInput{input.Content, input.Hashtags, input.Tags}, &previous)
+	if !ok {
+		return
+	}
+
 	post.Content = input.Content
+	post.Entities = content.Entities
 	post.ImageURL = input.ImageURL
 	post.HasImage = input.ImageURL != ""
 	post.IsQuote = input.IsQuote
 	post.QuoteLines = input.QuoteLines
+	visibility := post.Visibility
+	wasDraft := visibility == models.PrivateVisibility
+	if input.Visibility != "" {
+		post.Visibility = input.Visibility
+	}
+	if input.ReplyPolicy != "" {
+		post.ReplyPolicy = input.ReplyPolicy
+	}
 
-	models.DB.Save(&post)
+	tagged, err := h.posts.Update(post, content.Hashtags, content.TagUserIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+	// Publishing a draft tells everyone it tags and reaches live feeds, as
+	// creating the post would have
+	if post.Visibility != models.PrivateVisibility {
+		if wasDraft {
+			tagged = make([]uint, 0, len(post.Tags))
+			for _, user := range post.Tags {
+				tagged = append(tagged, user.ID)
+			}
+			h.timelines.PostPublished(*post)
+		}
+		h.notifications.Tagged(post.UserID, models.PostTarget, post.ID, tagged)
+	}
+	// Watchers the post is no longer visible to stop getting its activity.
+	// Mentioned-only posts may have lost some of their tags
+	if post.Visibility != visibility || post.Visibility == models.MentionedVisibility {
+		h.events.Recheck(realtime.PostTopic(post.ID))
+	}
 	c.JSON(http.StatusOK, post)
 }
 
-func DeletePost(c *gin.Context) {
-	id := c.Param("id")
+func (h *PostHandler) DeletePost(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
 	userId := c.GetUint("userId")
 
-	var post models.Post
-	if err := models.DB.First(&post, id).Error; err != nil {
+	post, err := h.posts.GetByID(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
@@ -158,6 +221,10 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
-	models.DB.Delete(&post)
+	if err := h.posts.Delete(post.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+		return
+	}
+	h.timelines.PostDeleted(post.ID)
 	c.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
 }
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type ReportHandler struct {
+	moderation store.ModerationStore
+	users      store.UserStore
+	posts      store.PostStore
+	comments   store.CommentStore
+	viewer     viewerState
+}
+
+func NewReportHandler(s *store.Store) *ReportHandler {
+	return &ReportHandler{moderation: s.Moderation, users: s.Users, posts: s.Posts, comments: s.Comments, viewer: newViewerState(s)}
+}
+
+type CreateReportInput struct {
+	TargetType models.ReportTargetType `json:"targetType" binding:"required,oneof=post comment user"`
+	TargetID   uint                    `json:"targetId" binding:"required"`
+	Reason     models.ReportReason     `json:"reason" binding:"required,oneof=spam harassment hate violence nudity misinformation self_harm other"`
+	Details    string                  `json:"details" binding:"max=1000"`
+}
+
+// target returns the author of the reported post or comment, or the reported
+// user, and whether the reporter may see it. Blocks are left out, so that
+// users can still report the content of those they blocked.
+func (h *ReportHandler) target(viewerID uint, targetType models.ReportTargetType, targetID uint) (uint, bool, error) {
+	switch targetType {
+	case models.ReportedPost:
+		post, err := h.posts.GetByID(targetID)
+		if err != nil {
+			return 0, false, err
+		}
+		visible, err := h.viewer.allowsPost(viewerID, *post)
+		return post.UserID, visible, err
+	case models.ReportedComment:
+		comment, err := h.comments.GetByID(targetID)
+		if err != nil {
+			return 0, false, err
+		}
+		var post *models.Post
+		if comment.PostID != nil {
+			if post, err = h.posts.GetByID(*comment.PostID); err != nil {
+				return 0, false, err
+			}
+		}
+		visible, err := h.viewer.allowsComment(viewerID, *comment, post)
+		return comment.UserID, visible, err
+	}
+	user, err := h.users.GetByID(targetID)
+	if err != nil {
+		return 0, false, err
+	}
+	return user.ID, true, nil
+}
+
+// CreateReport reports a post, comment or user to the moderators. Only
+// content the caller can see may be reported, and not their own.
+func (h *ReportHandler) CreateReport(c *gin.Context) {
+	var input CreateReportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userId := c.GetUint("userId")
+
+	authorID, visible, err := h.target(userId, input.TargetType, input.TargetID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && !visible) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report target not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find report target"})
+		return
+	}
+	if authorID == userId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot report yourself"})
+		return
+	}
+
+	report := models.Report{
+		ReporterID: userId,
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		Reason:     input.Reason,
+		Details:    input.Details,
+	}
+	if err := h.moderation.CreateReport(&report); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You already reported this"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetReports lists the caller's own reports, newest first, so they can follow
+// what became of them.
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	reports, err := h.moderation.Reports(store.ReportFilter{ReporterID: c.GetUint("userId")}, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+	respondPage(c, reports)
+}
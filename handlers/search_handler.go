@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type SearchHandler struct {
+	search store.SearchStore
+	users  store.UserStore
+	viewer viewerState
+}
+
+func NewSearchHandler(s *store.Store) *SearchHandler {
+	return &SearchHandler{search: s.Search, users: s.Users, viewer: newViewerState(s)}
+}
+
+const (
+	maxSearchQueryLength = 200
+	// searchSectionLimit is the default number of results in each section
+	// when searching everything at once.
+	searchSectionLimit = 5
+)
+
+// searchHit is one search result. Snippets are HTML-escaped text with the
+// matching words wrapped in <mark> tags.
+type searchHit[T any] struct {
+	Item    T       `json:"item"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// searchResponse holds a section per result type when searching everything.
+type searchResponse struct {
+	Posts    pageResponse[searchHit[models.Post]]    `json:"posts"`
+	Comments pageResponse[searchHit[models.Comment]] `json:"comments"`
+	Users    pageResponse[searchHit[models.User]]    `json:"users"`
+	Hashtags pageResponse[searchHit[models.Hashtag]] `json:"hashtags"`
+}
+
+// Search finds posts, comments, users and hashtags. The q parameter takes web
+// search syntax ("phrases", OR, -excluded). With type=posts, comments, users
+// or hashtags it pages through that type alone; otherwise it returns the top
+// of each type. Posts and comments can be filtered by author (a username),
+// from and to (RFC 3339 times or dates, to being inclusive), and posts by
+// hasImage and isQuote; sort=recent lists them newest first instead of by
+// relevance.
+func (h *SearchHandler) Search(c *gin.Context) {
+	query, ok := h.parseSearchQuery(c)
+	if !ok {
+		return
+	}
+
+	searchType := c.DefaultQuery("type", "all")
+	if searchType == "all" && c.Query("cursor") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A cursor requires a search type"})
+		return
+	}
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	viewerID := c.GetUint("userId")
+
+	var response searchResponse
+	var section interface{}
+	var err error
+	switch searchType {
+	case "all":
+		if c.Query("limit") == "" {
+			page.Limit = searchSectionLimit
+		}
+		response.Posts, err = h.posts(query, page, viewerID)
+		if err == nil {
+			response.Comments, err = h.comments(query, page, viewerID)
+		}
+		if err == nil {
+			response.Users, err = h.userHits(query, page, viewerID)
+		}
+		if err == nil {
+			response.Hashtags, err = searchSection(h.search.Hashtags, query, page)
+		}
+		section = response
+	case "posts":
+		section, err = h.posts(query, page, viewerID)
+	case "comments":
+		section, err = h.comments(query, page, viewerID)
+	case "users":
+		section, err = h.userHits(query, page, viewerID)
+	case "hashtags":
+		section, err = searchSection(h.search.Hashtags, query, page)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search type"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, section)
+}
+
+// parseSearchQuery reads the query text and filters, responding with 400 or
+// 404 when one is invalid.
+func (h *SearchHandler) parseSearchQuery(c *gin.Context) (store.SearchQuery, bool) {
+	query := store.SearchQuery{Text: strings.TrimSpace(c.Query("q")), Sort: store.SortRelevance}
+	if query.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query is required"})
+		return query, false
+	}
+	if len([]rune(query.Text)) > maxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query is too long"})
+		return query, false
+	}
+
+	switch sort := store.SearchSort(c.DefaultQuery("sort", string(store.SortRelevance))); sort {
+	case store.SortRelevance, store.SortRecent:
+		query.Sort = sort
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort"})
+		return query, false
+	}
+
+	if username := c.Query("author"); username != "" {
+		author, err := h.users.GetByUsername(strings.TrimPrefix(username, "@"))
+		if errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+			return query, false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search"})
+			return query, false
+		}
+		query.AuthorID = &author.ID
+	}
+
+	var ok bool
+	if query.From, ok = parseSearchTime(c, "from", false); !ok {
+		return query, false
+	}
+	if query.To, ok = parseSearchTime(c, "to", true); !ok {
+		return query, false
+	}
+	if query.HasImage, ok = parseBoolQuery(c, "hasImage"); !ok {
+		return query, false
+	}
+	if query.IsQuote, ok = parseBoolQuery(c, "isQuote"); !ok {
+		return query, false
+	}
+	return query, true
+}
+
+// parseSearchTime reads an RFC 3339 time or a date. An end date covers the
+// whole day.
+func parseSearchTime(c *gin.Context, name string, end bool) (*time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, true
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " date"})
+		return nil, false
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, true
+}
+
+// searchPage converts a page of store hits into the response envelope.
+func searchPage[T any](page store.Page[store.SearchHit[T]], items []T) pageResponse[searchHit[T]] {
+	response := pageResponse[searchHit[T]]{Data: make([]searchHit[T], 0, len(page.Items))}
+	for i, hit := range page.Items {
+		response.Data = append(response.Data, searchHit[T]{Item: items[i], Rank: hit.Rank, Snippet: hit.Snippet})
+	}
+	if page.Next != nil {
+		next := page.Next.Encode()
+		response.NextCursor = &next
+	}
+	return response
+}
+
+func (h *SearchHandler) posts(query store.SearchQuery, page store.PageRequest, viewerID uint) (pageResponse[searchHit[models.Post]], error) {
+	hits, err := h.search.Posts(query, page)
+	if err != nil {
+		return pageResponse[searchHit[models.Post]]{}, err
+	}
+	posts, err := h.viewer.visiblePosts(viewerID, hitItems(hits))
+	if err != nil {
+		return pageResponse[searchHit[models.Post]]{}, err
+	}
+	hits.Items = keepHits(hits.Items, posts, func(post models.Post) uint { return post.ID })
+	if err := h.viewer.annotatePosts(viewerID, posts); err != nil {
+		return pageResponse[searchHit[models.Post]]{}, err
+	}
+	return searchPage(hits, posts), nil
+}
+
+func (h *SearchHandler) comments(query store.SearchQuery, page store.PageRequest, viewerID uint) (pageResponse[searchHit[models.Comment]], error) {
+	hits, err := h.search.Comments(query, page)
+	if err != nil {
+		return pageResponse[searchHit[models.Comment]]{}, err
+	}
+	comments, err := h.viewer.visibleComments(viewerID, hitItems(hits))
+	if err != nil {
+		return pageResponse[searchHit[models.Comment]]{}, err
+	}
+	hits.Items = keepHits(hits.Items, comments, func(comment models.Comment) uint { return comment.ID })
+	if err := h.viewer.annotateComments(viewerID, comments); err != nil {
+		return pageResponse[searchHit[models.Comment]]{}, err
+	}
+	return searchPage(hits, comments), nil
+}
+
+// userHits searches users, leaving out those hidden from the viewer.
+func (h *SearchHandler) userHits(query store.SearchQuery, page store.PageRequest, viewerID uint) (pageResponse[searchHit[models.User]], error) {
+	hits, err := h.search.Users(query, page)
+	if err != nil {
+		return pageResponse[searchHit[models.User]]{}, err
+	}
+	hidden, err := h.viewer.hiddenIDs(viewerID)
+	if err != nil {
+		return pageResponse[searchHit[models.User]]{}, err
+	}
+	users := make([]models.User, 0, len(hits.Items))
+	for _, hit := range hits.Items {
+		if !hidden[hit.Item.ID] {
+			users = append(users, hit.Item)
+		}
+	}
+	hits.Items = keepHits(hits.Items, users, func(user models.User) uint { return user.ID })
+	return searchPage(hits, users), nil
+}
+
+// keepHits keeps the hits whose items survived filtering into kept, which
+// preserved their order.
+func keepHits[T any](hits []store.SearchHit[T], kept []T, id func(T) uint) []store.SearchHit[T] {
+	result := make([]store.SearchHit[T], 0, len(kept))
+	for _, hit := range hits {
+		if len(kept) > 0 && id(kept[0]) == id(hit.Item) {
+			result = append(result, hit)
+			kept = kept[1:]
+		}
+	}
+	return result
+}
+
+// searchSection runs a search whose results need no viewer state.
+func searchSection[T any](search func(store.SearchQuery, store.PageRequest) (store.Page[store.SearchHit[T]], error), query store.SearchQuery, page store.PageRequest) (pageResponse[searchHit[T]], error) {
+	hits, err := search(query, page)
+	if err != nil {
+		return pageResponse[searchHit[T]]{}, err
+	}
+	return searchPage(hits, hitItems(hits)), nil
+}
+
+// hitItems returns the records of the hits.
+func hitItems[T any](hits store.Page[store.SearchHit[T]]) []T {
+	items := make([]T, len(hits.Items))
+	for i, hit := range hits.Items {
+		items[i] = hit.Item
+	}
+	return items
+}
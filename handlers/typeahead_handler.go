@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/textparse"
+	"sinkedin/typeahead"
+)
+
+type TypeaheadHandler struct {
+	typeahead *typeahead.Service
+}
+
+func NewTypeaheadHandler(typeahead *typeahead.Service) *TypeaheadHandler {
+	return &TypeaheadHandler{typeahead: typeahead}
+}
+
+const (
+	defaultSuggestions = 8
+	maxSuggestions     = 20
+)
+
+type userSuggestion struct {
+	ID             uint   `json:"id"`
+	Username       string `json:"username"`
+	Name           string `json:"name"`
+	PhotoURL       string `json:"photoURL"`
+	FollowersCount int    `json:"followersCount"`
+	Following      bool   `json:"following"`
+	FollowsYou     bool   `json:"followsYou"`
+}
+
+type hashtagSuggestion struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	Counter    int    `json:"counter"`
+	RecentUses int    `json:"recentUses"`
+}
+
+type typeaheadResponse struct {
+	Users    []userSuggestion    `json:"users"`
+	Hashtags []hashtagSuggestion `json:"hashtags"`
+}
+
+// Suggest completes the q prefix as the caller types. A leading @ asks for
+// users only and a leading # for hashtags only; otherwise both are suggested.
+// Users the caller follows come first, then their followers, then popular
+// accounts; hashtags are ranked by recent usage.
+func (h *TypeaheadHandler) Suggest(c *gin.Context) {
+	q, ok := c.GetQuery("q")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query is required"})
+		return
+	}
+
+	limit := defaultSuggestions
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(n, maxSuggestions)
+	}
+
+	wantUsers, wantHashtags := true, true
+	switch {
+	case strings.HasPrefix(q, "@"):
+		wantHashtags = false
+	case strings.HasPrefix(q, "#"):
+		wantUsers = false
+	}
+	prefix := strings.ToLower(strings.TrimLeft(q, "@#"))
+
+	response := typeaheadResponse{Users: []userSuggestion{}, Hashtags: []hashtagSuggestion{}}
+	// Nothing can match a prefix that is empty or could not be a username
+	// or hashtag
+	if !isPrefix(prefix) {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if wantUsers {
+		users, err := h.typeahead.Users(c.GetUint("userId"), prefix, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+			return
+		}
+		for _, s := range users {
+			response.Users = append(response.Users, userSuggestion{
+				ID:             s.User.ID,
+				Username:       s.User.Username,
+				Name:           s.User.Name,
+				PhotoURL:       s.User.PhotoURL,
+				FollowersCount: s.User.FollowersCount,
+				Following:      s.Following,
+				FollowsYou:     s.FollowedBy,
+			})
+		}
+	}
+
+	if wantHashtags {
+		hashtags, err := h.typeahead.Hashtags(prefix, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+			return
+		}
+		for _, s := range hashtags {
+			response.Hashtags = append(response.Hashtags, hashtagSuggestion{
+				ID:         s.Hashtag.ID,
+				Name:       s.Hashtag.Name,
+				Counter:    s.Hashtag.Counter,
+				RecentUses: s.RecentUses,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// isPrefix reports whether s could start a username or hashtag.
+func isPrefix(s string) bool {
+	if s == "" || len([]rune(s)) > textparse.MaxLength {
+		return false
+	}
+	for _, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) {
+			return false
+		}
+	}
+	return true
+}
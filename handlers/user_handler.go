@@ -1,16 +1,34 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
+	"sinkedin/auth"
 	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
+	"sinkedin/store"
+	"sinkedin/timeline"
 )
 
+type UserHandler struct {
+	users         store.UserStore
+	blocks        store.BlockStore
+	requests      store.FollowRequestStore
+	auth          *auth.Service
+	timelines     *timeline.Service
+	notifications *notifications.Service
+	events        *realtime.Hub
+}
+
+func NewUserHandler(s *store.Store, authService *auth.Service, timelines *timeline.Service, notifications *notifications.Service, events *realtime.Hub) *UserHandler {
+	return &UserHandler{users: s.Users, blocks: s.Blocks, requests: s.FollowRequests, auth: authService, timelines: timelines, notifications: notifications, events: events}
+}
+
 type RegisterInput struct {
 	Name     string `json:"name" binding:"required"`
 	Username string `json:"username" binding:"required"`
@@ -23,7 +41,35 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
-func RegisterUser(c *gin.Context) {
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type UpdateProfileInput struct {
+	Name        *string    `json:"name"`
+	Username    *string    `json:"username"`
+	Email       *string    `json:"email" binding:"omitempty,email"`
+	PhoneNumber *string    `json:"phoneNumber"`
+	Bio         *string    `json:"bio"`
+	DOB         *time.Time `json:"dob"`
+	PhotoURL    *string    `json:"photoURL"`
+	BannerURL   *string    `json:"bannerURL"`
+	IsPrivate   *bool      `json:"isPrivate"`
+}
+
+// ownProfileResponse is the caller's own account, with the moderation state
+// hidden from everyone else.
+type ownProfileResponse struct {
+	models.User
+	Role           models.Role `json:"role"`
+	SuspendedUntil *time.Time  `json:"suspendedUntil,omitempty"`
+}
+
+func ownProfile(user models.User) ownProfileResponse {
+	return ownProfileResponse{User: user, Role: user.Role, SuspendedUntil: user.SuspendedUntil}
+}
+
+func (h *UserHandler) RegisterUser(c *gin.Context) {
 	var input RegisterInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -44,27 +90,30 @@ func RegisterUser(c *gin.Context) {
 		Password: string(hashedPassword),
 	}
 
-	result := models.DB.Create(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username or email already exists"})
+	if err := h.users.Create(&user); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username or email already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User registered successfully",
-		"user":    user,
+		"user":    ownProfile(user),
 	})
 }
 
-func LoginUser(c *gin.Context) {
+func (h *UserHandler) LoginUser(c *gin.Context) {
 	var input LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var user models.User
-	if err := models.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
+	user, err := h.users.GetByEmail(input.Email)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
@@ -74,41 +123,107 @@ func LoginUser(c *gin.Context) {
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": user.ID,
-		"exp":    time.Now().Add(time.Hour * 24 * 7).Unix(),
-	})
+	if user.SuspendedUntil != nil && user.SuspendedUntil.After(time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended", "suspendedUntil": user.SuspendedUntil})
+		return
+	}
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokens, err := h.auth.Login(user.ID, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token": tokenString,
-		"user":  user,
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresAt":    tokens.ExpiresAt,
+		"user":         ownProfile(*user),
 	})
 }
 
-func GetUserProfile(c *gin.Context) {
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var input RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.auth.Refresh(input.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token was already used; session revoked"})
+		case errors.Is(err, auth.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *UserHandler) Logout(c *gin.Context) {
+	sessionId := c.GetUint("sessionId")
+
+	if err := h.auth.Logout(sessionId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	userId := c.GetUint("userId")
+
+	if err := h.auth.LogoutAll(userId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices"})
+}
+
+func (h *UserHandler) GetUserProfile(c *gin.Context) {
 	username := c.Param("username")
 
-	var user models.User
-	if err := models.DB.Where("username = ?", username).First(&user).Error; err != nil {
+	user, err := h.users.GetByUsername(username)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
+	viewerID := c.GetUint("userId")
+	if viewerID == user.ID {
+		c.JSON(http.StatusOK, ownProfile(*user))
+		return
+	}
+
+	// Users blocked either way cannot see each other's profile
+	if viewerID != 0 {
+		blocking, err := h.blocks.Blocking(viewerID, []uint{user.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+		if blocking[user.ID] {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, user)
 }
 
-func UpdateUserProfile(c *gin.Context) {
+func (h *UserHandler) UpdateUserProfile(c *gin.Context) {
 	username := c.Param("username")
 	userId := c.GetUint("userId")
 
-	var user models.User
-	if err := models.DB.Where("username = ?", username).First(&user).Error; err != nil {
+	user, err := h.users.GetByUsername(username)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -118,22 +233,59 @@ func UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
-	var input models.User
+	var input UpdateProfileInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	models.DB.Model(&user).Updates(input)
-	c.JSON(http.StatusOK, user)
+	updated, err := h.users.UpdateProfile(user.ID, store.ProfileUpdate{
+		Name:        input.Name,
+		Username:    input.Username,
+		Email:       input.Email,
+		PhoneNumber: input.PhoneNumber,
+		Bio:         input.Bio,
+		DOB:         input.DOB,
+		PhotoURL:    input.PhotoURL,
+		BannerURL:   input.BannerURL,
+		IsPrivate:   input.IsPrivate,
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username or email already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	// Going public lets in everyone who was still waiting for approval
+	if user.IsPrivate && !updated.IsPrivate {
+		requesterIDs, err := h.requests.AcceptAll(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept follow requests"})
+			return
+		}
+		for _, requesterID := range requesterIDs {
+			h.timelines.Followed(requesterID, user.ID)
+			h.notifications.FollowAccepted(requesterID, user.ID)
+		}
+	}
+	// Going private stops live activity on their posts from reaching anyone
+	// who does not follow them
+	if !user.IsPrivate && updated.IsPrivate {
+		h.events.Recheck(realtime.AuthorTopic(user.ID))
+	}
+
+	c.JSON(http.StatusOK, ownProfile(*updated))
 }
 
-func DeleteUser(c *gin.Context) {
+func (h *UserHandler) DeleteUser(c *gin.Context) {
 	username := c.Param("username")
 	userId := c.GetUint("userId")
 
-	var user models.User
-	if err := models.DB.Where("username = ?", username).First(&user).Error; err != nil {
+	user, err := h.users.GetByUsername(username)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -143,6 +295,13 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	models.DB.Delete(&user)
+	if err := h.users.Delete(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	if err := h.auth.LogoutAll(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+// viewerState fills in the Viewer fields of posts and comments for the
+// authenticated caller. Each flag is computed for the whole batch at once:
+// one query for likes and one for follow relationships with the authors,
+// while tags come from the already loaded associations. It also decides
+// which content the caller may see at all.
+type viewerState struct {
+	likes   store.LikeStore
+	follows store.FollowStore
+	blocks  store.BlockStore
+	posts   store.PostStore
+}
+
+func newViewerState(s *store.Store) viewerState {
+	return viewerState{likes: s.Likes, follows: s.Follows, blocks: s.Blocks, posts: s.Posts}
+}
+
+// audience decides whose posts and comments a viewer may see: not those of
+// users they muted or are blocked from, those of private users only when the
+// viewer follows them, posts only as far as their visibility allows, and
+// content hidden by moderators only when it is their own.
+type audience struct {
+	viewerID  uint
+	hidden    map[uint]bool
+	following map[uint]bool
+}
+
+func (a audience) canSee(author models.User) bool {
+	if a.hidden[author.ID] {
+		return false
+	}
+	return !author.IsPrivate || author.ID == a.viewerID || a.following[author.ID]
+}
+
+// canSeeComment applies moderation on top of canSee for the comment's author.
+func (a audience) canSeeComment(comment models.Comment) bool {
+	return a.canSee(comment.User) && (!comment.IsHidden || comment.UserID == a.viewerID)
+}
+
+// canSeePost applies the post's visibility on top of canSee for its author.
+func (a audience) canSeePost(post models.Post) bool {
+	return !a.hidden[post.UserID] && a.allows(post)
+}
+
+// allows reports whether the post's author and visibility let the viewer in,
+// leaving mutes and blocks out. Comments on a post are visible only where it
+// allows them to be.
+func (a audience) allows(post models.Post) bool {
+	if post.UserID == a.viewerID {
+		return true
+	}
+	if post.IsHidden || post.User.IsPrivate && !a.following[post.UserID] {
+		return false
+	}
+	switch post.Visibility {
+	case models.FollowersVisibility:
+		return a.following[post.UserID]
+	case models.MentionedVisibility:
+		return containsUser(post.Tags, a.viewerID)
+	case models.PrivateVisibility:
+		return false
+	}
+	return true
+}
+
+// audience loads what deciding on listed content by authors and on posts
+// takes for the viewer, who is anonymous when viewerID is 0.
+func (v viewerState) audience(viewerID uint, authors []models.User, posts []models.Post) (audience, error) {
+	a, err := v.followers(viewerID, authors, posts)
+	if err != nil {
+		return a, err
+	}
+	a.hidden, err = v.hiddenIDs(viewerID)
+	return a, err
+}
+
+// hiddenIDs loads the users left out of the viewer's listings: those they
+// muted or blocked and those who blocked them. Nobody is hidden from
+// anonymous viewers.
+func (v viewerState) hiddenIDs(viewerID uint) (map[uint]bool, error) {
+	hidden := map[uint]bool{}
+	if viewerID == 0 {
+		return hidden, nil
+	}
+	ids, err := v.blocks.HiddenIDs(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		hidden[id] = true
+	}
+	return hidden, nil
+}
+
+// followers loads which of the private authors, and of the authors of
+// followers-only posts, the viewer follows, leaving mutes and blocks out.
+func (v viewerState) followers(viewerID uint, authors []models.User, posts []models.Post) (audience, error) {
+	a := audience{viewerID: viewerID, hidden: map[uint]bool{}, following: map[uint]bool{}}
+	if viewerID == 0 {
+		return a, nil
+	}
+
+	var ids []uint
+	for _, author := range authors {
+		if author.IsPrivate && author.ID != viewerID {
+			ids = append(ids, author.ID)
+		}
+	}
+	for _, post := range posts {
+		needsFollow := post.User.IsPrivate || post.Visibility == models.FollowersVisibility
+		if needsFollow && post.UserID != viewerID {
+			ids = append(ids, post.UserID)
+		}
+	}
+	if len(ids) == 0 {
+		return a, nil
+	}
+	relationships, err := v.follows.Relationships(viewerID, unique(ids))
+	if err != nil {
+		return a, err
+	}
+	for id, r := range relationships {
+		a.following[id] = r.Following
+	}
+	return a, nil
+}
+
+// canSeePost reports whether the viewer may see the post when asking for it
+// directly, which users blocked either way from its author may not. Mutes
+// are left out, as they only filter listings.
+func (v viewerState) canSeePost(viewerID uint, post models.Post) (bool, error) {
+	visible, err := v.allowsPost(viewerID, post)
+	if err != nil || !visible {
+		return false, err
+	}
+	blocked, err := v.blocked(viewerID, post.UserID)
+	return !blocked, err
+}
+
+// canSeeComment reports whether the viewer may see the comment, and the post
+// it belongs to if any, when asking for it directly. As with canSeePost,
+// blocks between the viewer and either author hide it.
+func (v viewerState) canSeeComment(viewerID uint, comment models.Comment, post *models.Post) (bool, error) {
+	visible, err := v.allowsComment(viewerID, comment, post)
+	if err != nil || !visible {
+		return false, err
+	}
+	authorIDs := []uint{comment.UserID}
+	if post != nil {
+		authorIDs = append(authorIDs, post.UserID)
+	}
+	blocked, err := v.blocked(viewerID, authorIDs...)
+	return !blocked, err
+}
+
+// allowsPost is canSeePost leaving blocks out.
+func (v viewerState) allowsPost(viewerID uint, post models.Post) (bool, error) {
+	a, err := v.followers(viewerID, nil, []models.Post{post})
+	if err != nil {
+		return false, err
+	}
+	return a.canSeePost(post), nil
+}
+
+// allowsComment is canSeeComment leaving blocks out.
+func (v viewerState) allowsComment(viewerID uint, comment models.Comment, post *models.Post) (bool, error) {
+	var posts []models.Post
+	if post != nil {
+		posts = append(posts, *post)
+	}
+	a, err := v.followers(viewerID, []models.User{comment.User}, posts)
+	if err != nil {
+		return false, err
+	}
+	return a.canSeeComment(comment) && (post == nil || a.allows(*post)), nil
+}
+
+// blocked reports whether the viewer has blocked or been blocked by any of
+// the authors other than themselves. Anonymous viewers are never blocked.
+func (v viewerState) blocked(viewerID uint, authorIDs ...uint) (bool, error) {
+	var others []uint
+	for _, id := range authorIDs {
+		if id != viewerID {
+			others = append(others, id)
+		}
+	}
+	if viewerID == 0 || len(others) == 0 {
+		return false, nil
+	}
+	blocking, err := v.blocks.Blocking(viewerID, unique(others))
+	if err != nil {
+		return false, err
+	}
+	return len(blocking) > 0, nil
+}
+
+// mayReply reports whether the post's reply policy lets the user comment on
+// it. The author always may.
+func (v viewerState) mayReply(userID uint, post models.Post) (bool, error) {
+	if post.UserID == userID {
+		return true, nil
+	}
+	switch post.ReplyPolicy {
+	case models.NoReplies:
+		return false, nil
+	case models.MentionedReplies:
+		return containsUser(post.Tags, userID), nil
+	case models.FollowersReplies:
+		relationships, err := v.follows.Relationships(userID, []uint{post.UserID})
+		if err != nil {
+			return false, err
+		}
+		return relationships[post.UserID].Following, nil
+	}
+	return true, nil
+}
+
+// visiblePosts drops the posts the viewer may not see. Pages can therefore
+// come back short, but their cursors still continue after the dropped posts.
+func (v viewerState) visiblePosts(viewerID uint, posts []models.Post) ([]models.Post, error) {
+	a, err := v.audience(viewerID, nil, posts)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]models.Post, 0, len(posts))
+	for _, post := range posts {
+		if a.canSeePost(post) {
+			visible = append(visible, post)
+		}
+	}
+	return visible, nil
+}
+
+// visibleComments drops the comments the viewer may not see, either for
+// their author or for the post they belong to, as visiblePosts does for
+// posts.
+func (v viewerState) visibleComments(viewerID uint, comments []models.Comment) ([]models.Comment, error) {
+	authors := make([]models.User, len(comments))
+	var postIDs []uint
+	for i, comment := range comments {
+		authors[i] = comment.User
+		if comment.PostID != nil {
+			postIDs = append(postIDs, *comment.PostID)
+		}
+	}
+	posts, err := v.posts.GetByIDs(unique(postIDs))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	a, err := v.audience(viewerID, authors, posts)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]models.Comment, 0, len(comments))
+	for _, comment := range comments {
+		if !a.canSeeComment(comment) {
+			continue
+		}
+		if comment.PostID != nil {
+			if post, ok := byID[*comment.PostID]; !ok || !a.allows(post) {
+				continue
+			}
+		}
+		visible = append(visible, comment)
+	}
+	return visible, nil
+}
+
+// annotatePosts sets Viewer on each post; it does nothing for anonymous
+// callers (viewerID 0).
+func (v viewerState) annotatePosts(viewerID uint, posts []models.Post) error {
+	if viewerID == 0 || len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(posts))
+	authorIDs := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+		authorIDs[i] = post.UserID
+	}
+	liked, relationships, err := v.load(viewerID, models.PostLike, ids, authorIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		post := &posts[i]
+		post.Viewer = &models.ViewerState{
+			LikedByMe:       liked[post.ID],
+			FollowingAuthor: relationships[post.UserID].Following,
+			AuthorFollowsMe: relationships[post.UserID].FollowedBy,
+			TaggedMe:        containsUser(post.Tags, viewerID),
+		}
+	}
+	return nil
+}
+
+// annotateComments sets Viewer on each comment; it does nothing for anonymous
+// callers (viewerID 0).
+func (v viewerState) annotateComments(viewerID uint, comments []models.Comment) error {
+	if viewerID == 0 || len(comments) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(comments))
+	authorIDs := make([]uint, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+		authorIDs[i] = comment.UserID
+	}
+	liked, relationships, err := v.load(viewerID, models.CommentLike, ids, authorIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range comments {
+		comment := &comments[i]
+		comment.Viewer = &models.ViewerState{
+			LikedByMe:       liked[comment.ID],
+			FollowingAuthor: relationships[comment.UserID].Following,
+			AuthorFollowsMe: relationships[comment.UserID].FollowedBy,
+			TaggedMe:        containsUser(comment.Tags, viewerID),
+		}
+	}
+	return nil
+}
+
+func (v viewerState) load(viewerID uint, likeType models.LikeType, ids, authorIDs []uint) (map[uint]bool, map[uint]store.Relationship, error) {
+	liked, err := v.likes.Liked(viewerID, likeType, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	relationships, err := v.follows.Relationships(viewerID, unique(authorIDs))
+	if err != nil {
+		return nil, nil, err
+	}
+	return liked, relationships, nil
+}
+
+func containsUser(users []models.User, id uint) bool {
+	for _, user := range users {
+		if user.ID == id {
+			return true
+		}
+	}
+	return false
+}
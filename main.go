@@ -3,11 +3,23 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"sinkedin/auth"
+	"sinkedin/messaging"
+	"sinkedin/migrations"
 	"sinkedin/models"
+	"sinkedin/moderation"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
 	"sinkedin/routes"
+	"sinkedin/store/gormstore"
+	"sinkedin/timeline"
+	"sinkedin/trending"
+	"sinkedin/typeahead"
 )
 
 func main() {
@@ -20,6 +32,17 @@ func main() {
 	// Setup database connection
 	models.SetupDB()
 
+	// Apply pending schema migrations unless disabled
+	if os.Getenv("AUTO_MIGRATE") != "false" {
+		ran, err := migrations.Up(models.DB)
+		if err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+		for _, m := range ran {
+			log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+		}
+	}
+
 	// Create gin router
 	r := gin.Default()
 
@@ -35,8 +58,86 @@ func main() {
 		c.Next()
 	})
 
+	// Share real-time events between instances through Postgres when asked
+	var broker realtime.Broker
+	if os.Getenv("EVENTS_BROKER") == "postgres" {
+		sqlDB, err := models.DB.DB()
+		if err != nil {
+			log.Fatalf("Failed to get database handle: %v", err)
+		}
+		channel := os.Getenv("EVENTS_CHANNEL")
+		if channel == "" {
+			channel = "sinkedin_events"
+		}
+		broker = realtime.NewPostgresBroker(sqlDB, channel)
+	}
+	events, err := realtime.New(realtime.Config{
+		Broker:    broker,
+		Heartbeat: envDuration("EVENTS_HEARTBEAT", 25*time.Second),
+	})
+	if err != nil {
+		log.Fatalf("Failed to start event hub: %v", err)
+	}
+
+	// Start the timeline fan-out workers
+	s := gormstore.New(models.DB)
+	timelines := timeline.New(s, timeline.Config{
+		Workers:     envInt("TIMELINE_WORKERS", 4),
+		FanOutLimit: envInt("TIMELINE_FANOUT_LIMIT", 10000),
+		Events:      events,
+	})
+
+	// Keep the trending hashtag snapshots fresh
+	trendingService := trending.New(s, trending.Config{
+		Interval: envDuration("TRENDING_INTERVAL", 5*time.Minute),
+	})
+
+	// Cache typeahead suggestions briefly, as clients ask on every keystroke
+	typeaheadService := typeahead.New(s, typeahead.Config{
+		TTL: envDuration("TYPEAHEAD_CACHE_TTL", 30*time.Second),
+	})
+
+	notificationService := notifications.New(s, notifications.Config{Events: events})
+
+	// Optionally only let mutual followers message each other
+	messagingService := messaging.New(s, messaging.Config{
+		MutualFollowOnly: os.Getenv("DM_MUTUAL_FOLLOW_ONLY") == "true",
+		MaxGroupSize:     envInt("DM_MAX_GROUP_SIZE", 50),
+		Events:           events,
+	})
+
+	// Suspensions last a week unless the moderator says otherwise
+	moderationService := moderation.New(s, timelines, notificationService, moderation.Config{
+		SuspensionDuration: envDuration("MODERATION_SUSPENSION_DURATION", 7*24*time.Hour),
+		Events:             events,
+	})
+
+	// Refuse to start without a usable JWT signing key
+	keys, err := auth.KeyringFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid JWT configuration: %v", err)
+	}
+	authService, err := auth.New(s.Sessions, auth.Config{
+		Keys:     keys,
+		Issuer:   os.Getenv("JWT_ISSUER"),
+		Audience: os.Getenv("JWT_AUDIENCE"),
+	})
+	if err != nil {
+		log.Fatalf("Invalid JWT configuration: %v", err)
+	}
+
 	// Setup routes
-	routes.SetupRoutes(r)
+	routes.SetupRoutes(r, routes.Services{
+		Store:         s,
+		Auth:          authService,
+		Timelines:     timelines,
+		Trending:      trendingService,
+		Typeahead:     typeaheadService,
+		Notifications: notificationService,
+		Messaging:     messagingService,
+		Moderation:    moderationService,
+		Events:        events,
+	})
 
 	// Get port from environment variable or use default
 	port := os.Getenv("PORT")
@@ -50,3 +151,23 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// envInt reads an integer environment variable, falling back to def when it
+// is unset or malformed.
+func envInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envDuration reads a duration environment variable such as "5m", falling
+// back to def when it is unset or malformed.
+func envDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
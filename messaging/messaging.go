@@ -0,0 +1,286 @@
+// Package messaging runs private conversations between users: one-to-one
+// threads, which are found again rather than duplicated, and named groups.
+// Every change is pushed to the members' live connections.
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sinkedin/models"
+	"sinkedin/realtime"
+	"sinkedin/store"
+)
+
+var (
+	// ErrNotMember is returned for conversations the user is not part of,
+	// which are treated as if they did not exist.
+	ErrNotMember = errors.New("not a member of the conversation")
+	// ErrNotSender is returned when changing someone else's message.
+	ErrNotSender = errors.New("message was sent by another member")
+	// ErrNotMutual is returned when messaging is restricted to mutual
+	// followers and a member does not follow the sender back.
+	ErrNotMutual = errors.New("members must follow each other")
+	// ErrBlocked is returned when a member has blocked the sender or been
+	// blocked by them.
+	ErrBlocked = errors.New("member is blocked")
+	// ErrInvalidMembers is returned for a conversation without other
+	// members, or with more than the configured maximum.
+	ErrInvalidMembers = errors.New("invalid conversation members")
+)
+
+type Config struct {
+	// MutualFollowOnly only lets users message those they follow and who
+	// follow them back.
+	MutualFollowOnly bool
+	// MaxGroupSize caps the number of members of a conversation, including
+	// its creator.
+	MaxGroupSize int
+	// Events receives new, edited and deleted messages and read markers for
+	// the members' live connections. Nil disables publishing.
+	Events realtime.Publisher
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.MaxGroupSize <= 0 {
+		cfg.MaxGroupSize = 50
+	}
+	return cfg
+}
+
+type Service struct {
+	messages store.MessageStore
+	follows  store.FollowStore
+	blocks   store.BlockStore
+	cfg      Config
+}
+
+func New(s *store.Store, cfg Config) *Service {
+	return &Service{
+		messages: s.Messages,
+		follows:  s.Follows,
+		blocks:   s.Blocks,
+		cfg:      cfg.withDefaults(),
+	}
+}
+
+// DirectKey identifies the one-to-one conversation between two users.
+func DirectKey(a, b uint) string {
+	if a > b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d:%d", a, b)
+}
+
+// checkAllowed fails when the user and one of otherIDs have blocked each
+// other or, when messaging is restricted to mutual followers, do not follow
+// each other.
+func (svc *Service) checkAllowed(userID uint, otherIDs []uint) error {
+	blocking, err := svc.blocks.Blocking(userID, otherIDs)
+	if err != nil {
+		return err
+	}
+	if len(blocking) > 0 {
+		return ErrBlocked
+	}
+	if !svc.cfg.MutualFollowOnly {
+		return nil
+	}
+	relationships, err := svc.follows.Relationships(userID, otherIDs)
+	if err != nil {
+		return err
+	}
+	for _, id := range otherIDs {
+		if r := relationships[id]; !r.Following || !r.FollowedBy {
+			return ErrNotMutual
+		}
+	}
+	return nil
+}
+
+// Start opens a conversation between the creator and memberIDs. Unless group
+// is set, a conversation with a single other member is one-to-one, and an
+// existing one is returned with created false.
+func (svc *Service) Start(creatorID uint, memberIDs []uint, group bool, title string) (*models.Conversation, bool, error) {
+	seen := map[uint]bool{creatorID: true}
+	others := make([]uint, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		if !seen[id] {
+			seen[id] = true
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 || len(others)+1 > svc.cfg.MaxGroupSize {
+		return nil, false, ErrInvalidMembers
+	}
+	if err := svc.checkAllowed(creatorID, others); err != nil {
+		return nil, false, err
+	}
+
+	conversation := &models.Conversation{
+		IsGroup:     group || len(others) > 1,
+		CreatedByID: creatorID,
+	}
+	if conversation.IsGroup {
+		conversation.Title = strings.TrimSpace(title)
+	} else {
+		key := DirectKey(creatorID, others[0])
+		conversation.DirectKey = &key
+		existing, err := svc.messages.GetDirect(key)
+		if err == nil {
+			return existing, false, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, false, err
+		}
+	}
+
+	err := svc.messages.CreateConversation(conversation, append([]uint{creatorID}, others...))
+	if errors.Is(err, store.ErrConflict) {
+		// Started concurrently by the other member
+		existing, err := svc.messages.GetDirect(*conversation.DirectKey)
+		return existing, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return conversation, true, nil
+}
+
+// Get returns the conversation if the user is one of its members.
+func (svc *Service) Get(userID, conversationID uint) (*models.Conversation, error) {
+	conversation, err := svc.messages.GetConversation(conversationID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotMember
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range conversation.Members {
+		if m.UserID == userID {
+			return conversation, nil
+		}
+	}
+	return nil, ErrNotMember
+}
+
+// List pages through the user's conversations by latest activity.
+func (svc *Service) List(userID uint, page store.PageRequest) (store.Page[models.Conversation], error) {
+	return svc.messages.ListConversations(userID, page)
+}
+
+// Messages pages through the conversation's messages, newest first.
+func (svc *Service) Messages(userID, conversationID uint, page store.PageRequest) (store.Page[models.Message], error) {
+	if _, err := svc.Get(userID, conversationID); err != nil {
+		return store.Page[models.Message]{}, err
+	}
+	return svc.messages.ListMessages(conversationID, page)
+}
+
+// Send posts a message to the conversation. In a one-to-one conversation
+// blocks and the mutual follow restriction are checked again, as either
+// side may have blocked or unfollowed the other since it started.
+func (svc *Service) Send(senderID, conversationID uint, content string) (*models.Message, error) {
+	conversation, err := svc.Get(senderID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.IsGroup {
+		if err := svc.checkAllowed(senderID, otherMembers(conversation, senderID)); err != nil {
+			return nil, err
+		}
+	}
+
+	message := &models.Message{ConversationID: conversationID, SenderID: senderID, Content: content}
+	if err := svc.messages.Send(message); err != nil {
+		return nil, err
+	}
+	svc.publish(conversation, realtime.MessageEvent, message)
+	return message, nil
+}
+
+// message returns one of the conversation's messages sent by the user.
+func (svc *Service) message(userID, conversationID, messageID uint) (*models.Conversation, *models.Message, error) {
+	conversation, err := svc.Get(userID, conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	message, err := svc.messages.GetMessage(messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if message.ConversationID != conversationID {
+		return nil, nil, store.ErrNotFound
+	}
+	if message.SenderID != userID {
+		return nil, nil, ErrNotSender
+	}
+	return conversation, message, nil
+}
+
+// Edit replaces the content of one of the user's messages.
+func (svc *Service) Edit(userID, conversationID, messageID uint, content string) (*models.Message, error) {
+	conversation, message, err := svc.message(userID, conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	message.Content = content
+	if err := svc.messages.UpdateMessage(message); err != nil {
+		return nil, err
+	}
+	svc.publish(conversation, realtime.MessageUpdatedEvent, message)
+	return message, nil
+}
+
+// Delete removes one of the user's messages.
+func (svc *Service) Delete(userID, conversationID, messageID uint) error {
+	conversation, message, err := svc.message(userID, conversationID, messageID)
+	if err != nil {
+		return err
+	}
+	if err := svc.messages.DeleteMessage(message.ID); err != nil {
+		return err
+	}
+	svc.publish(conversation, realtime.MessageDeletedEvent, map[string]uint{
+		"conversationId": conversationID,
+		"id":             message.ID,
+	})
+	return nil
+}
+
+// MarkRead moves the user's read marker up to messageID, or to the latest
+// message when it is zero.
+func (svc *Service) MarkRead(userID, conversationID, messageID uint) (*models.ConversationMember, error) {
+	conversation, err := svc.Get(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := svc.messages.MarkRead(conversationID, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	svc.publish(conversation, realtime.ConversationReadEvent, member)
+	return member, nil
+}
+
+func otherMembers(conversation *models.Conversation, userID uint) []uint {
+	var ids []uint
+	for _, m := range conversation.Members {
+		if m.UserID != userID {
+			ids = append(ids, m.UserID)
+		}
+	}
+	return ids
+}
+
+// publish pushes the event to every member's live connections, including the
+// actor's, so that their other clients stay in step.
+func (svc *Service) publish(conversation *models.Conversation, eventType string, data interface{}) {
+	if svc.cfg.Events == nil {
+		return
+	}
+	for _, m := range conversation.Members {
+		svc.cfg.Events.Publish(realtime.UserTopic(m.UserID), eventType, data)
+	}
+}
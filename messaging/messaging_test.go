@@ -0,0 +1,58 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"sinkedin/models"
+	"sinkedin/store/memstore"
+)
+
+func TestMutualFollowOnly(t *testing.T) {
+	s := memstore.New()
+	var users []models.User
+	for i := 0; i < 3; i++ {
+		user := models.User{Name: fmt.Sprint("user", i), Username: fmt.Sprint("user", i), Email: fmt.Sprint("user", i, "@example.com"), Password: "x"}
+		if err := s.Users.Create(&user); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+	}
+	a, b, c := users[0].ID, users[1].ID, users[2].ID
+	svc := New(s, Config{MutualFollowOnly: true})
+
+	toggle := func(follower, following uint) {
+		t.Helper()
+		if _, err := s.Follows.Toggle(follower, following); err != nil {
+			t.Fatal(err)
+		}
+	}
+	toggle(a, b)
+	if _, _, err := svc.Start(a, []uint{b}, false, ""); !errors.Is(err, ErrNotMutual) {
+		t.Fatalf("Start with a one-way follow: %v", err)
+	}
+
+	toggle(b, a)
+	conversation, created, err := svc.Start(a, []uint{b}, false, "")
+	if err != nil || !created {
+		t.Fatalf("Start between mutual followers = %v, %v", created, err)
+	}
+	if _, _, err := svc.Start(a, []uint{b, c}, false, ""); !errors.Is(err, ErrNotMutual) {
+		t.Errorf("Start a group including a stranger: %v", err)
+	}
+	if again, created, err := svc.Start(b, []uint{a}, false, ""); err != nil || created || again.ID != conversation.ID {
+		t.Errorf("Start again from the other side = %v, %v, %v", again, created, err)
+	}
+
+	if _, err := svc.Send(b, conversation.ID, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	toggle(a, b)
+	if _, err := svc.Send(b, conversation.ID, "still there?"); !errors.Is(err, ErrNotMutual) {
+		t.Errorf("Send after an unfollow: %v", err)
+	}
+	if _, err := svc.Send(c, conversation.ID, "hello"); !errors.Is(err, ErrNotMember) {
+		t.Errorf("Send by a non-member: %v", err)
+	}
+}
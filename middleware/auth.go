@@ -1,36 +1,94 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
+	"sinkedin/auth"
+	"sinkedin/models"
+	"sinkedin/store"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(authService *auth.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		if c.GetHeader("Authorization") == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
 			c.Abort()
 			return
 		}
 
-		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		if authenticate(c, authService) {
+			c.Next()
+		}
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+// OptionalAuth identifies the caller when a token is present but lets
+// anonymous requests through, leaving userId unset. A token that is present
+// but invalid is still rejected, so clients know to refresh it.
+func OptionalAuth(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
 			return
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
-		c.Set("userId", uint(claims["userId"].(float64)))
+		if authenticate(c, authService) {
+			c.Next()
+		}
+	}
+}
+
+// StreamAuth is AuthMiddleware for event streams. Browsers cannot set headers
+// on EventSource and WebSocket requests, so the token may instead be passed
+// in the access_token query parameter.
+func StreamAuth(authService *auth.Service) gin.HandlerFunc {
+	requireAuth := AuthMiddleware(authService)
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		requireAuth(c)
+	}
+}
+
+// authenticate validates the bearer token and stores the caller's identity in
+// the context, aborting with 401 when the token is rejected.
+func authenticate(c *gin.Context, authService *auth.Service) bool {
+	tokenString := strings.Replace(c.GetHeader("Authorization"), "Bearer ", "", 1)
+	claims, err := authService.Authenticate(tokenString)
+	if err != nil {
+		message := "Invalid token"
+		if errors.Is(err, auth.ErrSessionRevoked) {
+			message = "Session has been revoked"
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+		c.Abort()
+		return false
+	}
+
+	c.Set("userId", claims.UserID)
+	c.Set("sessionId", claims.SessionID)
+	return true
+}
+
+// RequireAdmin lets through only callers with the admin role. It must run
+// after AuthMiddleware, which identifies the caller.
+func RequireAdmin(users store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := users.GetByID(c.GetUint("userId"))
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check role"})
+			c.Abort()
+			return
+		}
+		if err != nil || user.Role != models.AdminRole {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
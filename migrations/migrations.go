@@ -0,0 +1,277 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+// lockID is the key of the advisory lock held while a migration runs, so that
+// several server instances starting at once don't race on the same step.
+const lockID = 72_011_845
+
+// Migration is a single versioned schema change with its rollback.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaMigration is the bookkeeping row recorded for every applied version.
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status describes whether a known migration has been applied.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Load reads the embedded migration files. Files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql, and versions run
+// from 1 without gaps.
+func Load() ([]Migration, error) {
+	return load(sqlFiles)
+}
+
+// load reads the migration files in the sql directory of fsys.
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migrations: unexpected file %q", fileName)
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionPart, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migrations: file %q is missing a name", fileName)
+		}
+		version, err := strconv.ParseInt(versionPart, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrations: file %q has an invalid version: %w", fileName, err)
+		}
+
+		contents, err := fs.ReadFile(fsys, path.Join("sql", fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migrations: version %d is used by both %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrations: version %d (%s) needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	// A gap usually means a file went missing in a merge, and applying the
+	// rest would leave the schema without it
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			return nil, fmt.Errorf("migrations: expected version %d but found %d (%s)", i+1, m.Version, m.Name)
+		}
+	}
+
+	return migrations, nil
+}
+
+func ensureTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       varchar(255) NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+}
+
+func applied(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// that ran. Each migration runs in its own transaction.
+func Up(db *gorm.DB) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, m := range migrations {
+		didRun := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockID).Error; err != nil {
+				return err
+			}
+
+			var count int64
+			if err := tx.Model(&SchemaMigration{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			didRun = true
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migrations: applying %d_%s: %w", m.Version, m.Name, err)
+		}
+		if didRun {
+			ran = append(ran, m)
+		}
+	}
+
+	return ran, nil
+}
+
+// Down rolls back the most recently applied migrations, at most steps of them,
+// and returns the ones that were reverted.
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+
+	known := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	var reverted []Migration
+	for i := 0; i < steps; i++ {
+		var m Migration
+		done := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockID).Error; err != nil {
+				return err
+			}
+
+			var latest SchemaMigration
+			result := tx.Order("version desc").Limit(1).Find(&latest)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				done = true
+				return nil
+			}
+
+			var ok bool
+			m, ok = known[latest.Version]
+			if !ok {
+				return fmt.Errorf("applied version %d (%s) has no migration file", latest.Version, latest.Name)
+			}
+
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migrations: reverting: %w", err)
+		}
+		if done {
+			break
+		}
+		reverted = append(reverted, m)
+	}
+
+	return reverted, nil
+}
+
+// Statuses lists every known migration alongside whether it has been applied.
+func Statuses(db *gorm.DB) ([]Status, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		status := Status{Version: m.Version, Name: m.Name}
+		if row, ok := done[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
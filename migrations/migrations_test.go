@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// files builds a migration directory from file names, each holding its own
+// name as contents.
+func files(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		fsys["sql/"+name] = &fstest.MapFile{Data: []byte(name)}
+	}
+	return fsys
+}
+
+func TestLoadPairsAndOrders(t *testing.T) {
+	migrations, err := load(files(
+		"0010_ten.down.sql", "0010_ten.up.sql",
+		"0002_with_underscores.up.sql", "0002_with_underscores.down.sql",
+		"0001_first.up.sql", "0001_first.down.sql",
+		"0003_third.up.sql", "0003_third.down.sql",
+		"0004_a.up.sql", "0004_a.down.sql", "0005_b.up.sql", "0005_b.down.sql",
+		"0006_c.up.sql", "0006_c.down.sql", "0007_d.up.sql", "0007_d.down.sql",
+		"0008_e.up.sql", "0008_e.down.sql", "0009_f.up.sql", "0009_f.down.sql",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 10 {
+		t.Fatalf("loaded %d migrations", len(migrations))
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d has version %d", i, m.Version)
+		}
+	}
+	second := migrations[1]
+	if second.Name != "with_underscores" || second.Up != "0002_with_underscores.up.sql" || second.Down != "0002_with_underscores.down.sql" {
+		t.Errorf("second migration = %+v", second)
+	}
+	if last := migrations[9]; last.Name != "ten" {
+		t.Errorf("last migration = %+v, want version 10 after 9", last)
+	}
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{"gap", files("0001_a.up.sql", "0001_a.down.sql", "0003_c.up.sql", "0003_c.down.sql"), "expected version 2"},
+		{"not starting at 1", files("0002_b.up.sql", "0002_b.down.sql"), "expected version 1"},
+		{"duplicate version", files("0001_a.up.sql", "0001_a.down.sql", "0001_b.up.sql", "0001_b.down.sql"), "used by both"},
+		{"missing down", files("0001_a.up.sql", "0001_a.down.sql", "0002_b.up.sql"), "needs both"},
+		{"missing up", files("0001_a.down.sql"), "needs both"},
+		{"empty file", fstest.MapFS{"sql/0001_a.up.sql": {Data: []byte("SELECT 1")}, "sql/0001_a.down.sql": {}}, "needs both"},
+		{"unexpected extension", files("0001_a.up.sql", "0001_a.down.sql", "README.md"), "unexpected file"},
+		{"missing name", files("0001.up.sql", "0001.down.sql"), "missing a name"},
+		{"bad version", files("one_a.up.sql", "one_a.down.sql"), "invalid version"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := load(tc.files)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error = %v, want one mentioning %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Name != "initial_schema" {
+		t.Errorf("embedded migrations start with %+v", migrations)
+	}
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS comment_hashtags;
+DROP TABLE IF EXISTS comment_tags;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS post_hashtags;
+DROP TABLE IF EXISTS follows;
+DROP TABLE IF EXISTS likes;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS hashtags;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,143 @@
+CREATE TABLE users (
+    id              serial PRIMARY KEY,
+    name            varchar(100) NOT NULL,
+    username        varchar(50)  NOT NULL,
+    email           varchar(100) NOT NULL,
+    phone_number    varchar(20),
+    password        varchar(255) NOT NULL,
+    bio             varchar(500),
+    followers_count bigint DEFAULT 0,
+    following_count bigint DEFAULT 0,
+    dob             timestamptz,
+    photo_url       varchar(255),
+    banner_url      varchar(255),
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    deleted_at      timestamptz
+);
+CREATE UNIQUE INDEX idx_users_username ON users (username);
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE posts (
+    id            serial PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    content       text   NOT NULL,
+    has_image     boolean DEFAULT false,
+    has_tag       boolean DEFAULT false,
+    has_hashtag   boolean DEFAULT false,
+    image_url     varchar(255),
+    like_count    bigint DEFAULT 0,
+    comment_count bigint DEFAULT 0,
+    is_quote      boolean DEFAULT false,
+    quote_lines   varchar(500),
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    deleted_at    timestamptz,
+    CONSTRAINT fk_posts_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_posts_user_id ON posts (user_id);
+CREATE INDEX idx_posts_created_at ON posts (created_at);
+CREATE INDEX idx_posts_deleted_at ON posts (deleted_at);
+
+CREATE TABLE hashtags (
+    id         serial PRIMARY KEY,
+    name       varchar(50) NOT NULL,
+    counter    bigint DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE UNIQUE INDEX idx_hashtags_name ON hashtags (name);
+CREATE INDEX idx_hashtags_deleted_at ON hashtags (deleted_at);
+
+CREATE TABLE comments (
+    id                serial PRIMARY KEY,
+    user_id           bigint NOT NULL,
+    post_id           bigint,
+    parent_comment_id bigint,
+    type              varchar(10) NOT NULL DEFAULT 'normal',
+    content           varchar(500),
+    contains_tag      boolean DEFAULT false,
+    contains_hashtag  boolean DEFAULT false,
+    like_count        bigint DEFAULT 0,
+    comment_count     bigint DEFAULT 0,
+    created_at        timestamptz,
+    updated_at        timestamptz,
+    deleted_at        timestamptz,
+    CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_comments_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_comments_parent_comment FOREIGN KEY (parent_comment_id) REFERENCES comments (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_comments_user_id ON comments (user_id);
+CREATE INDEX idx_comments_post_id ON comments (post_id);
+CREATE INDEX idx_comments_parent_comment_id ON comments (parent_comment_id);
+CREATE INDEX idx_comments_created_at ON comments (created_at);
+CREATE INDEX idx_comments_deleted_at ON comments (deleted_at);
+
+CREATE TABLE likes (
+    id         serial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    parent_id  bigint NOT NULL,
+    type       varchar(10) NOT NULL,
+    created_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT fk_likes_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_user_parent_type ON likes (user_id, parent_id, type);
+CREATE INDEX idx_likes_deleted_at ON likes (deleted_at);
+
+CREATE TABLE follows (
+    follower_id  bigint NOT NULL,
+    following_id bigint NOT NULL,
+    created_at   timestamptz,
+    deleted_at   timestamptz,
+    PRIMARY KEY (follower_id, following_id),
+    CONSTRAINT fk_follows_follower FOREIGN KEY (follower_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_follows_following FOREIGN KEY (following_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_follows_following_id ON follows (following_id);
+CREATE INDEX idx_follows_deleted_at ON follows (deleted_at);
+
+CREATE TABLE post_hashtags (
+    post_id    bigint NOT NULL,
+    hashtag_id bigint NOT NULL,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, hashtag_id),
+    CONSTRAINT fk_post_hashtags_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_post_hashtags_hashtag FOREIGN KEY (hashtag_id) REFERENCES hashtags (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_post_hashtag ON post_hashtags (post_id, hashtag_id);
+CREATE INDEX idx_post_hashtags_hashtag_id ON post_hashtags (hashtag_id);
+
+CREATE TABLE post_tags (
+    post_id    bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, user_id),
+    CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_post_tags_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_post_tag ON post_tags (post_id, user_id);
+CREATE INDEX idx_post_tags_user_id ON post_tags (user_id);
+
+CREATE TABLE comment_tags (
+    comment_id bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (comment_id, user_id),
+    CONSTRAINT fk_comment_tags_comment FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_tags_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_comment_tag ON comment_tags (comment_id, user_id);
+
+CREATE TABLE comment_hashtags (
+    comment_id bigint NOT NULL,
+    hashtag_id bigint NOT NULL,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (comment_id, hashtag_id),
+    CONSTRAINT fk_comment_hashtags_comment FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_hashtags_hashtag FOREIGN KEY (hashtag_id) REFERENCES hashtags (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_comment_hashtag ON comment_hashtags (comment_id, hashtag_id);
+CREATE INDEX idx_comment_hashtags_hashtag_id ON comment_hashtags (hashtag_id);
@@ -0,0 +1,12 @@
+CREATE TABLE timeline_entries (
+    user_id    bigint NOT NULL,
+    post_id    bigint NOT NULL,
+    author_id  bigint NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (user_id, post_id),
+    CONSTRAINT fk_timeline_entries_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_timeline_entries_post FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_timeline_entries_post_id ON timeline_entries (post_id);
+CREATE INDEX idx_timeline_entries_user_author ON timeline_entries (user_id, author_id);
+CREATE INDEX idx_timeline_entries_user_created_at ON timeline_entries (user_id, created_at DESC, post_id DESC);
@@ -0,0 +1,15 @@
+CREATE TABLE sessions (
+    id                  serial PRIMARY KEY,
+    user_id             bigint NOT NULL,
+    refresh_token_hash  varchar(64) NOT NULL,
+    previous_token_hash varchar(64),
+    user_agent          varchar(255),
+    ip_address          varchar(64),
+    expires_at          timestamptz NOT NULL,
+    last_used_at        timestamptz,
+    revoked_at          timestamptz,
+    created_at          timestamptz,
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX idx_sessions_revoked_at ON sessions (revoked_at);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_comments_parent_created_at_id;
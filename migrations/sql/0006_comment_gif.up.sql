@@ -0,0 +1 @@
+ALTER TABLE comments ADD COLUMN gif jsonb;
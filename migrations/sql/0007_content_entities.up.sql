@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN entities jsonb;
+ALTER TABLE comments ADD COLUMN entities jsonb;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comment_hashtags_created_at;
+DROP INDEX IF EXISTS idx_post_hashtags_created_at;
+DROP TABLE IF EXISTS trending_hashtags;
@@ -0,0 +1,27 @@
+CREATE TABLE trending_hashtags (
+    "window"      varchar(8) NOT NULL,
+    hashtag_id    bigint NOT NULL,
+    rank          integer NOT NULL,
+    score         double precision NOT NULL,
+    uses          integer NOT NULL,
+    previous_uses integer NOT NULL,
+    computed_at   timestamptz NOT NULL,
+    PRIMARY KEY ("window", hashtag_id),
+    CONSTRAINT fk_trending_hashtags_hashtag FOREIGN KEY (hashtag_id) REFERENCES hashtags (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_trending_hashtags_window_rank ON trending_hashtags ("window", rank);
+
+CREATE INDEX idx_post_hashtags_created_at ON post_hashtags (created_at);
+CREATE INDEX idx_comment_hashtags_created_at ON comment_hashtags (created_at);
+
+-- Counters were never decremented for deleted posts nor incremented for
+-- comments; rebuild them from the live uses
+UPDATE hashtags SET counter = (
+    SELECT count(*) FROM post_hashtags
+    JOIN posts ON posts.id = post_hashtags.post_id AND posts.deleted_at IS NULL
+    WHERE post_hashtags.hashtag_id = hashtags.id
+) + (
+    SELECT count(*) FROM comment_hashtags
+    JOIN comments ON comments.id = comment_hashtags.comment_id AND comments.deleted_at IS NULL
+    WHERE comment_hashtags.hashtag_id = hashtags.id
+);
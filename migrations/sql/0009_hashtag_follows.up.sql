@@ -0,0 +1,10 @@
+CREATE TABLE hashtag_follows (
+    user_id    bigint NOT NULL,
+    hashtag_id bigint NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (user_id, hashtag_id),
+    CONSTRAINT fk_hashtag_follows_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_hashtag_follows_hashtag FOREIGN KEY (hashtag_id) REFERENCES hashtags (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_hashtag_follows_hashtag_id ON hashtag_follows (hashtag_id);
+CREATE INDEX idx_hashtag_follows_user_created_at ON hashtag_follows (user_id, created_at DESC);
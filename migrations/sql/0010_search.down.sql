@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_hashtags_name_trgm;
+ALTER TABLE users DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE comments DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE posts DROP COLUMN IF EXISTS search_vector;
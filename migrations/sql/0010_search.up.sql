@@ -0,0 +1,20 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('english', coalesce(content, '') || ' ' || coalesce(quote_lines, ''))
+) STORED;
+CREATE INDEX idx_posts_search_vector ON posts USING gin (search_vector);
+
+ALTER TABLE comments ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('english', coalesce(content, ''))
+) STORED;
+CREATE INDEX idx_comments_search_vector ON comments USING gin (search_vector);
+
+-- Names and usernames rank above the bio
+ALTER TABLE users ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(username, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(bio, '')), 'B')
+) STORED;
+CREATE INDEX idx_users_search_vector ON users USING gin (search_vector);
+
+CREATE INDEX idx_hashtags_name_trgm ON hashtags USING gin (name gin_trgm_ops);
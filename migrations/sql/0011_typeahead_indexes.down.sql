@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_hashtags_name_prefix;
+DROP INDEX IF EXISTS idx_users_name_prefix;
+DROP INDEX IF EXISTS idx_users_username_prefix;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_users_username_prefix ON users (lower(username) text_pattern_ops);
+CREATE INDEX idx_users_name_prefix ON users (lower(name) text_pattern_ops);
+CREATE INDEX idx_hashtags_name_prefix ON hashtags (name text_pattern_ops);
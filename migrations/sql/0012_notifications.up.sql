@@ -0,0 +1,27 @@
+CREATE TABLE notifications (
+    id            serial PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    type          varchar(16) NOT NULL,
+    target_type   varchar(16) NOT NULL,
+    target_id     bigint NOT NULL,
+    group_key     varchar(64) NOT NULL,
+    actor_count   integer NOT NULL DEFAULT 0,
+    last_actor_id bigint NOT NULL,
+    read_at       timestamptz,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_notifications_user_updated_at ON notifications (user_id, updated_at DESC, id DESC);
+-- New actors join the unread notification of their group
+CREATE UNIQUE INDEX idx_notifications_user_group_unread ON notifications (user_id, group_key) WHERE read_at IS NULL;
+
+CREATE TABLE notification_actors (
+    notification_id bigint NOT NULL,
+    user_id         bigint NOT NULL,
+    created_at      timestamptz NOT NULL,
+    PRIMARY KEY (notification_id, user_id),
+    CONSTRAINT fk_notification_actors_notification FOREIGN KEY (notification_id) REFERENCES notifications (id) ON DELETE CASCADE,
+    CONSTRAINT fk_notification_actors_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_notification_actors_created_at ON notification_actors (notification_id, created_at DESC);
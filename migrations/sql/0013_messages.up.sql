@@ -0,0 +1,38 @@
+CREATE TABLE conversations (
+    id              serial PRIMARY KEY,
+    is_group        boolean NOT NULL DEFAULT false,
+    title           varchar(100),
+    created_by_id   bigint NOT NULL,
+    direct_key      varchar(32),
+    last_message_at timestamptz NOT NULL,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+CREATE UNIQUE INDEX idx_conversations_direct_key ON conversations (direct_key);
+
+CREATE TABLE conversation_members (
+    conversation_id      bigint NOT NULL,
+    user_id              bigint NOT NULL,
+    last_read_message_id bigint NOT NULL DEFAULT 0,
+    last_read_at         timestamptz,
+    joined_at            timestamptz NOT NULL,
+    PRIMARY KEY (conversation_id, user_id),
+    CONSTRAINT fk_conversation_members_conversation FOREIGN KEY (conversation_id) REFERENCES conversations (id) ON DELETE CASCADE,
+    CONSTRAINT fk_conversation_members_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_conversation_members_user_id ON conversation_members (user_id);
+
+CREATE TABLE messages (
+    id              serial PRIMARY KEY,
+    conversation_id bigint NOT NULL,
+    sender_id       bigint NOT NULL,
+    content         text NOT NULL,
+    edited_at       timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    deleted_at      timestamptz,
+    CONSTRAINT fk_messages_conversation FOREIGN KEY (conversation_id) REFERENCES conversations (id) ON DELETE CASCADE,
+    CONSTRAINT fk_messages_sender FOREIGN KEY (sender_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_messages_conversation_created_at ON messages (conversation_id, created_at DESC, id DESC);
+CREATE INDEX idx_messages_deleted_at ON messages (deleted_at);
@@ -0,0 +1,19 @@
+CREATE TABLE blocks (
+    blocker_id bigint NOT NULL,
+    blocked_id bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT fk_blocks_blocker FOREIGN KEY (blocker_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_blocks_blocked FOREIGN KEY (blocked_id) REFERENCES users (id) ON DELETE CASCADE
+);
+-- Blocks are checked from both sides
+CREATE INDEX idx_blocks_blocked_id ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+    user_id    bigint NOT NULL,
+    muted_id   bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (user_id, muted_id),
+    CONSTRAINT fk_mutes_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_mutes_muted FOREIGN KEY (muted_id) REFERENCES users (id) ON DELETE CASCADE
+);
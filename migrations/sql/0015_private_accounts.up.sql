@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN is_private boolean NOT NULL DEFAULT false;
+
+CREATE TABLE follow_requests (
+    id           serial PRIMARY KEY,
+    requester_id bigint NOT NULL,
+    target_id    bigint NOT NULL,
+    status       varchar(16) NOT NULL DEFAULT 'pending',
+    created_at   timestamptz,
+    decided_at   timestamptz,
+    CONSTRAINT fk_follow_requests_requester FOREIGN KEY (requester_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_follow_requests_target FOREIGN KEY (target_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_follow_requests_pair ON follow_requests (requester_id, target_id);
+-- Incoming requests are listed newest first
+CREATE INDEX idx_follow_requests_target_pending ON follow_requests (target_id, created_at DESC, id DESC) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN visibility varchar(16) NOT NULL DEFAULT 'public';
+ALTER TABLE posts ADD COLUMN reply_policy varchar(16) NOT NULL DEFAULT 'everyone';
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS moderation_actions;
+DROP TABLE IF EXISTS reports;
+ALTER TABLE comments DROP COLUMN IF EXISTS is_hidden;
+ALTER TABLE posts DROP COLUMN IF EXISTS is_hidden;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,43 @@
+ALTER TABLE users ADD COLUMN role varchar(16) NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN suspended_until timestamptz;
+ALTER TABLE posts ADD COLUMN is_hidden boolean NOT NULL DEFAULT false;
+ALTER TABLE comments ADD COLUMN is_hidden boolean NOT NULL DEFAULT false;
+
+CREATE TABLE reports (
+    id            serial PRIMARY KEY,
+    reporter_id   bigint NOT NULL,
+    target_type   varchar(16) NOT NULL,
+    target_id     bigint NOT NULL,
+    reason        varchar(32) NOT NULL,
+    details       varchar(1000),
+    status        varchar(16) NOT NULL DEFAULT 'open',
+    handled_by_id bigint,
+    resolved_at   timestamptz,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    CONSTRAINT fk_reports_reporter FOREIGN KEY (reporter_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_reports_handled_by FOREIGN KEY (handled_by_id) REFERENCES users (id)
+);
+CREATE INDEX idx_reports_reporter_id ON reports (reporter_id);
+-- One unresolved report per reporter and target
+CREATE UNIQUE INDEX idx_reports_unresolved ON reports (reporter_id, target_type, target_id) WHERE status IN ('open', 'triaged');
+-- The queue is listed by status, newest first
+CREATE INDEX idx_reports_queue ON reports (status, created_at DESC, id DESC);
+CREATE INDEX idx_reports_target ON reports (target_type, target_id);
+
+CREATE TABLE moderation_actions (
+    id           serial PRIMARY KEY,
+    moderator_id bigint NOT NULL,
+    action       varchar(16) NOT NULL,
+    target_type  varchar(16) NOT NULL,
+    target_id    bigint NOT NULL,
+    report_id    bigint,
+    note         varchar(1000),
+    until        timestamptz,
+    created_at   timestamptz,
+    CONSTRAINT fk_moderation_actions_moderator FOREIGN KEY (moderator_id) REFERENCES users (id),
+    CONSTRAINT fk_moderation_actions_report FOREIGN KEY (report_id) REFERENCES reports (id) ON DELETE SET NULL
+);
+CREATE INDEX idx_moderation_actions_moderator_id ON moderation_actions (moderator_id);
+CREATE INDEX idx_moderation_actions_report_id ON moderation_actions (report_id);
+CREATE INDEX idx_moderation_actions_target ON moderation_actions (target_type, target_id, created_at DESC);
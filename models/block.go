@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Block cuts two users off from each other: neither can follow, tag, reply
+// to or like the other, and each disappears from the other's listings.
+type Block struct {
+	BlockerID uint      `gorm:"primaryKey;autoIncrement:false" json:"blockerId"`
+	BlockedID uint      `gorm:"primaryKey;autoIncrement:false;index" json:"blockedId"`
+	Blocked   User      `gorm:"foreignKey:BlockedID;references:ID;constraint:OnDelete:CASCADE" json:"blocked"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Mute hides the muted user's posts and comments from the muter's listings
+// without them being told or losing the ability to interact.
+type Mute struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"userId"`
+	MutedID   uint      `gorm:"primaryKey;autoIncrement:false" json:"mutedId"`
+	Muted     User      `gorm:"foreignKey:MutedID;references:ID;constraint:OnDelete:CASCADE" json:"muted"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -13,6 +13,13 @@ import (
 
 var DB *gorm.DB
 
+type Role string
+
+const (
+    UserRole  Role = "user"
+    AdminRole Role = "admin"
+)
+
 type User struct {
     ID            uint           `gorm:"primaryKey;type:serial" json:"id"`
     Name          string         `gorm:"type:varchar(100);not null" json:"name"`
@@ -26,11 +33,40 @@ type User struct {
     DOB           *time.Time     `json:"dob"`
     PhotoURL      string         `gorm:"type:varchar(255)" json:"photoURL"`
     BannerURL     string         `gorm:"type:varchar(255)" json:"bannerURL"`
+    // IsPrivate limits the user's posts, comments and follow lists to the
+    // followers they approved
+    IsPrivate     bool           `gorm:"not null;default:false" json:"isPrivate"`
+    // Role is UserRole or, for those who moderate reports, AdminRole. It is
+    // only shown to the user themselves
+    Role          Role           `gorm:"type:varchar(16);not null;default:'user'" json:"-"`
+    // SuspendedUntil keeps the user from logging in while it is in the future
+    SuspendedUntil *time.Time    `json:"-"`
     CreatedAt     time.Time      `json:"createdAt"`
     UpdatedAt     time.Time      `json:"updatedAt"`
     DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// PostVisibility decides who besides the author may see a post.
+type PostVisibility string
+
+const (
+    PublicVisibility    PostVisibility = "public"
+    FollowersVisibility PostVisibility = "followers"
+    MentionedVisibility PostVisibility = "mentioned"
+    // PrivateVisibility keeps the post to its author, as a draft
+    PrivateVisibility   PostVisibility = "private"
+)
+
+// ReplyPolicy decides who besides the author may comment on a post.
+type ReplyPolicy string
+
+const (
+    EveryoneReplies  ReplyPolicy = "everyone"
+    FollowersReplies ReplyPolicy = "followers"
+    MentionedReplies ReplyPolicy = "mentioned"
+    NoReplies        ReplyPolicy = "nobody"
+)
+
 type Post struct {
     ID           uint           `gorm:"primaryKey;type:serial" json:"id"`
     UserID       uint           `gorm:"not null;index" json:"userId"`
@@ -44,6 +80,11 @@ type Post struct {
     CommentCount int            `gorm:"default:0" json:"commentCount"`
     IsQuote      bool           `gorm:"default:false" json:"isQuote"`
     QuoteLines   string         `gorm:"type:varchar(500)" json:"quoteLines"`
+    Entities     []Entity       `gorm:"type:jsonb;serializer:json" json:"entities"`
+    Visibility   PostVisibility `gorm:"type:varchar(16);not null;default:'public'" json:"visibility"`
+    ReplyPolicy  ReplyPolicy    `gorm:"type:varchar(16);not null;default:'everyone'" json:"replyPolicy"`
+    // IsHidden is set by moderators and keeps the post to its author
+    IsHidden     bool           `gorm:"not null;default:false" json:"isHidden"`
     CreatedAt    time.Time      `gorm:"index" json:"createdAt"` 
     UpdatedAt    time.Time      `json:"updatedAt"`
     DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
@@ -51,6 +92,9 @@ type Post struct {
     // Many-to-many relationships
     Hashtags     []Hashtag      `gorm:"many2many:post_hashtags;" json:"hashtags"`
     Tags         []User         `gorm:"many2many:post_tags;" json:"tags"`
+
+    // Viewer is filled in per request for authenticated readers
+    Viewer       *ViewerState   `gorm:"-" json:"viewer,omitempty"`
 }
 
 type Hashtag struct {
@@ -100,8 +144,12 @@ type Comment struct {
     ParentComment   *Comment       `gorm:"foreignKey:ParentCommentID;references:ID;constraint:OnDelete:CASCADE" json:"parentComment"`
     Type            CommentType    `gorm:"type:varchar(10);not null;default:'normal'" json:"type"`
     Content         string         `gorm:"type:varchar(500)" json:"content"`
+    Gif             *GifPayload    `gorm:"type:jsonb;serializer:json" json:"gif,omitempty"`
+    Entities        []Entity       `gorm:"type:jsonb;serializer:json" json:"entities"`
     ContainsTag     bool           `gorm:"default:false" json:"containsTag"`
     ContainsHashtag bool           `gorm:"default:false" json:"containsHashtag"`
+    // IsHidden is set by moderators and keeps the comment to its author
+    IsHidden        bool           `gorm:"not null;default:false" json:"isHidden"`
     LikeCount       int            `gorm:"default:0" json:"likeCount"`
     CommentCount    int            `gorm:"default:0" json:"commentCount"`
     CreatedAt       time.Time      `gorm:"index" json:"createdAt"`
@@ -109,6 +157,7 @@ type Comment struct {
     DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
     Tags            []User         `gorm:"many2many:comment_tags;" json:"tags"`
     Hashtags        []Hashtag      `gorm:"many2many:comment_hashtags;" json:"hashtags"`
+    Viewer          *ViewerState   `gorm:"-" json:"viewer,omitempty"`
 }
 
 type LikeType string
@@ -201,7 +250,8 @@ func SetupDB() {
     )
 
     db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-        Logger: dbLogger,
+        Logger:         dbLogger,
+        TranslateError: true,
     })
 
     if err != nil {
@@ -0,0 +1,20 @@
+package models
+
+type EntityType string
+
+const (
+	HashtagEntity EntityType = "hashtag"
+	MentionEntity EntityType = "mention"
+)
+
+// Entity marks a #hashtag or @mention found in the content of a post or
+// comment. Start and End are offsets in Unicode code points, End exclusive,
+// and cover the leading # or @. Text is the normalized hashtag name or the
+// mentioned username.
+type Entity struct {
+	Type   EntityType `json:"type"`
+	Text   string     `json:"text"`
+	Start  int        `json:"start"`
+	End    int        `json:"end"`
+	UserID uint       `json:"userId,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type FollowRequestStatus string
+
+const (
+	FollowRequestPending  FollowRequestStatus = "pending"
+	FollowRequestAccepted FollowRequestStatus = "accepted"
+	FollowRequestRejected FollowRequestStatus = "rejected"
+)
+
+// FollowRequest asks a private user to approve a follow. There is one per
+// requester and target; following again after a rejection reopens it.
+type FollowRequest struct {
+	ID          uint                `gorm:"primaryKey;type:serial" json:"id"`
+	RequesterID uint                `gorm:"not null;uniqueIndex:idx_follow_requests_pair" json:"requesterId"`
+	Requester   User                `gorm:"foreignKey:RequesterID;references:ID;constraint:OnDelete:CASCADE" json:"requester"`
+	TargetID    uint                `gorm:"not null;uniqueIndex:idx_follow_requests_pair" json:"targetId"`
+	Status      FollowRequestStatus `gorm:"type:varchar(16);not null;default:pending" json:"status"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	// DecidedAt is when the request was accepted or rejected
+	DecidedAt *time.Time `json:"decidedAt"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// GifPayload describes the GIF attached to a GifComment. It is stored as JSON
+// alongside the comment.
+type GifPayload struct {
+	URL        string `json:"url"`
+	PreviewURL string `json:"previewUrl,omitempty"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Provider   string `json:"provider,omitempty"`
+}
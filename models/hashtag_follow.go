@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// HashtagFollow subscribes a user to a hashtag, so that posts using it show
+// up in their home timeline.
+type HashtagFollow struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"userId"`
+	HashtagID uint      `gorm:"primaryKey;autoIncrement:false;index" json:"hashtagId"`
+	CreatedAt time.Time `gorm:"not null" json:"createdAt"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Conversation is a private thread of messages between its members, either
+// two users or a named group. LastMessageAt moves with every message sent
+// and orders each member's conversation list.
+type Conversation struct {
+	ID          uint   `gorm:"primaryKey;type:serial" json:"id"`
+	IsGroup     bool   `gorm:"not null;default:false" json:"isGroup"`
+	Title       string `gorm:"type:varchar(100)" json:"title"`
+	CreatedByID uint   `gorm:"not null" json:"createdById"`
+	// DirectKey identifies a one-to-one conversation by its two members, so
+	// that starting it again finds the existing one. Groups leave it nil.
+	DirectKey     *string              `gorm:"type:varchar(32);uniqueIndex" json:"-"`
+	LastMessageAt time.Time            `gorm:"not null" json:"lastMessageAt"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	UpdatedAt     time.Time            `json:"updatedAt"`
+	Members       []ConversationMember `gorm:"foreignKey:ConversationID" json:"members"`
+
+	// LastMessage and UnreadCount are filled in when listing a member's
+	// conversations
+	LastMessage *Message `gorm:"-" json:"lastMessage,omitempty"`
+	UnreadCount int      `gorm:"-" json:"unreadCount"`
+}
+
+// ConversationMember links a user to a conversation and records how far
+// they have read it.
+type ConversationMember struct {
+	ConversationID    uint       `gorm:"primaryKey;autoIncrement:false" json:"conversationId"`
+	UserID            uint       `gorm:"primaryKey;autoIncrement:false;index" json:"userId"`
+	User              User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"user"`
+	LastReadMessageID uint       `gorm:"not null;default:0" json:"lastReadMessageId"`
+	LastReadAt        *time.Time `json:"lastReadAt"`
+	JoinedAt          time.Time  `gorm:"not null" json:"joinedAt"`
+}
+
+type Message struct {
+	ID             uint           `gorm:"primaryKey;type:serial" json:"id"`
+	ConversationID uint           `gorm:"not null" json:"conversationId"`
+	SenderID       uint           `gorm:"not null" json:"senderId"`
+	Sender         User           `gorm:"foreignKey:SenderID;references:ID;constraint:OnDelete:CASCADE" json:"sender"`
+	Content        string         `gorm:"type:text;not null" json:"content"`
+	EditedAt       *time.Time     `json:"editedAt"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
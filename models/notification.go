@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+type NotificationType string
+
+const (
+	// LikeNotification tells an author someone liked their post or comment.
+	LikeNotification NotificationType = "like"
+	// CommentNotification tells an author someone commented on their post.
+	CommentNotification NotificationType = "comment"
+	// ReplyNotification tells an author someone replied to their comment.
+	ReplyNotification NotificationType = "reply"
+	// FollowNotification tells a user someone followed them.
+	FollowNotification NotificationType = "follow"
+	// MentionNotification tells a user they were tagged in a post or comment.
+	MentionNotification NotificationType = "mention"
+	// FollowRequestNotification tells a private user someone asked to follow
+	// them.
+	FollowRequestNotification NotificationType = "follow_request"
+	// FollowAcceptedNotification tells a user their follow request was
+	// approved.
+	FollowAcceptedNotification NotificationType = "follow_accepted"
+	// ReportActionedNotification tells a reporter moderators acted on their
+	// report.
+	ReportActionedNotification NotificationType = "report_actioned"
+	// ReportDismissedNotification tells a reporter moderators found nothing
+	// to act on in their report.
+	ReportDismissedNotification NotificationType = "report_dismissed"
+	// WarningNotification tells a user a moderator warned them about their
+	// post, comment or account.
+	WarningNotification NotificationType = "warning"
+)
+
+// NotificationTarget names what a notification is about.
+type NotificationTarget string
+
+const (
+	PostTarget    NotificationTarget = "post"
+	CommentTarget NotificationTarget = "comment"
+	UserTarget    NotificationTarget = "user"
+	ReportTarget  NotificationTarget = "report"
+)
+
+// Notification tells UserID that one or more actors did the same thing to the
+// same target. While it is unread, further actors join it instead of creating
+// a new notification ("Alice and 12 others liked your post"), so GroupKey is
+// unique among a user's unread notifications. UpdatedAt moves with the latest
+// actor and orders the list.
+type Notification struct {
+	ID          uint               `gorm:"primaryKey;type:serial" json:"id"`
+	UserID      uint               `gorm:"not null" json:"userId"`
+	User        User               `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Type        NotificationType   `gorm:"type:varchar(16);not null" json:"type"`
+	TargetType  NotificationTarget `gorm:"type:varchar(16);not null" json:"targetType"`
+	TargetID    uint               `gorm:"not null" json:"targetId"`
+	GroupKey    string             `gorm:"type:varchar(64);not null" json:"-"`
+	ActorCount  int                `gorm:"not null;default:0" json:"actorCount"`
+	LastActorID uint               `gorm:"not null" json:"-"`
+	// Actors holds the most recent actors, newest first, filled in by the
+	// store when listing.
+	Actors    []User     `gorm:"-" json:"actors"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// NotificationActor records one actor of a grouped notification.
+type NotificationActor struct {
+	NotificationID uint      `gorm:"primaryKey;autoIncrement:false" json:"notificationId"`
+	UserID         uint      `gorm:"primaryKey;autoIncrement:false" json:"userId"`
+	CreatedAt      time.Time `gorm:"not null" json:"createdAt"`
+}
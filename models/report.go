@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// ReportTargetType names what a report, or a moderation action, is about.
+type ReportTargetType string
+
+const (
+	ReportedPost    ReportTargetType = "post"
+	ReportedComment ReportTargetType = "comment"
+	ReportedUser    ReportTargetType = "user"
+)
+
+// ReportReason is the reason code a reporter picks.
+type ReportReason string
+
+const (
+	SpamReason           ReportReason = "spam"
+	HarassmentReason     ReportReason = "harassment"
+	HateReason           ReportReason = "hate"
+	ViolenceReason       ReportReason = "violence"
+	NudityReason         ReportReason = "nudity"
+	MisinformationReason ReportReason = "misinformation"
+	SelfHarmReason       ReportReason = "self_harm"
+	OtherReason          ReportReason = "other"
+)
+
+// ReportStatus is where a report stands in the moderation queue. Open and
+// triaged reports are unresolved; actioned and dismissed ones are resolved.
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportTriaged   ReportStatus = "triaged"
+	ReportActioned  ReportStatus = "actioned"
+	ReportDismissed ReportStatus = "dismissed"
+)
+
+// Resolved reports whether moderators are done with reports in the status.
+func (s ReportStatus) Resolved() bool {
+	return s == ReportActioned || s == ReportDismissed
+}
+
+// Report asks moderators to look at a post, comment or user. A reporter has
+// at most one unresolved report per target.
+type Report struct {
+	ID         uint             `gorm:"primaryKey;type:serial" json:"id"`
+	ReporterID uint             `gorm:"not null;index" json:"reporterId"`
+	Reporter   User             `gorm:"foreignKey:ReporterID;references:ID;constraint:OnDelete:CASCADE" json:"reporter"`
+	TargetType ReportTargetType `gorm:"type:varchar(16);not null" json:"targetType"`
+	TargetID   uint             `gorm:"not null" json:"targetId"`
+	Reason     ReportReason     `gorm:"type:varchar(32);not null" json:"reason"`
+	Details    string           `gorm:"type:varchar(1000)" json:"details"`
+	Status     ReportStatus     `gorm:"type:varchar(16);not null;default:'open'" json:"status"`
+	// HandledByID is the moderator who last moved the report along.
+	HandledByID *uint      `json:"handledById"`
+	ResolvedAt  *time.Time `json:"resolvedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// ModerationActionType is what a moderator did.
+type ModerationActionType string
+
+const (
+	TriageAction    ModerationActionType = "triage"
+	DismissAction   ModerationActionType = "dismiss"
+	HideAction      ModerationActionType = "hide"
+	UnhideAction    ModerationActionType = "unhide"
+	RemoveAction    ModerationActionType = "remove"
+	WarnAction      ModerationActionType = "warn"
+	SuspendAction   ModerationActionType = "suspend"
+	UnsuspendAction ModerationActionType = "unsuspend"
+)
+
+// ModerationAction is one entry of the audit trail: what a moderator did to
+// which target, and for which report if any. Entries are never changed or
+// removed.
+type ModerationAction struct {
+	ID          uint                 `gorm:"primaryKey;type:serial" json:"id"`
+	ModeratorID uint                 `gorm:"not null;index" json:"moderatorId"`
+	Moderator   User                 `gorm:"foreignKey:ModeratorID;references:ID" json:"moderator"`
+	Action      ModerationActionType `gorm:"type:varchar(16);not null" json:"action"`
+	TargetType  ReportTargetType     `gorm:"type:varchar(16);not null" json:"targetType"`
+	TargetID    uint                 `gorm:"not null" json:"targetId"`
+	ReportID    *uint                `gorm:"index" json:"reportId"`
+	Note        string               `gorm:"type:varchar(1000)" json:"note"`
+	// Until is when a suspension ends.
+	Until     *time.Time `json:"until,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Session is a server-side login. Access tokens carry its ID so they can be
+// revoked, and the refresh token that renews them is stored only as a hash.
+type Session struct {
+	ID                uint       `gorm:"primaryKey;type:serial" json:"id"`
+	UserID            uint       `gorm:"not null;index" json:"userId"`
+	User              User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	RefreshTokenHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	PreviousTokenHash string     `gorm:"type:varchar(64)" json:"-"`
+	UserAgent         string     `gorm:"type:varchar(255)" json:"userAgent"`
+	IPAddress         string     `gorm:"type:varchar(64)" json:"ipAddress"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expiresAt"`
+	LastUsedAt        time.Time  `json:"lastUsedAt"`
+	RevokedAt         *time.Time `gorm:"index" json:"revokedAt"`
+	CreatedAt         time.Time  `json:"createdAt"`
+}
+
+// Active reports whether the session can still authenticate requests.
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
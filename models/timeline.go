@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TimelineEntry materializes a post in a follower's home timeline. CreatedAt
+// is the post's creation time so timelines page in the same order as posts.
+type TimelineEntry struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"userId"`
+	PostID    uint      `gorm:"primaryKey;autoIncrement:false;index" json:"postId"`
+	AuthorID  uint      `gorm:"not null" json:"authorId"`
+	CreatedAt time.Time `gorm:"not null" json:"createdAt"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TrendingHashtag is one row of a trending snapshot, recomputed periodically
+// for each window ("1h", "24h", "7d"). Uses counts post and comment uses in
+// the window and PreviousUses those in the window before it.
+type TrendingHashtag struct {
+	Window       string    `gorm:"primaryKey;type:varchar(8)" json:"window"`
+	HashtagID    uint      `gorm:"primaryKey;autoIncrement:false" json:"hashtagId"`
+	Hashtag      Hashtag   `gorm:"foreignKey:HashtagID;references:ID;constraint:OnDelete:CASCADE" json:"hashtag"`
+	Rank         int       `gorm:"not null" json:"rank"`
+	Score        float64   `gorm:"not null" json:"score"`
+	Uses         int       `gorm:"not null" json:"uses"`
+	PreviousUses int       `gorm:"not null" json:"previousUses"`
+	ComputedAt   time.Time `gorm:"not null" json:"computedAt"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// ViewerState describes a post or comment from the point of view of the
+// authenticated user reading it. It is left nil for anonymous readers.
+type ViewerState struct {
+	LikedByMe bool `json:"likedByMe"`
+	// FollowingAuthor and AuthorFollowsMe describe the follow relationship
+	// with the author; both are false on the viewer's own content.
+	FollowingAuthor bool `json:"followingAuthor"`
+	AuthorFollowsMe bool `json:"authorFollowsMe"`
+	TaggedMe        bool `json:"taggedMe"`
+}
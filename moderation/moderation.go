@@ -0,0 +1,298 @@
+// Package moderation works the report queue: moderators triage and dismiss
+// reports, act on the reported posts, comments and users, and every step is
+// written to the audit trail. Reporters hear back once their report is
+// resolved.
+package moderation
+
+import (
+	"errors"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
+	"sinkedin/store"
+	"sinkedin/timeline"
+)
+
+var (
+	// ErrInvalidAction is returned for an action that does not apply to its
+	// target, such as hiding a user.
+	ErrInvalidAction = errors.New("action does not apply to the target")
+	// ErrResolved is returned when moving a report that was already
+	// actioned or dismissed.
+	ErrResolved = errors.New("report is already resolved")
+)
+
+type Config struct {
+	// SuspensionDuration is how long a suspension lasts when the moderator
+	// does not say.
+	SuspensionDuration time.Duration
+	// Events is told to recheck who may go on following a post's live
+	// events once a moderator hides or removes it, or hides one of its
+	// comments. Nil disables rechecking.
+	Events realtime.Publisher
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.SuspensionDuration <= 0 {
+		cfg.SuspensionDuration = 7 * 24 * time.Hour
+	}
+	return cfg
+}
+
+type Service struct {
+	moderation    store.ModerationStore
+	timelines     *timeline.Service
+	notifications *notifications.Service
+	cfg           Config
+}
+
+func New(s *store.Store, timelines *timeline.Service, notifications *notifications.Service, cfg Config) *Service {
+	return &Service{
+		moderation:    s.Moderation,
+		timelines:     timelines,
+		notifications: notifications,
+		cfg:           cfg.withDefaults(),
+	}
+}
+
+// unresolved are the statuses a report can still be moved out of.
+var unresolved = []models.ReportStatus{models.ReportOpen, models.ReportTriaged}
+
+// move moves an unresolved report to status and records why, both or
+// neither.
+func (svc *Service) move(moderatorID, reportID uint, status models.ReportStatus, action models.ModerationActionType, note string) (*models.Report, error) {
+	from := unresolved
+	if status == models.ReportTriaged {
+		from = []models.ReportStatus{models.ReportOpen}
+	}
+
+	var report *models.Report
+	err := svc.moderation.Transaction(func(tx *store.Store) error {
+		var err error
+		report, err = tx.Moderation.MoveReport(reportID, from, status, moderatorID)
+		if errors.Is(err, store.ErrConflict) {
+			return ErrResolved
+		}
+		if err != nil {
+			return err
+		}
+
+		entry := models.ModerationAction{
+			ModeratorID: moderatorID,
+			Action:      action,
+			TargetType:  report.TargetType,
+			TargetID:    report.TargetID,
+			ReportID:    &report.ID,
+			Note:        note,
+		}
+		return tx.Moderation.Record(&entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Triage marks an open report as being looked at.
+func (svc *Service) Triage(moderatorID, reportID uint) (*models.Report, error) {
+	return svc.move(moderatorID, reportID, models.ReportTriaged, models.TriageAction, "")
+}
+
+// Dismiss closes an unresolved report without acting on its target and tells
+// the reporter.
+func (svc *Service) Dismiss(moderatorID, reportID uint, note string) (*models.Report, error) {
+	report, err := svc.move(moderatorID, reportID, models.ReportDismissed, models.DismissAction, note)
+	if err != nil {
+		return nil, err
+	}
+	svc.notifications.ReportResolved(*report, moderatorID)
+	return report, nil
+}
+
+// Request describes a moderator action. When ReportID is set the target is
+// taken from the report, which must still be unresolved.
+type Request struct {
+	Action     models.ModerationActionType
+	TargetType models.ReportTargetType
+	TargetID   uint
+	ReportID   *uint
+	Note       string
+	// Duration is how long a suspension lasts; zero uses the configured
+	// default.
+	Duration time.Duration
+}
+
+// resolving reports whether the action settles the reports on its target.
+func resolving(action models.ModerationActionType) bool {
+	switch action {
+	case models.HideAction, models.RemoveAction, models.WarnAction, models.SuspendAction:
+		return true
+	}
+	return false
+}
+
+// Act applies the action to its target and records it, both or neither.
+// Actions that deal with the target resolve every unresolved report on it,
+// and each reporter is told once the action is committed.
+func (svc *Service) Act(moderatorID uint, req Request) (*models.ModerationAction, error) {
+	var (
+		entry    models.ModerationAction
+		reports  []models.Report
+		followUp func()
+	)
+	err := svc.moderation.Transaction(func(tx *store.Store) error {
+		if req.ReportID != nil {
+			report, err := tx.Moderation.GetReport(*req.ReportID)
+			if err != nil {
+				return err
+			}
+			if report.Status.Resolved() {
+				return ErrResolved
+			}
+			req.TargetType, req.TargetID = report.TargetType, report.TargetID
+		}
+
+		entry = models.ModerationAction{
+			ModeratorID: moderatorID,
+			Action:      req.Action,
+			TargetType:  req.TargetType,
+			TargetID:    req.TargetID,
+			ReportID:    req.ReportID,
+			Note:        req.Note,
+		}
+		var err error
+		if followUp, err = svc.apply(tx, moderatorID, req, &entry); err != nil {
+			return err
+		}
+		if err := tx.Moderation.Record(&entry); err != nil {
+			return err
+		}
+
+		if resolving(req.Action) {
+			reports, err = tx.Moderation.ResolveTarget(req.TargetType, req.TargetID, moderatorID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if followUp != nil {
+		followUp()
+	}
+	for _, report := range reports {
+		svc.notifications.ReportResolved(report, moderatorID)
+	}
+	return &entry, nil
+}
+
+// apply carries out the action on its target within tx, filling in what the
+// audit entry needs to know about it. Effects outside the store, such as
+// notifying the author, are returned to be run once tx is committed.
+func (svc *Service) apply(tx *store.Store, moderatorID uint, req Request, entry *models.ModerationAction) (func(), error) {
+	var (
+		authorID uint
+		post     *models.Post
+		comment  *models.Comment
+		err      error
+	)
+	switch req.TargetType {
+	case models.ReportedPost:
+		if post, err = tx.Posts.GetByID(req.TargetID); err != nil {
+			return nil, err
+		}
+		authorID = post.UserID
+	case models.ReportedComment:
+		if comment, err = tx.Comments.GetByID(req.TargetID); err != nil {
+			return nil, err
+		}
+		authorID = comment.UserID
+	case models.ReportedUser:
+		if _, err = tx.Users.GetByID(req.TargetID); err != nil {
+			return nil, err
+		}
+		authorID = req.TargetID
+	default:
+		return nil, ErrInvalidAction
+	}
+
+	switch req.Action {
+	case models.HideAction, models.UnhideAction:
+		if req.TargetType == models.ReportedUser {
+			return nil, ErrInvalidAction
+		}
+		if err := tx.Moderation.SetHidden(req.TargetType, req.TargetID, req.Action == models.HideAction); err != nil {
+			return nil, err
+		}
+		if req.Action == models.UnhideAction {
+			return nil, nil
+		}
+		if post != nil {
+			return func() { svc.recheck(post.ID) }, nil
+		}
+		if comment.PostID != nil {
+			return func() { svc.recheck(*comment.PostID) }, nil
+		}
+		return nil, nil
+	case models.RemoveAction:
+		switch {
+		case post != nil:
+			if err := tx.Posts.Delete(post.ID); err != nil {
+				return nil, err
+			}
+			return func() {
+				svc.timelines.PostDeleted(post.ID)
+				svc.recheck(post.ID)
+			}, nil
+		case comment != nil:
+			return nil, tx.Comments.Delete(comment)
+		}
+		return nil, ErrInvalidAction
+	case models.WarnAction:
+		return func() {
+			svc.notifications.Warned(moderatorID, authorID, models.NotificationTarget(req.TargetType), req.TargetID)
+		}, nil
+	case models.SuspendAction:
+		duration := req.Duration
+		if duration <= 0 {
+			duration = svc.cfg.SuspensionDuration
+		}
+		until := time.Now().Add(duration)
+		if err := tx.Moderation.Suspend(authorID, &until); err != nil {
+			return nil, err
+		}
+		entry.Until = &until
+		return nil, tx.Sessions.RevokeAll(authorID)
+	case models.UnsuspendAction:
+		return nil, tx.Moderation.Suspend(authorID, nil)
+	}
+	return nil, ErrInvalidAction
+}
+
+// recheck has the live event streams drop the post's topic from everyone no
+// longer allowed to see it.
+func (svc *Service) recheck(postID uint) {
+	if svc.cfg.Events != nil {
+		svc.cfg.Events.Recheck(realtime.PostTopic(postID))
+	}
+}
+
+// Reports pages through the queue, newest first, optionally by status.
+func (svc *Service) Reports(status models.ReportStatus, page store.PageRequest) (store.Page[models.Report], error) {
+	return svc.moderation.Reports(store.ReportFilter{Status: status}, page)
+}
+
+// Report returns a report with its audit trail, newest entry first.
+func (svc *Service) Report(id uint) (*models.Report, []models.ModerationAction, error) {
+	report, err := svc.moderation.GetReport(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	actions, err := svc.moderation.Actions(store.ActionFilter{ReportID: id}, store.PageRequest{Limit: store.MaxPageLimit})
+	if err != nil {
+		return nil, nil, err
+	}
+	return report, actions.Items, nil
+}
@@ -0,0 +1,77 @@
+package moderation
+
+import (
+	"errors"
+	"testing"
+
+	"sinkedin/models"
+	"sinkedin/notifications"
+	"sinkedin/store"
+	"sinkedin/store/memstore"
+	"sinkedin/timeline"
+)
+
+var errRecord = errors.New("audit trail unavailable")
+
+// failingRecords is a ModerationStore whose audit trail rejects every entry,
+// within transactions too.
+type failingRecords struct {
+	store.ModerationStore
+}
+
+func (s failingRecords) Record(*models.ModerationAction) error { return errRecord }
+
+func (s failingRecords) Transaction(fn func(tx *store.Store) error) error {
+	return s.ModerationStore.Transaction(func(tx *store.Store) error {
+		wrapped := *tx
+		wrapped.Moderation = failingRecords{tx.Moderation}
+		return fn(&wrapped)
+	})
+}
+
+func TestActRollsBackWithoutAuditEntry(t *testing.T) {
+	s := memstore.New()
+	var ids []uint
+	for _, name := range []string{"admin", "alice", "bob"} {
+		user := models.User{Name: name, Username: name, Email: name + "@example.com", Password: "x"}
+		if err := s.Users.Create(&user); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, user.ID)
+	}
+	adminID, aliceID, bobID := ids[0], ids[1], ids[2]
+
+	post := models.Post{UserID: aliceID, Content: "buy now"}
+	if err := s.Posts.Create(&post, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	report := models.Report{ReporterID: bobID, TargetType: models.ReportedPost, TargetID: post.ID, Reason: models.SpamReason}
+	if err := s.Moderation.CreateReport(&report); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Moderation = failingRecords{s.Moderation}
+	svc := New(s, timeline.New(s, timeline.Config{}), notifications.New(s, notifications.Config{}), Config{})
+
+	for _, action := range []models.ModerationActionType{models.RemoveAction, models.SuspendAction} {
+		_, err := svc.Act(adminID, Request{Action: action, ReportID: &report.ID})
+		if !errors.Is(err, errRecord) {
+			t.Fatalf("Act(%s) = %v, want errRecord", action, err)
+		}
+	}
+	if _, err := s.Posts.GetByID(post.ID); err != nil {
+		t.Errorf("post removed without an audit entry: %v", err)
+	}
+	if author, err := s.Users.GetByID(aliceID); err != nil || author.SuspendedUntil != nil {
+		t.Errorf("author suspended without an audit entry: %+v %v", author, err)
+	}
+	if got, err := s.Moderation.GetReport(report.ID); err != nil || got.Status != models.ReportOpen {
+		t.Errorf("report = %+v %v, want it still open", got, err)
+	}
+	if _, err := svc.Triage(adminID, report.ID); !errors.Is(err, errRecord) {
+		t.Errorf("Triage() = %v, want errRecord", err)
+	}
+	if got, _ := s.Moderation.GetReport(report.ID); got.Status != models.ReportOpen {
+		t.Errorf("report triaged without an audit entry: %s", got.Status)
+	}
+}
@@ -0,0 +1,334 @@
+// Package notifications tells users when others like, comment on or reply to
+// their content, follow them or tag them. Actors doing the same thing to the
+// same target are grouped into one notification while it is unread.
+package notifications
+
+import (
+	"fmt"
+	"log"
+
+	"sinkedin/models"
+	"sinkedin/realtime"
+	"sinkedin/store"
+)
+
+type Config struct {
+	// Actors is the number of most recent actors listed with each
+	// notification.
+	Actors int
+	// Events receives new notifications and unread count changes for the
+	// recipient's live connections. Nil disables publishing.
+	Events realtime.Publisher
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Actors <= 0 {
+		cfg.Actors = 3
+	}
+	return cfg
+}
+
+type Service struct {
+	notifications store.NotificationStore
+	users         store.UserStore
+	posts         store.PostStore
+	comments      store.CommentStore
+	cfg           Config
+}
+
+func New(s *store.Store, cfg Config) *Service {
+	return &Service{
+		notifications: s.Notifications,
+		users:         s.Users,
+		posts:         s.Posts,
+		comments:      s.Comments,
+		cfg:           cfg.withDefaults(),
+	}
+}
+
+// GroupKey identifies the notifications that actors are grouped into.
+func GroupKey(kind models.NotificationType, target models.NotificationTarget, targetID uint) string {
+	return fmt.Sprintf("%s:%s:%d", kind, target, targetID)
+}
+
+// Notifying never fails the action that triggered it, so errors are logged.
+func logFailure(what string, err error) {
+	if err != nil {
+		log.Printf("notifications: %s failed: %v", what, err)
+	}
+}
+
+// notify adds actorID to the recipient's notification of the given kind,
+// unless they are acting on their own content.
+func (svc *Service) notify(recipientID, actorID uint, kind models.NotificationType, target models.NotificationTarget, targetID uint) {
+	if recipientID == 0 || recipientID == actorID {
+		return
+	}
+	n, added, err := svc.notifications.Add(models.Notification{
+		UserID:     recipientID,
+		Type:       kind,
+		TargetType: target,
+		TargetID:   targetID,
+		GroupKey:   GroupKey(kind, target, targetID),
+	}, actorID)
+	if err != nil {
+		logFailure(string(kind), err)
+		return
+	}
+	if added {
+		svc.publishAdded(*n, actorID)
+	}
+}
+
+// pushed is the payload of a notification event.
+type pushed struct {
+	models.Notification
+	Summary     string `json:"summary"`
+	UnreadCount int    `json:"unreadCount"`
+}
+
+// publishAdded pushes the notification, with its newest actor, to the
+// recipient's live connections.
+func (svc *Service) publishAdded(n models.Notification, actorID uint) {
+	if svc.cfg.Events == nil {
+		return
+	}
+	actor, err := svc.users.GetByID(actorID)
+	if err != nil {
+		logFailure("publish", err)
+		return
+	}
+	n.Actors = []models.User{*actor}
+	if fromModerators(n.Type) {
+		n.Actors = []models.User{}
+	}
+	count, err := svc.notifications.UnreadCount(n.UserID)
+	if err != nil {
+		logFailure("publish", err)
+		return
+	}
+	svc.cfg.Events.Publish(realtime.UserTopic(n.UserID), realtime.NotificationEvent, pushed{
+		Notification: n,
+		Summary:      Summary(n),
+		UnreadCount:  count,
+	})
+}
+
+// publishRead pushes the user's unread count after some were marked read.
+func (svc *Service) publishRead(userID uint, changed int) {
+	if svc.cfg.Events == nil || changed == 0 {
+		return
+	}
+	count, err := svc.notifications.UnreadCount(userID)
+	if err != nil {
+		logFailure("publish", err)
+		return
+	}
+	svc.cfg.Events.Publish(realtime.UserTopic(userID), realtime.NotificationsReadEvent, map[string]int{"unreadCount": count})
+}
+
+func (svc *Service) retract(recipientID, actorID uint, kind models.NotificationType, target models.NotificationTarget, targetID uint) {
+	if recipientID == 0 || recipientID == actorID {
+		return
+	}
+	err := svc.notifications.Retract(recipientID, GroupKey(kind, target, targetID), actorID)
+	logFailure("retract "+string(kind), err)
+}
+
+// owner returns the author of the liked post or comment.
+func (svc *Service) owner(likeType models.LikeType, parentID uint) (uint, models.NotificationTarget, error) {
+	if likeType == models.PostLike {
+		post, err := svc.posts.GetByID(parentID)
+		if err != nil {
+			return 0, "", err
+		}
+		return post.UserID, models.PostTarget, nil
+	}
+	comment, err := svc.comments.GetByID(parentID)
+	if err != nil {
+		return 0, "", err
+	}
+	return comment.UserID, models.CommentTarget, nil
+}
+
+// Liked notifies the author of the liked post or comment.
+func (svc *Service) Liked(actorID, parentID uint, likeType models.LikeType) {
+	ownerID, target, err := svc.owner(likeType, parentID)
+	if err != nil {
+		logFailure("like", err)
+		return
+	}
+	svc.notify(ownerID, actorID, models.LikeNotification, target, parentID)
+}
+
+// Unliked withdraws the like from its notification if that is still unread.
+func (svc *Service) Unliked(actorID, parentID uint, likeType models.LikeType) {
+	ownerID, target, err := svc.owner(likeType, parentID)
+	if err != nil {
+		logFailure("retract like", err)
+		return
+	}
+	svc.retract(ownerID, actorID, models.LikeNotification, target, parentID)
+}
+
+// Commented notifies the author of the post and, for a reply, the author of
+// the parent comment. Someone who wrote both only hears about the reply.
+func (svc *Service) Commented(comment models.Comment) {
+	var replyTo uint
+	if comment.ParentCommentID != nil {
+		parent, err := svc.comments.GetByID(*comment.ParentCommentID)
+		if err != nil {
+			logFailure("reply", err)
+		} else {
+			replyTo = parent.UserID
+			svc.notify(parent.UserID, comment.UserID, models.ReplyNotification, models.CommentTarget, parent.ID)
+		}
+	}
+
+	if comment.PostID == nil {
+		return
+	}
+	post, err := svc.posts.GetByID(*comment.PostID)
+	if err != nil {
+		logFailure("comment", err)
+		return
+	}
+	if post.UserID != replyTo {
+		svc.notify(post.UserID, comment.UserID, models.CommentNotification, models.PostTarget, post.ID)
+	}
+}
+
+// Followed notifies the followed user.
+func (svc *Service) Followed(followerID, followingID uint) {
+	svc.notify(followingID, followerID, models.FollowNotification, models.UserTarget, followingID)
+}
+
+// Unfollowed withdraws the follow from its notification if that is still
+// unread.
+func (svc *Service) Unfollowed(followerID, followingID uint) {
+	svc.retract(followingID, followerID, models.FollowNotification, models.UserTarget, followingID)
+}
+
+// FollowRequested notifies a private user of a request to follow them.
+func (svc *Service) FollowRequested(requesterID, targetID uint) {
+	svc.notify(targetID, requesterID, models.FollowRequestNotification, models.UserTarget, targetID)
+}
+
+// FollowRequestCancelled withdraws the request from its notification if that
+// is still unread.
+func (svc *Service) FollowRequestCancelled(requesterID, targetID uint) {
+	svc.retract(targetID, requesterID, models.FollowRequestNotification, models.UserTarget, targetID)
+}
+
+// FollowAccepted tells the requester their request to follow targetID was
+// approved.
+func (svc *Service) FollowAccepted(requesterID, targetID uint) {
+	svc.notify(requesterID, targetID, models.FollowAcceptedNotification, models.UserTarget, requesterID)
+}
+
+// ReportResolved tells the reporter what became of their report.
+func (svc *Service) ReportResolved(report models.Report, moderatorID uint) {
+	kind := models.ReportDismissedNotification
+	if report.Status == models.ReportActioned {
+		kind = models.ReportActionedNotification
+	}
+	svc.notify(report.ReporterID, moderatorID, kind, models.ReportTarget, report.ID)
+}
+
+// Warned tells the user a moderator warned them about the target.
+func (svc *Service) Warned(moderatorID, userID uint, target models.NotificationTarget, targetID uint) {
+	svc.notify(userID, moderatorID, models.WarningNotification, target, targetID)
+}
+
+// Tagged notifies users newly tagged by actorID in a post or comment.
+func (svc *Service) Tagged(actorID uint, target models.NotificationTarget, targetID uint, userIDs []uint) {
+	for _, userID := range userIDs {
+		svc.notify(userID, actorID, models.MentionNotification, target, targetID)
+	}
+}
+
+// List pages through the user's notifications by latest activity.
+func (svc *Service) List(userID uint, unreadOnly bool, page store.PageRequest) (store.Page[models.Notification], error) {
+	notifications, err := svc.notifications.List(userID, unreadOnly, svc.cfg.Actors, page)
+	for i := range notifications.Items {
+		if fromModerators(notifications.Items[i].Type) {
+			notifications.Items[i].Actors = []models.User{}
+		}
+	}
+	return notifications, err
+}
+
+// fromModerators reports whether notifications of the kind come from the
+// moderators as a whole, whose actors are therefore never shown.
+func fromModerators(kind models.NotificationType) bool {
+	switch kind {
+	case models.ReportActionedNotification, models.ReportDismissedNotification, models.WarningNotification:
+		return true
+	}
+	return false
+}
+
+func (svc *Service) MarkRead(userID uint, ids []uint) (int, error) {
+	changed, err := svc.notifications.MarkRead(userID, ids)
+	if err == nil {
+		svc.publishRead(userID, changed)
+	}
+	return changed, err
+}
+
+func (svc *Service) MarkAllRead(userID uint) (int, error) {
+	changed, err := svc.notifications.MarkAllRead(userID)
+	if err == nil {
+		svc.publishRead(userID, changed)
+	}
+	return changed, err
+}
+
+func (svc *Service) UnreadCount(userID uint) (int, error) {
+	return svc.notifications.UnreadCount(userID)
+}
+
+// Summary describes the notification in a sentence, naming its latest actor
+// and counting the rest, e.g. "alice and 12 others liked your post".
+func Summary(n models.Notification) string {
+	who := "Someone"
+	if len(n.Actors) > 0 {
+		who = n.Actors[0].Username
+	}
+	switch others := n.ActorCount - 1; {
+	case others == 1 && len(n.Actors) > 1:
+		who += " and " + n.Actors[1].Username
+	case others == 1:
+		who += " and 1 other"
+	case others > 1:
+		who += fmt.Sprintf(" and %d others", others)
+	}
+
+	switch n.Type {
+	case models.LikeNotification:
+		return fmt.Sprintf("%s liked your %s", who, n.TargetType)
+	case models.CommentNotification:
+		return fmt.Sprintf("%s commented on your %s", who, n.TargetType)
+	case models.ReplyNotification:
+		return fmt.Sprintf("%s replied to your %s", who, n.TargetType)
+	case models.FollowNotification:
+		return who + " started following you"
+	case models.MentionNotification:
+		return fmt.Sprintf("%s mentioned you in a %s", who, n.TargetType)
+	case models.FollowRequestNotification:
+		return who + " requested to follow you"
+	case models.FollowAcceptedNotification:
+		return who + " accepted your follow request"
+	// Moderation notices speak for the moderators rather than naming them
+	case models.ReportActionedNotification:
+		return "Moderators reviewed your report and took action"
+	case models.ReportDismissedNotification:
+		return "Moderators reviewed your report and found no violation"
+	case models.WarningNotification:
+		if n.TargetType == models.UserTarget {
+			return "Moderators warned you about your account"
+		}
+		return fmt.Sprintf("Moderators warned you about your %s", n.TargetType)
+	}
+	return who + " interacted with you"
+}
@@ -0,0 +1,94 @@
+package notifications
+
+import (
+	"fmt"
+	"testing"
+
+	"sinkedin/models"
+	"sinkedin/store"
+	"sinkedin/store/memstore"
+)
+
+func TestSummary(t *testing.T) {
+	alice := models.User{Username: "alice"}
+	bob := models.User{Username: "bob"}
+	tests := []struct {
+		n    models.Notification
+		want string
+	}{
+		{models.Notification{Type: models.LikeNotification, TargetType: models.PostTarget, ActorCount: 1, Actors: []models.User{alice}}, "alice liked your post"},
+		{models.Notification{Type: models.LikeNotification, TargetType: models.CommentTarget, ActorCount: 2, Actors: []models.User{alice, bob}}, "alice and bob liked your comment"},
+		{models.Notification{Type: models.CommentNotification, TargetType: models.PostTarget, ActorCount: 13, Actors: []models.User{alice, bob}}, "alice and 12 others commented on your post"},
+		{models.Notification{Type: models.FollowNotification, TargetType: models.UserTarget, ActorCount: 2, Actors: []models.User{bob}}, "bob and 1 other started following you"},
+		{models.Notification{Type: models.MentionNotification, TargetType: models.CommentTarget, ActorCount: 1}, "Someone mentioned you in a comment"},
+		{models.Notification{Type: models.FollowRequestNotification, TargetType: models.UserTarget, ActorCount: 2, Actors: []models.User{alice, bob}}, "alice and bob requested to follow you"},
+		{models.Notification{Type: models.FollowAcceptedNotification, TargetType: models.UserTarget, ActorCount: 1, Actors: []models.User{bob}}, "bob accepted your follow request"},
+		{models.Notification{Type: models.ReportActionedNotification, TargetType: models.ReportTarget, ActorCount: 2}, "Moderators reviewed your report and took action"},
+		{models.Notification{Type: models.WarningNotification, TargetType: models.CommentTarget, ActorCount: 1}, "Moderators warned you about your comment"},
+		{models.Notification{Type: models.WarningNotification, TargetType: models.UserTarget, ActorCount: 1}, "Moderators warned you about your account"},
+	}
+	for _, tt := range tests {
+		if got := Summary(tt.n); got != tt.want {
+			t.Errorf("Summary() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestGroupingAndRetraction(t *testing.T) {
+	s := memstore.New()
+	var users []models.User
+	for i := 0; i < 3; i++ {
+		user := models.User{Name: fmt.Sprint("user", i), Username: fmt.Sprint("user", i), Email: fmt.Sprint("user", i, "@example.com"), Password: "x"}
+		if err := s.Users.Create(&user); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+	}
+	author, fan1, fan2 := users[0], users[1], users[2]
+	post := models.Post{UserID: author.ID, Content: "hello"}
+	if err := s.Posts.Create(&post, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := New(s, Config{})
+	list := func() []models.Notification {
+		t.Helper()
+		page, err := svc.List(author.ID, false, store.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return page.Items
+	}
+
+	svc.Liked(author.ID, post.ID, models.PostLike)
+	svc.Liked(fan1.ID, post.ID, models.PostLike)
+	svc.Liked(fan2.ID, post.ID, models.PostLike)
+	got := list()
+	if len(got) != 1 || got[0].ActorCount != 2 || len(got[0].Actors) != 2 || got[0].Actors[0].ID != fan2.ID {
+		t.Fatalf("grouped likes = %+v", got)
+	}
+
+	svc.Unliked(fan2.ID, post.ID, models.PostLike)
+	if got := list(); len(got) != 1 || got[0].ActorCount != 1 || got[0].Actors[0].ID != fan1.ID {
+		t.Fatalf("after unlike = %+v", got)
+	}
+	svc.Unliked(fan1.ID, post.ID, models.PostLike)
+	if got := list(); len(got) != 0 {
+		t.Fatalf("after every unlike = %+v", got)
+	}
+
+	// Once read, a notification stops collecting actors
+	svc.Liked(fan1.ID, post.ID, models.PostLike)
+	if _, err := svc.MarkAllRead(author.ID); err != nil {
+		t.Fatal(err)
+	}
+	svc.Liked(fan2.ID, post.ID, models.PostLike)
+	svc.Unliked(fan1.ID, post.ID, models.PostLike)
+	got = list()
+	if len(got) != 2 || got[0].ReadAt != nil || got[1].ReadAt == nil || got[1].ActorCount != 1 {
+		t.Fatalf("after reading = %+v", got)
+	}
+	if count, err := svc.UnreadCount(author.ID); err != nil || count != 1 {
+		t.Fatalf("UnreadCount() = %d, %v", count, err)
+	}
+}
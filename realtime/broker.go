@@ -0,0 +1,43 @@
+package realtime
+
+import "errors"
+
+// ErrPayloadTooLarge is returned by brokers that cannot carry an event of
+// its size. The hub still delivers such events to its own subscribers.
+var ErrPayloadTooLarge = errors.New("event payload too large for broker")
+
+// Broker carries events between the instances sharing it.
+type Broker interface {
+	// Publish hands the event to every instance, including this one.
+	Publish(e Event) error
+	// Listen starts calling deliver with every event published through the
+	// broker, until Close. deliver must not block.
+	Listen(deliver func(Event)) error
+	Close() error
+}
+
+// LocalBroker keeps events within the process, for single-instance
+// deployments and tests.
+type LocalBroker struct {
+	deliver func(Event)
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{}
+}
+
+func (b *LocalBroker) Publish(e Event) error {
+	if b.deliver != nil {
+		b.deliver(e)
+	}
+	return nil
+}
+
+func (b *LocalBroker) Listen(deliver func(Event)) error {
+	b.deliver = deliver
+	return nil
+}
+
+func (b *LocalBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types pushed to clients.
+const (
+	// NotificationEvent carries a notification that was added or joined by a
+	// new actor, with the recipient's unread count.
+	NotificationEvent = "notification"
+	// NotificationsReadEvent carries the unread count after notifications
+	// were marked read, so that the user's other clients can catch up.
+	NotificationsReadEvent = "notifications.read"
+	// CommentEvent carries a new comment on a post.
+	CommentEvent = "comment"
+	// LikeEvent carries the new like count of a post or comment.
+	LikeEvent = "like"
+	// FeedPostEvent carries a new post for the home feed. A post can reach a
+	// user through more than one topic, so clients should ignore posts they
+	// already have.
+	FeedPostEvent = "feed.post"
+	// MessageEvent carries a new direct message to each member of its
+	// conversation.
+	MessageEvent = "message"
+	// MessageUpdatedEvent carries an edited direct message.
+	MessageUpdatedEvent = "message.updated"
+	// MessageDeletedEvent carries the conversation and ID of a deleted
+	// direct message.
+	MessageDeletedEvent = "message.deleted"
+	// ConversationReadEvent carries a member's new read marker to the other
+	// members of the conversation.
+	ConversationReadEvent = "conversation.read"
+
+	// ReadyEvent opens every stream with its connection ID and topics.
+	ReadyEvent = "ready"
+	// SubscribedEvent answers a change of subscriptions with the topics now
+	// subscribed to.
+	SubscribedEvent = "subscribed"
+	// HeartbeatEvent is sent on idle connections.
+	HeartbeatEvent = "heartbeat"
+	// ErrorEvent reports why the server is closing the stream or rejected a
+	// request sent over it.
+	ErrorEvent = "error"
+
+	// dropEvent and ignoreEvent change the subscriptions of a user's
+	// connections on every instance, and recheckEvent those of a topic's
+	// subscribers. They travel on the topic but are never sent to clients.
+	dropEvent    = "subscriptions.drop"
+	ignoreEvent  = "subscriptions.ignore"
+	recheckEvent = "subscriptions.recheck"
+)
+
+// Event is one message published to a topic. ActorID is the user who caused
+// it, if any; subscribers ignoring them are not sent the event.
+type Event struct {
+	Topic   string          `json:"topic,omitempty"`
+	Type    string          `json:"type"`
+	ActorID uint            `json:"actorId,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	At      time.Time       `json:"at"`
+}
+
+// NewEvent encodes data as the payload of an event.
+func NewEvent(topic, eventType string, data interface{}) (Event, error) {
+	e := Event{Topic: topic, Type: eventType, At: time.Now()}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return Event{}, err
+		}
+		e.Data = raw
+	}
+	return e, nil
+}
+
+// Topic kinds. Clients may only subscribe to post topics themselves; the
+// others are subscribed to on their behalf when they connect.
+const (
+	// UserKind topics carry a user's private events.
+	UserKind = "user"
+	// PostKind topics carry activity on a post and its comments.
+	PostKind = "post"
+	// AuthorKind topics carry new posts by an author whose posts are not
+	// fanned out to each follower.
+	AuthorKind = "author"
+	// HashtagKind topics carry new posts using a hashtag.
+	HashtagKind = "hashtag"
+)
+
+func topic(kind string, id uint) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
+
+func UserTopic(userID uint) string       { return topic(UserKind, userID) }
+func PostTopic(postID uint) string       { return topic(PostKind, postID) }
+func AuthorTopic(authorID uint) string   { return topic(AuthorKind, authorID) }
+func HashtagTopic(hashtagID uint) string { return topic(HashtagKind, hashtagID) }
+
+// ErrInvalidTopic is returned for topics not of the form "kind:id".
+var ErrInvalidTopic = errors.New("invalid topic")
+
+// ParseTopic splits a topic into its kind and ID.
+func ParseTopic(t string) (string, uint, error) {
+	kind, rawID, ok := strings.Cut(t, ":")
+	if !ok {
+		return "", 0, ErrInvalidTopic
+	}
+	id, err := strconv.ParseUint(rawID, 10, 32)
+	if err != nil || id == 0 {
+		return "", 0, ErrInvalidTopic
+	}
+	switch kind {
+	case UserKind, PostKind, AuthorKind, HashtagKind:
+		return kind, uint(id), nil
+	}
+	return "", 0, ErrInvalidTopic
+}
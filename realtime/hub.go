@@ -0,0 +1,525 @@
+// Package realtime pushes events such as new notifications, comments, like
+// counts and feed posts to connected clients. Events are published to topics
+// through a Broker, so that every instance sharing it delivers them to the
+// subscribers connected to it.
+package realtime
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSlowConsumer ends a subscription whose queue filled up.
+	ErrSlowConsumer = errors.New("connection fell too far behind")
+	// ErrTooManyTopics is returned when a subscription would exceed
+	// Config.MaxTopics.
+	ErrTooManyTopics = errors.New("too many topics")
+	// ErrHubClosed ends subscriptions when the hub shuts down.
+	ErrHubClosed = errors.New("server is shutting down")
+)
+
+// Publisher is what producers of events depend on; Hub implements it.
+type Publisher interface {
+	Publish(topic, eventType string, data interface{})
+	PublishFrom(actorID uint, topic, eventType string, data interface{})
+	Drop(userID uint, topics ...string)
+	Recheck(topic string)
+}
+
+type Config struct {
+	// Broker carries events between instances. Nil keeps them in process.
+	Broker Broker
+	// Buffer is the number of events queued for each connection. A
+	// connection that falls this far behind is disconnected rather than
+	// holding up delivery to everyone else.
+	Buffer int
+	// Heartbeat is how often idle connections are sent a heartbeat, keeping
+	// proxies from closing them and revealing clients that have gone.
+	Heartbeat time.Duration
+	// MaxTopics bounds the topics a client may subscribe one connection to,
+	// not counting the ones subscribed on its behalf.
+	MaxTopics int
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Broker == nil {
+		cfg.Broker = NewLocalBroker()
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = 64
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = 25 * time.Second
+	}
+	if cfg.MaxTopics <= 0 {
+		cfg.MaxTopics = 100
+	}
+	return cfg
+}
+
+type Hub struct {
+	cfg Config
+
+	mu            sync.RWMutex
+	closed        bool
+	subscriptions map[string]*Subscription
+	topics        map[string]map[*Subscription]struct{}
+	authorize     func(userID uint, topic string) (bool, error)
+}
+
+// New creates the hub and starts listening on its broker.
+func New(cfg Config) (*Hub, error) {
+	h := &Hub{
+		cfg:           cfg.withDefaults(),
+		subscriptions: map[string]*Subscription{},
+		topics:        map[string]map[*Subscription]struct{}{},
+	}
+	if err := h.cfg.Broker.Listen(h.deliver); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Heartbeat is the interval at which idle connections should be pinged.
+func (h *Hub) Heartbeat() time.Duration {
+	return h.cfg.Heartbeat
+}
+
+// Publish sends data as an event to the topic's subscribers on every
+// instance. Delivery is best effort, so failures are logged.
+func (h *Hub) Publish(topic, eventType string, data interface{}) {
+	h.PublishFrom(0, topic, eventType, data)
+}
+
+// PublishFrom is Publish for an event caused by the actor, which subscribers
+// ignoring them are not sent.
+func (h *Hub) PublishFrom(actorID uint, topic, eventType string, data interface{}) {
+	e, err := NewEvent(topic, eventType, data)
+	if err != nil {
+		log.Printf("realtime: encoding %s event failed: %v", eventType, err)
+		return
+	}
+	e.ActorID = actorID
+
+	err = h.cfg.Broker.Publish(e)
+	if errors.Is(err, ErrPayloadTooLarge) {
+		// Other instances miss out, but local subscribers need not
+		h.deliver(e)
+	}
+	if err != nil {
+		log.Printf("realtime: publishing %s event to %s failed: %v", eventType, topic, err)
+	}
+}
+
+// Drop removes the topics from every connection the user has open, fixed
+// ones included, such as an author's topic once the user unfollowed them.
+func (h *Hub) Drop(userID uint, topics ...string) {
+	if len(topics) > 0 {
+		h.Publish(UserTopic(userID), dropEvent, topics)
+	}
+}
+
+// Ignore replaces the users whose events the user's open connections skip,
+// such as those the user blocked or muted.
+func (h *Hub) Ignore(userID uint, actorIDs []uint) {
+	if actorIDs == nil {
+		actorIDs = []uint{}
+	}
+	h.Publish(UserTopic(userID), ignoreEvent, actorIDs)
+}
+
+// Authorize sets how Recheck decides whether a user may go on receiving a
+// topic they subscribed to themselves. Until it is set, Recheck keeps every
+// subscription.
+func (h *Hub) Authorize(fn func(userID uint, topic string) (bool, error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.authorize = fn
+}
+
+// Recheck authorizes the client-chosen topics of the topic's subscribers on
+// every instance again, dropping the ones no longer allowed. For a user's
+// topic that is every such topic of their connections, as after a block or
+// an unfollow. For an author's topic it is every post topic of every
+// connection, as after the author went private, since the hub does not know
+// who wrote which post. Otherwise it is the topic itself, as after a post's
+// visibility changed.
+func (h *Hub) Recheck(topic string) {
+	h.Publish(topic, recheckEvent, nil)
+}
+
+// deliver queues the event for the local subscribers of its topic that do
+// not ignore its actor.
+func (h *Hub) deliver(e Event) {
+	switch e.Type {
+	case dropEvent, ignoreEvent:
+		h.revise(e)
+		return
+	case recheckEvent:
+		// Authorizing may be slow, and must not hold up delivery
+		go h.recheck(e.Topic)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.topics[e.Topic] {
+		if e.ActorID == 0 || !sub.ignored[e.ActorID] {
+			sub.send(e)
+		}
+	}
+}
+
+// revise applies a dropEvent or ignoreEvent to the local subscriptions of
+// the user whose topic it was published to. Clients are told which topics
+// they still have when some were dropped.
+func (h *Hub) revise(e Event) {
+	var topics []string
+	var actorIDs []uint
+	var err error
+	if e.Type == dropEvent {
+		err = json.Unmarshal(e.Data, &topics)
+	} else {
+		err = json.Unmarshal(e.Data, &actorIDs)
+	}
+	if err != nil {
+		log.Printf("realtime: decoding %s event failed: %v", e.Type, err)
+		return
+	}
+
+	var changed []*Subscription
+	h.mu.Lock()
+	for sub := range h.topics[e.Topic] {
+		if UserTopic(sub.UserID) != e.Topic {
+			continue
+		}
+		if e.Type == ignoreEvent {
+			sub.setIgnored(actorIDs)
+		} else if sub.drop(topics) {
+			changed = append(changed, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, sub := range changed {
+		sub.sendTopics()
+	}
+}
+
+// recheck applies a recheckEvent to the local subscribers of the topic.
+func (h *Hub) recheck(topic string) {
+	type check struct {
+		sub   *Subscription
+		topic string
+	}
+	var checks []check
+	h.mu.RLock()
+	authorize := h.authorize
+	if kind, _, _ := ParseTopic(topic); kind == AuthorKind {
+		for _, sub := range h.subscriptions {
+			for t := range sub.topics {
+				if kind, _, _ := ParseTopic(t); kind == PostKind {
+					checks = append(checks, check{sub, t})
+				}
+			}
+		}
+	}
+	for sub := range h.topics[topic] {
+		if topic != UserTopic(sub.UserID) {
+			if sub.topics[topic] {
+				checks = append(checks, check{sub, topic})
+			}
+			continue
+		}
+		for t := range sub.topics {
+			checks = append(checks, check{sub, t})
+		}
+	}
+	h.mu.RUnlock()
+	if authorize == nil {
+		return
+	}
+
+	revoked := map[*Subscription][]string{}
+	for _, c := range checks {
+		allowed, err := authorize(c.sub.UserID, c.topic)
+		if err != nil {
+			log.Printf("realtime: rechecking %s for user %d failed: %v", c.topic, c.sub.UserID, err)
+			continue
+		}
+		if !allowed {
+			revoked[c.sub] = append(revoked[c.sub], c.topic)
+		}
+	}
+
+	var changed []*Subscription
+	h.mu.Lock()
+	for sub, topics := range revoked {
+		if sub.drop(topics) {
+			changed = append(changed, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, sub := range changed {
+		sub.sendTopics()
+	}
+}
+
+// Connect opens a subscription for the user to their own topic and the given
+// fixed topics, which the client cannot unsubscribe from.
+func (h *Hub) Connect(userID uint, fixed ...string) (*Subscription, error) {
+	id, err := newConnectionID()
+	if err != nil {
+		return nil, err
+	}
+	sub := &Subscription{
+		ID:      id,
+		UserID:  userID,
+		hub:     h,
+		events:  make(chan Event, h.cfg.Buffer),
+		done:    make(chan struct{}),
+		fixed:   map[string]bool{},
+		topics:  map[string]bool{},
+		ignored: map[uint]bool{},
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+	h.subscriptions[id] = sub
+	for _, t := range append([]string{UserTopic(userID)}, fixed...) {
+		sub.fixed[t] = true
+		h.attach(t, sub)
+	}
+	return sub, nil
+}
+
+// Lookup finds the user's open subscription by ID.
+func (h *Hub) Lookup(id string, userID uint) (*Subscription, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sub, ok := h.subscriptions[id]
+	if !ok || sub.UserID != userID {
+		return nil, false
+	}
+	return sub, true
+}
+
+func (h *Hub) attach(topic string, sub *Subscription) {
+	subs, ok := h.topics[topic]
+	if !ok {
+		subs = map[*Subscription]struct{}{}
+		h.topics[topic] = subs
+	}
+	subs[sub] = struct{}{}
+}
+
+func (h *Hub) detach(topic string, sub *Subscription) {
+	if subs, ok := h.topics[topic]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// Close ends every subscription and stops listening on the broker.
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	subs := make([]*Subscription, 0, len(h.subscriptions))
+	for _, sub := range h.subscriptions {
+		subs = append(subs, sub)
+	}
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.end(ErrHubClosed)
+		sub.Close()
+	}
+	return h.cfg.Broker.Close()
+}
+
+func newConnectionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Subscription is one client connection's view of the hub. Its events are
+// queued up to Config.Buffer; once the queue is full the subscription ends
+// with ErrSlowConsumer and the client is expected to reconnect.
+type Subscription struct {
+	ID     string
+	UserID uint
+
+	hub    *Hub
+	events chan Event
+
+	once sync.Once
+	done chan struct{}
+	err  error
+
+	// fixed, topics and ignored are guarded by hub.mu
+	fixed   map[string]bool
+	topics  map[string]bool
+	ignored map[uint]bool
+}
+
+// Events returns the queue of events to send to the client.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the subscription ends; Err then says why.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) Err() error {
+	<-s.done
+	return s.err
+}
+
+func (s *Subscription) send(e Event) {
+	select {
+	case s.events <- e:
+	default:
+		s.end(ErrSlowConsumer)
+	}
+}
+
+func (s *Subscription) end(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// Subscribe adds client-chosen topics, failing without changes when they
+// would take the subscription past Config.MaxTopics.
+func (s *Subscription) Subscribe(topics ...string) error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	added := map[string]bool{}
+	for _, t := range topics {
+		if !s.topics[t] && !s.fixed[t] {
+			added[t] = true
+		}
+	}
+	if len(s.topics)+len(added) > s.hub.cfg.MaxTopics {
+		return ErrTooManyTopics
+	}
+	for t := range added {
+		s.topics[t] = true
+		s.hub.attach(t, s)
+	}
+	return nil
+}
+
+// Unsubscribe removes client-chosen topics; fixed topics are kept.
+func (s *Subscription) Unsubscribe(topics ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	for _, t := range topics {
+		if s.topics[t] {
+			delete(s.topics, t)
+			s.hub.detach(t, s)
+		}
+	}
+}
+
+// Ignore sets the users whose events the subscription skips, replacing any
+// set before.
+func (s *Subscription) Ignore(actorIDs []uint) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.setIgnored(actorIDs)
+}
+
+// setIgnored is Ignore for callers holding hub.mu.
+func (s *Subscription) setIgnored(actorIDs []uint) {
+	s.ignored = make(map[uint]bool, len(actorIDs))
+	for _, id := range actorIDs {
+		s.ignored[id] = true
+	}
+}
+
+// sendTopics tells the client which topics it has after some were dropped.
+func (s *Subscription) sendTopics() {
+	// A plain map of strings cannot fail to encode
+	subscribed, _ := NewEvent("", SubscribedEvent, map[string][]string{"topics": s.Topics()})
+	s.send(subscribed)
+}
+
+// drop removes topics, fixed or not, and reports whether any were
+// subscribed. The user's own topic is always kept. The caller must hold
+// hub.mu.
+func (s *Subscription) drop(topics []string) bool {
+	dropped := false
+	for _, t := range topics {
+		if t == UserTopic(s.UserID) || !s.topics[t] && !s.fixed[t] {
+			continue
+		}
+		delete(s.topics, t)
+		delete(s.fixed, t)
+		s.hub.detach(t, s)
+		dropped = true
+	}
+	return dropped
+}
+
+// Topics lists every topic the subscription receives, sorted.
+func (s *Subscription) Topics() []string {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+
+	topics := make([]string, 0, len(s.fixed)+len(s.topics))
+	for t := range s.fixed {
+		topics = append(topics, t)
+	}
+	for t := range s.topics {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Close detaches the subscription from the hub.
+func (s *Subscription) Close() {
+	s.end(nil)
+
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	delete(s.hub.subscriptions, s.ID)
+	for t := range s.fixed {
+		s.hub.detach(t, s)
+	}
+	for t := range s.topics {
+		s.hub.detach(t, s)
+	}
+}
@@ -0,0 +1,223 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestHub(t *testing.T, cfg Config) *Hub {
+	t.Helper()
+	h, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case e := <-sub.Events():
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+	return Event{}
+}
+
+func expectNothing(t *testing.T, sub *Subscription) {
+	t.Helper()
+	select {
+	case e := <-sub.Events():
+		t.Fatalf("unexpected event %+v", e)
+	default:
+	}
+}
+
+func TestRouting(t *testing.T) {
+	h := newTestHub(t, Config{})
+	alice, err := h.Connect(1, AuthorTopic(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := h.Connect(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.Subscribe(PostTopic(5)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := alice.Topics(), []string{"author:9", "user:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Topics() = %v, want %v", got, want)
+	}
+
+	h.Publish(UserTopic(1), NotificationEvent, map[string]int{"unreadCount": 1})
+	if e := receive(t, alice); e.Type != NotificationEvent || string(e.Data) != `{"unreadCount":1}` {
+		t.Errorf("alice got %+v", e)
+	}
+	expectNothing(t, bob)
+
+	h.Publish(PostTopic(5), CommentEvent, nil)
+	if e := receive(t, bob); e.Topic != "post:5" {
+		t.Errorf("bob got %+v", e)
+	}
+	expectNothing(t, alice)
+
+	// Fixed topics stay subscribed
+	bob.Unsubscribe(PostTopic(5), UserTopic(2))
+	h.Publish(PostTopic(5), CommentEvent, nil)
+	h.Publish(UserTopic(2), NotificationEvent, nil)
+	if e := receive(t, bob); e.Topic != "user:2" {
+		t.Errorf("bob got %+v", e)
+	}
+	expectNothing(t, bob)
+
+	if sub, ok := h.Lookup(bob.ID, 2); !ok || sub != bob {
+		t.Error("Lookup() did not find bob's subscription")
+	}
+	if _, ok := h.Lookup(bob.ID, 1); ok {
+		t.Error("Lookup() found bob's subscription for alice")
+	}
+	bob.Close()
+	if _, ok := h.Lookup(bob.ID, 2); ok {
+		t.Error("Lookup() found a closed subscription")
+	}
+}
+
+func TestMaxTopics(t *testing.T) {
+	h := newTestHub(t, Config{MaxTopics: 2})
+	sub, err := h.Connect(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Subscribe(PostTopic(1), PostTopic(2), UserTopic(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Subscribe(PostTopic(3)); !errors.Is(err, ErrTooManyTopics) {
+		t.Errorf("Subscribe() = %v, want ErrTooManyTopics", err)
+	}
+	if len(sub.Topics()) != 3 {
+		t.Errorf("Topics() = %v", sub.Topics())
+	}
+}
+
+func TestSlowConsumer(t *testing.T) {
+	h := newTestHub(t, Config{Buffer: 2})
+	slow, _ := h.Connect(1)
+	fast, _ := h.Connect(1)
+
+	for i := 0; i < 3; i++ {
+		h.Publish(UserTopic(1), LikeEvent, i)
+		receive(t, fast)
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow subscription still open")
+	}
+	if !errors.Is(slow.Err(), ErrSlowConsumer) {
+		t.Errorf("Err() = %v, want ErrSlowConsumer", slow.Err())
+	}
+	select {
+	case <-fast.Done():
+		t.Error("fast subscription ended")
+	default:
+	}
+}
+
+func TestIgnoreAndDrop(t *testing.T) {
+	h := newTestHub(t, Config{})
+	alice, err := h.Connect(1, AuthorTopic(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.Subscribe(PostTopic(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Ignore(1, []uint{3})
+	h.PublishFrom(3, PostTopic(5), CommentEvent, nil)
+	expectNothing(t, alice)
+	h.PublishFrom(4, PostTopic(5), CommentEvent, nil)
+	if e := receive(t, alice); e.ActorID != 4 {
+		t.Errorf("alice got %+v", e)
+	}
+
+	// The user's own topic survives a drop
+	h.Drop(1, AuthorTopic(9), UserTopic(1))
+	if e := receive(t, alice); e.Type != SubscribedEvent || string(e.Data) != `{"topics":["post:5","user:1"]}` {
+		t.Errorf("alice got %+v", e)
+	}
+	h.Publish(AuthorTopic(9), FeedPostEvent, nil)
+	expectNothing(t, alice)
+
+	// Dropping topics no longer subscribed changes nothing
+	h.Drop(1, AuthorTopic(9))
+	h.Ignore(1, nil)
+	h.PublishFrom(3, UserTopic(1), NotificationEvent, nil)
+	if e := receive(t, alice); e.Type != NotificationEvent {
+		t.Errorf("alice got %+v", e)
+	}
+}
+
+func TestRecheck(t *testing.T) {
+	h := newTestHub(t, Config{})
+	alice, _ := h.Connect(1)
+	bob, _ := h.Connect(2)
+	if err := alice.Subscribe(PostTopic(5), PostTopic(6)); err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.Subscribe(PostTopic(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	allowed := map[string]bool{"1 post:6": true, "2 post:5": true}
+	h.Authorize(func(userID uint, topic string) (bool, error) {
+		return allowed[fmt.Sprint(userID, " ", topic)], nil
+	})
+
+	h.Recheck(PostTopic(5))
+	if e := receive(t, alice); e.Type != SubscribedEvent || string(e.Data) != `{"topics":["post:6","user:1"]}` {
+		t.Errorf("alice got %+v", e)
+	}
+	expectNothing(t, bob)
+
+	delete(allowed, "1 post:6")
+	h.Recheck(UserTopic(1))
+	if e := receive(t, alice); e.Type != SubscribedEvent || string(e.Data) != `{"topics":["user:1"]}` {
+		t.Errorf("alice got %+v", e)
+	}
+	h.Publish(PostTopic(5), CommentEvent, nil)
+	if e := receive(t, bob); e.Type != CommentEvent {
+		t.Errorf("bob got %+v", e)
+	}
+	expectNothing(t, alice)
+
+	// An author's topic rechecks every post topic, whoever holds it
+	if err := alice.Subscribe(PostTopic(7)); err != nil {
+		t.Fatal(err)
+	}
+	delete(allowed, "2 post:5")
+	allowed["1 post:7"] = true
+	h.Recheck(AuthorTopic(3))
+	if e := receive(t, bob); e.Type != SubscribedEvent || string(e.Data) != `{"topics":["user:2"]}` {
+		t.Errorf("bob got %+v", e)
+	}
+	expectNothing(t, alice)
+}
+
+func TestParseTopic(t *testing.T) {
+	if kind, id, err := ParseTopic("post:12"); err != nil || kind != PostKind || id != 12 {
+		t.Errorf("ParseTopic(post:12) = %q, %d, %v", kind, id, err)
+	}
+	for _, bad := range []string{"", "post", "post:", "post:0", "post:x", "room:1"} {
+		if _, _, err := ParseTopic(bad); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("ParseTopic(%q) = %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// maxNotifyPayload is the largest payload NOTIFY accepts by default.
+const maxNotifyPayload = 7999
+
+// PostgresBroker shares events between instances through LISTEN/NOTIFY on a
+// single channel. One pooled connection is held for listening and replaced,
+// with backoff, whenever it fails; events published while it is being
+// replaced are missed by this instance.
+type PostgresBroker struct {
+	db      *sql.DB
+	channel string
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPostgresBroker shares events over the given channel. The database must
+// use the pgx driver.
+func NewPostgresBroker(db *sql.DB, channel string) *PostgresBroker {
+	return &PostgresBroker{db: db, channel: channel}
+}
+
+func (b *PostgresBroker) Publish(e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		return ErrPayloadTooLarge
+	}
+	_, err = b.db.Exec("SELECT pg_notify($1, $2)", b.channel, string(payload))
+	return err
+}
+
+func (b *PostgresBroker) Listen(deliver func(Event)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cancel != nil {
+		return fmt.Errorf("already listening on %s", b.channel)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.done = make(chan struct{})
+	go b.listen(ctx, deliver)
+	return nil
+}
+
+func (b *PostgresBroker) listen(ctx context.Context, deliver func(Event)) {
+	defer close(b.done)
+
+	backoff := time.Second
+	for {
+		err := b.listenOnce(ctx, deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("realtime: listening on %s failed, retrying in %s: %v", b.channel, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// listenOnce listens on a dedicated connection until it fails or ctx ends.
+func (b *PostgresBroker) listenOnce(ctx context.Context, deliver func(Event)) error {
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var listenErr error
+	conn.Raw(func(driverConn interface{}) error {
+		listenErr = b.receive(ctx, driverConn, deliver)
+		// Never hand a connection that is still listening back to the pool
+		return driver.ErrBadConn
+	})
+	return listenErr
+}
+
+func (b *PostgresBroker) receive(ctx context.Context, driverConn interface{}, deliver func(Event)) error {
+	stdConn, ok := driverConn.(*stdlib.Conn)
+	if !ok {
+		return fmt.Errorf("unsupported driver connection %T", driverConn)
+	}
+	pgConn := stdConn.Conn()
+	if _, err := pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(notification.Payload), &e); err != nil {
+			log.Printf("realtime: ignoring malformed event on %s: %v", b.channel, err)
+			continue
+		}
+		deliver(e)
+	}
+}
+
+// Close stops listening and waits for the listener to let go of its
+// connection.
+func (b *PostgresBroker) Close() error {
+	b.mu.Lock()
+	cancel, done := b.cancel, b.done
+	b.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return nil
+}
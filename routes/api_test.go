@@ -0,0 +1,197 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/auth"
+	"sinkedin/messaging"
+	"sinkedin/models"
+	"sinkedin/moderation"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
+	"sinkedin/routes"
+	"sinkedin/store"
+	"sinkedin/store/memstore"
+	"sinkedin/timeline"
+	"sinkedin/trending"
+	"sinkedin/typeahead"
+)
+
+// testAPI drives the real router against an in-memory store.
+type testAPI struct {
+	t      *testing.T
+	router *gin.Engine
+	store  *store.Store
+	events *realtime.Hub
+}
+
+type testUser struct {
+	models.User
+	token        string
+	refreshToken string
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	s := memstore.New()
+	authService, err := auth.New(s.Sessions, auth.Config{
+		Keys: auth.Keyring{Active: auth.Key{ID: "test", Secret: []byte("test-secret-test-secret-test-secret")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := realtime.New(realtime.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { events.Close() })
+
+	timelines := timeline.New(s, timeline.Config{Events: events})
+	notices := notifications.New(s, notifications.Config{Events: events})
+	r := gin.New()
+	routes.SetupRoutes(r, routes.Services{
+		Store:         s,
+		Auth:          authService,
+		Timelines:     timelines,
+		Trending:      trending.New(s, trending.Config{}),
+		Typeahead:     typeahead.New(s, typeahead.Config{}),
+		Notifications: notices,
+		Messaging:     messaging.New(s, messaging.Config{Events: events}),
+		Moderation:    moderation.New(s, timelines, notices, moderation.Config{Events: events}),
+		Events:        events,
+	})
+
+	return &testAPI{t: t, router: r, store: s, events: events}
+}
+
+// request sends a JSON request, authenticating as user when it is not nil.
+func (a *testAPI) request(method, path string, body interface{}, user *testUser) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			a.t.Fatalf("encoding request body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if user != nil {
+		req.Header.Set("Authorization", "Bearer "+user.token)
+	}
+
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+// registerUser registers a user named after username and logs them in.
+func (a *testAPI) registerUser(username string) *testUser {
+	a.t.Helper()
+
+	w := a.request(http.MethodPost, "/api/users/register", gin.H{
+		"name":     "User " + username,
+		"username": username,
+		"email":    username + "@example.com",
+		"password": "password123",
+	}, nil)
+	expectStatus(a.t, w, http.StatusCreated)
+
+	w = a.request(http.MethodPost, "/api/users/login", gin.H{
+		"email":    username + "@example.com",
+		"password": "password123",
+	}, nil)
+	expectStatus(a.t, w, http.StatusOK)
+
+	var login struct {
+		Token        string      `json:"token"`
+		RefreshToken string      `json:"refreshToken"`
+		User         models.User `json:"user"`
+	}
+	decode(a.t, w, &login)
+	return &testUser{User: login.User, token: login.Token, refreshToken: login.RefreshToken}
+}
+
+func (a *testAPI) createPost(user *testUser, body gin.H) models.Post {
+	a.t.Helper()
+
+	w := a.request(http.MethodPost, "/api/posts/", body, user)
+	expectStatus(a.t, w, http.StatusCreated)
+
+	var post models.Post
+	decode(a.t, w, &post)
+	return post
+}
+
+func (a *testAPI) createComment(user *testUser, body gin.H) models.Comment {
+	a.t.Helper()
+
+	w := a.request(http.MethodPost, "/api/comments/", body, user)
+	expectStatus(a.t, w, http.StatusCreated)
+
+	var comment models.Comment
+	decode(a.t, w, &comment)
+	return comment
+}
+
+func (a *testAPI) getPost(user *testUser, id uint) models.Post {
+	a.t.Helper()
+
+	w := a.request(http.MethodGet, fmt.Sprintf("/api/posts/%d", id), nil, user)
+	expectStatus(a.t, w, http.StatusOK)
+
+	var post models.Post
+	decode(a.t, w, &post)
+	return post
+}
+
+func (a *testAPI) getUser(username string) models.User {
+	a.t.Helper()
+
+	w := a.request(http.MethodGet, "/api/users/"+username, nil, nil)
+	expectStatus(a.t, w, http.StatusOK)
+
+	var user models.User
+	decode(a.t, w, &user)
+	return user
+}
+
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, want, w.Body.String())
+	}
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding response %q: %v", w.Body.String(), err)
+	}
+}
+
+// listPage is the envelope returned by paginated listings.
+type listPage[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+// errorMessage returns the "error" field of an error response.
+func errorMessage(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error string `json:"error"`
+	}
+	decode(t, w, &body)
+	return body.Error
+}
@@ -0,0 +1,132 @@
+package routes_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (a *testAPI) refresh(refreshToken string) (*tokenPair, int) {
+	a.t.Helper()
+
+	w := a.request(http.MethodPost, "/api/users/refresh", gin.H{"refreshToken": refreshToken}, nil)
+	if w.Code != http.StatusOK {
+		return nil, w.Code
+	}
+	var tokens tokenPair
+	decode(a.t, w, &tokens)
+	return &tokens, w.Code
+}
+
+// login opens an additional session for an already registered user.
+func (a *testAPI) login(user *testUser) *testUser {
+	a.t.Helper()
+
+	w := a.request(http.MethodPost, "/api/users/login", gin.H{
+		"email":    user.Email,
+		"password": "password123",
+	}, nil)
+	expectStatus(a.t, w, http.StatusOK)
+
+	var tokens tokenPair
+	decode(a.t, w, &tokens)
+	return &testUser{User: user.User, token: tokens.Token, refreshToken: tokens.RefreshToken}
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	if alice.refreshToken == "" {
+		t.Fatal("login returned no refresh token")
+	}
+
+	tokens, code := api.refresh(alice.refreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("refresh status = %d", code)
+	}
+	if tokens.RefreshToken == alice.refreshToken {
+		t.Error("refresh token was not rotated")
+	}
+
+	refreshed := &testUser{User: alice.User, token: tokens.Token, refreshToken: tokens.RefreshToken}
+	expectStatus(t, api.request(http.MethodGet, "/api/feed", nil, refreshed), http.StatusOK)
+
+	// The rotated token may be used once more
+	if _, code := api.refresh(tokens.RefreshToken); code != http.StatusOK {
+		t.Errorf("second refresh status = %d", code)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	tokens, code := api.refresh(alice.refreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("refresh status = %d", code)
+	}
+
+	// Replaying the old refresh token signals theft and kills the session
+	if _, code := api.refresh(alice.refreshToken); code != http.StatusUnauthorized {
+		t.Errorf("replayed refresh status = %d, want 401", code)
+	}
+	if _, code := api.refresh(tokens.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("refresh after reuse status = %d, want 401", code)
+	}
+	w := api.request(http.MethodGet, "/api/feed", nil, &testUser{token: tokens.Token})
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestRefreshRejectsGarbage(t *testing.T) {
+	api := newTestAPI(t)
+
+	for _, token := range []string{"nope", "1.", "999.abc"} {
+		if _, code := api.refresh(token); code != http.StatusUnauthorized {
+			t.Errorf("refresh(%q) status = %d, want 401", token, code)
+		}
+	}
+}
+
+func TestLogoutRevokesOnlyCurrentSession(t *testing.T) {
+	api := newTestAPI(t)
+	phone := api.registerUser("alice")
+	laptop := api.login(phone)
+
+	expectStatus(t, api.request(http.MethodPost, "/api/users/logout", nil, phone), http.StatusOK)
+
+	w := api.request(http.MethodGet, "/api/feed", nil, phone)
+	expectStatus(t, w, http.StatusUnauthorized)
+	if msg := errorMessage(t, w); msg != "Session has been revoked" {
+		t.Errorf("error = %q", msg)
+	}
+	if _, code := api.refresh(phone.refreshToken); code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout status = %d, want 401", code)
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/api/feed", nil, laptop), http.StatusOK)
+}
+
+func TestLogoutAllDevices(t *testing.T) {
+	api := newTestAPI(t)
+	phone := api.registerUser("alice")
+	laptop := api.login(phone)
+	bob := api.registerUser("bob")
+
+	expectStatus(t, api.request(http.MethodPost, "/api/users/logout-all", nil, laptop), http.StatusOK)
+
+	for _, session := range []*testUser{phone, laptop} {
+		expectStatus(t, api.request(http.MethodGet, "/api/feed", nil, session), http.StatusUnauthorized)
+		if _, code := api.refresh(session.refreshToken); code != http.StatusUnauthorized {
+			t.Errorf("refresh after logout-all status = %d, want 401", code)
+		}
+	}
+
+	// Other users are unaffected
+	expectStatus(t, api.request(http.MethodGet, "/api/feed", nil, bob), http.StatusOK)
+}
@@ -0,0 +1,278 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+	"sinkedin/realtime"
+)
+
+// listIDs fetches a listing of posts or comments and returns their IDs.
+func listIDs(t *testing.T, api *testAPI, user *testUser, path string) []uint {
+	t.Helper()
+	w := api.request(http.MethodGet, path, nil, user)
+	expectStatus(t, w, http.StatusOK)
+	var page listPage[struct {
+		ID uint `json:"id"`
+	}]
+	decode(t, w, &page)
+	ids := make([]uint, 0, len(page.Data))
+	for _, item := range page.Data {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestBlock(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	api.registerUser("carol")
+
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/bob", nil, alice), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+	alicePost := api.createPost(alice, gin.H{"content": "hello"})
+	bobPost := api.createPost(bob, gin.H{"content": "hi"})
+	aliceComment := api.createComment(alice, gin.H{"postId": bobPost.ID, "type": "normal", "content": "hey bob"})
+
+	expectStatus(t, api.request(http.MethodPost, "/api/blocks/alice", nil, alice), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodPost, "/api/blocks/nobody", nil, alice), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodPost, "/api/blocks/bob", nil, alice), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, "/api/blocks/bob", nil, alice), http.StatusOK)
+
+	// Follows in both directions are gone
+	for _, username := range []string{"alice", "bob"} {
+		if user := api.getUser(username); user.FollowersCount != 0 || user.FollowingCount != 0 {
+			t.Errorf("%s's follow counts = %d/%d after the block", username, user.FollowersCount, user.FollowingCount)
+		}
+	}
+	if got := feedIDs(t, api, alice, ""); fmt.Sprint(got) != fmt.Sprint([]uint{alicePost.ID}) {
+		t.Errorf("alice's feed after the block = %v", got)
+	}
+
+	w := api.request(http.MethodGet, "/api/blocks", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var blocked listPage[models.User]
+	decode(t, w, &blocked)
+	if len(blocked.Data) != 1 || blocked.Data[0].Username != "bob" {
+		t.Errorf("alice's blocks = %+v", blocked.Data)
+	}
+
+	// Neither side can reach the other, whoever blocked
+	for _, tc := range []struct {
+		actor  *testUser
+		method string
+		path   string
+		body   gin.H
+		want   int
+	}{
+		{bob, http.MethodPost, "/api/follow/alice", nil, http.StatusForbidden},
+		{alice, http.MethodPost, "/api/follow/bob", nil, http.StatusForbidden},
+		{bob, http.MethodGet, "/api/users/alice", nil, http.StatusNotFound},
+		{alice, http.MethodGet, "/api/users/bob", nil, http.StatusNotFound},
+		{nil, http.MethodGet, "/api/users/alice", nil, http.StatusOK},
+		{bob, http.MethodPost, fmt.Sprintf("/api/likes/post/%d", alicePost.ID), nil, http.StatusForbidden},
+		{bob, http.MethodPost, "/api/comments/", gin.H{"postId": alicePost.ID, "type": "normal", "content": "hi"}, http.StatusForbidden},
+		{bob, http.MethodPost, "/api/comments/", gin.H{"parentId": aliceComment.ID, "type": "normal", "content": "hi"}, http.StatusForbidden},
+		{bob, http.MethodPost, "/api/posts/", gin.H{"content": "look", "tags": []string{"alice"}}, http.StatusForbidden},
+		{alice, http.MethodPost, "/api/conversations", gin.H{"usernames": []string{"bob"}}, http.StatusForbidden},
+	} {
+		w := api.request(tc.method, tc.path, tc.body, tc.actor)
+		if w.Code != tc.want {
+			t.Errorf("%s %s = %d, want %d: %s", tc.method, tc.path, w.Code, tc.want, w.Body.String())
+		}
+	}
+
+	// A mention of a blocked user is left as plain text and tags no one
+	mentioning := api.createPost(bob, gin.H{"content": "hey @alice and @carol"})
+	if len(mentioning.Tags) != 1 || mentioning.Tags[0].Username != "carol" {
+		t.Errorf("tags of a post mentioning a blocked user = %+v", mentioning.Tags)
+	}
+
+	// Each disappears from the other's listings, and their posts and
+	// comments cannot be fetched directly either
+	if got := listIDs(t, api, bob, fmt.Sprintf("/api/comments/post/%d", bobPost.ID)); len(got) != 0 {
+		t.Errorf("bob sees comments %v by alice", got)
+	}
+	for _, tc := range []struct {
+		actor *testUser
+		path  string
+	}{
+		{bob, fmt.Sprintf("/api/posts/%d", alicePost.ID)},
+		{alice, fmt.Sprintf("/api/posts/%d", bobPost.ID)},
+		{alice, fmt.Sprintf("/api/comments/post/%d", bobPost.ID)},
+		{bob, fmt.Sprintf("/api/comments/%d", aliceComment.ID)},
+		{bob, fmt.Sprintf("/api/comments/%d/thread", aliceComment.ID)},
+		{bob, fmt.Sprintf("/api/comments/%d/replies", aliceComment.ID)},
+	} {
+		expectStatus(t, api.request(http.MethodGet, tc.path, nil, tc.actor), http.StatusNotFound)
+	}
+	// The blocker can still report the blocked user's content
+	report(t, api, alice, "post", bobPost.ID, "harassment")
+	if got := listIDs(t, api, bob, "/api/posts/"); fmt.Sprint(got) != fmt.Sprint([]uint{mentioning.ID, bobPost.ID}) {
+		t.Errorf("bob sees posts %v", got)
+	}
+
+	expectStatus(t, api.request(http.MethodDelete, "/api/blocks/bob", nil, alice), http.StatusOK)
+	expectStatus(t, api.request(http.MethodDelete, "/api/blocks/bob", nil, alice), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodGet, "/api/users/alice", nil, bob), http.StatusOK)
+}
+
+func TestBlockKeepsWithdrawingLikes(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "hello"})
+	path := fmt.Sprintf("/api/likes/post/%d", post.ID)
+
+	expectStatus(t, api.request(http.MethodPost, path, nil, bob), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodPost, "/api/blocks/bob", nil, alice), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, path, nil, bob), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, path, nil, bob), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodPost, "/api/likes/post/999", nil, bob), http.StatusNotFound)
+}
+
+func TestMute(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/bob", nil, alice), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/carol", nil, alice), http.StatusCreated)
+	bobPost := api.createPost(bob, gin.H{"content": "loud #news"})
+	carolPost := api.createPost(carol, gin.H{"content": "quiet #news"})
+	bobComment := api.createComment(bob, gin.H{"postId": carolPost.ID, "type": "normal", "content": "more #news"})
+	carolComment := api.createComment(carol, gin.H{"postId": carolPost.ID, "type": "normal", "content": "thanks"})
+	api.createComment(bob, gin.H{"parentId": carolComment.ID, "type": "normal", "content": "welcome"})
+
+	expectStatus(t, api.request(http.MethodPost, "/api/mutes/bob", nil, alice), http.StatusOK)
+
+	for path, want := range map[string][]uint{
+		"/api/feed":                {carolPost.ID},
+		"/api/posts/":              {carolPost.ID},
+		"/api/hashtags/news/posts": {carolPost.ID},
+		fmt.Sprintf("/api/comments/post/%d", carolPost.ID):       {carolComment.ID},
+		fmt.Sprintf("/api/comments/%d/replies", carolComment.ID): {},
+	} {
+		if got := listIDs(t, api, alice, path); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s = %v, want %v", path, got, want)
+		}
+	}
+
+	// Nor in user search or among a hashtag's top contributors
+	for user, want := range map[*testUser]int{alice: 0, carol: 1} {
+		w := api.request(http.MethodGet, "/api/search?type=users&q=bob", nil, user)
+		expectStatus(t, w, http.StatusOK)
+		var users listPage[searchHit[models.User]]
+		decode(t, w, &users)
+		if len(users.Data) != want {
+			t.Errorf("%s finds users %+v", user.Username, users.Data)
+		}
+	}
+	w := api.request(http.MethodGet, "/api/hashtags/news", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var details struct {
+		TopContributors []struct {
+			User models.User `json:"user"`
+		} `json:"topContributors"`
+	}
+	decode(t, w, &details)
+	if len(details.TopContributors) != 1 || details.TopContributors[0].User.Username != "carol" {
+		t.Errorf("top contributors = %+v", details.TopContributors)
+	}
+
+	w = api.request(http.MethodGet, "/api/hashtags/news/content", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var content listPage[struct {
+		Type string `json:"type"`
+	}]
+	decode(t, w, &content)
+	if len(content.Data) != 1 || content.Data[0].Type != "post" {
+		t.Errorf("hashtag content = %+v", content.Data)
+	}
+
+	w = api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d/thread", carolComment.ID), nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var thread struct {
+		Replies []struct{} `json:"replies"`
+	}
+	decode(t, w, &thread)
+	if len(thread.Replies) != 0 {
+		t.Errorf("thread shows %d muted replies", len(thread.Replies))
+	}
+
+	// Only the muter is affected, and the muted user can still interact
+	if got := listIDs(t, api, carol, fmt.Sprintf("/api/comments/post/%d", carolPost.ID)); len(got) != 2 {
+		t.Errorf("carol sees comments %v", got)
+	}
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/comment/%d", bobComment.ID), nil, alice), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodGet, "/api/users/bob", nil, alice), http.StatusOK)
+
+	w = api.request(http.MethodGet, "/api/mutes", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var muted listPage[models.User]
+	decode(t, w, &muted)
+	if len(muted.Data) != 1 || muted.Data[0].Username != "bob" {
+		t.Errorf("alice's mutes = %+v", muted.Data)
+	}
+
+	expectStatus(t, api.request(http.MethodDelete, "/api/mutes/bob", nil, alice), http.StatusOK)
+	if got := listIDs(t, api, alice, "/api/feed"); fmt.Sprint(got) != fmt.Sprint([]uint{carolPost.ID, bobPost.ID}) {
+		t.Errorf("feed after unmuting = %v", got)
+	}
+}
+
+func TestBlockDropsPostSubscriptions(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+	alicePost := api.createPost(alice, gin.H{"content": "alice"})
+	bobPost := api.createPost(bob, gin.H{"content": "bob"})
+	query := "?access_token=%s&topics=post:%d"
+	_, aliceEvents := openStream(t, server, fmt.Sprintf(query, alice.token, bobPost.ID))
+	_, bobEvents := openStream(t, server, fmt.Sprintf(query, bob.token, alicePost.ID))
+	_, carolEvents := openStream(t, server, fmt.Sprintf(query, carol.token, alicePost.ID))
+	var connection struct {
+		ConnectionID string `json:"connectionId"`
+	}
+	json.Unmarshal(nextEvent(t, bobEvents).Data, &connection)
+	nextEvent(t, aliceEvents)
+	nextEvent(t, carolEvents)
+
+	// Each loses the other's post, whoever blocked, while others keep it
+	expectStatus(t, api.request(http.MethodPost, "/api/blocks/alice", nil, bob), http.StatusOK)
+	if got, want := subscription(nextEvent(t, aliceEvents)), fmt.Sprintf("subscribed [user:%d]", alice.ID); got != want {
+		t.Errorf("alice after the block = %s, want %s", got, want)
+	}
+	if got, want := subscription(nextEvent(t, bobEvents)), fmt.Sprintf("subscribed [user:%d]", bob.ID); got != want {
+		t.Errorf("bob after the block = %s, want %s", got, want)
+	}
+	like := fmt.Sprintf("/api/likes/post/%d", alicePost.ID)
+	expectStatus(t, api.request(http.MethodPost, like, nil, alice), http.StatusCreated)
+	if e := nextEvent(t, carolEvents); e.Type != realtime.LikeEvent {
+		t.Errorf("carol after the block = %+v, want the like", e)
+	}
+
+	// Unblocking does not bring the topic back, but it may be subscribed to
+	// again on the same stream
+	path := "/api/events/" + connection.ConnectionID + "/subscriptions"
+	body := gin.H{"subscribe": []string{realtime.PostTopic(alicePost.ID)}}
+	expectStatus(t, api.request(http.MethodPost, path, body, bob), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodDelete, "/api/blocks/alice", nil, bob), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, path, body, bob), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, like, nil, alice), http.StatusOK)
+	if e := nextEvent(t, bobEvents); e.Type != realtime.LikeEvent {
+		t.Errorf("bob after subscribing again = %+v, want the like", e)
+	}
+}
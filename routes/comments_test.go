@@ -0,0 +1,244 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+func TestCommentsAndReplies(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "Hello world!"})
+
+	comment := api.createComment(bob, gin.H{
+		"postId":   post.ID,
+		"content":  "Great first post! #welcome",
+		"type":     "normal",
+		"hashtags": []string{"welcome"},
+		"tags":     []string{"alice"},
+	})
+	if comment.PostID == nil || *comment.PostID != post.ID || comment.User.Username != "bob" {
+		t.Errorf("comment = %+v", comment)
+	}
+	if !comment.ContainsHashtag || !comment.ContainsTag || len(comment.Hashtags) != 1 || len(comment.Tags) != 1 {
+		t.Errorf("comment associations = %+v / %+v", comment.Hashtags, comment.Tags)
+	}
+
+	reply := api.createComment(alice, gin.H{
+		"postId":   post.ID,
+		"parentId": comment.ID,
+		"content":  "Thanks!",
+		"type":     "normal",
+	})
+	if reply.ParentCommentID == nil || *reply.ParentCommentID != comment.ID {
+		t.Errorf("reply parent = %v", reply.ParentCommentID)
+	}
+
+	if got := api.getPost(alice, post.ID); got.CommentCount != 2 {
+		t.Errorf("post commentCount = %d, want 2", got.CommentCount)
+	}
+
+	w := api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d", comment.ID), nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var parent models.Comment
+	decode(t, w, &parent)
+	if parent.CommentCount != 1 {
+		t.Errorf("comment commentCount = %d, want 1", parent.CommentCount)
+	}
+
+	w = api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d", reply.ID), nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var child models.Comment
+	decode(t, w, &child)
+	if child.ParentComment == nil || child.ParentComment.ID != comment.ID {
+		t.Errorf("reply parentComment = %+v", child.ParentComment)
+	}
+
+	// Only top-level comments are listed for the post
+	w = api.request(http.MethodGet, fmt.Sprintf("/api/comments/post/%d", post.ID), nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var comments listPage[models.Comment]
+	decode(t, w, &comments)
+	if len(comments.Data) != 1 || comments.Data[0].ID != comment.ID {
+		t.Errorf("post comments = %+v", comments.Data)
+	}
+}
+
+func TestUpdateAndDeleteComment(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "Hello"})
+	comment := api.createComment(bob, gin.H{"postId": post.ID, "content": "first", "type": "normal"})
+	path := fmt.Sprintf("/api/comments/%d", comment.ID)
+
+	w := api.request(http.MethodPut, path, gin.H{"content": "edited", "type": "normal"}, alice)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = api.request(http.MethodPut, path, gin.H{"content": "edited", "type": "normal"}, bob)
+	expectStatus(t, w, http.StatusOK)
+	var updated models.Comment
+	decode(t, w, &updated)
+	if updated.Content != "edited" {
+		t.Errorf("content = %q", updated.Content)
+	}
+
+	w = api.request(http.MethodDelete, path, nil, alice)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = api.request(http.MethodDelete, path, nil, bob)
+	expectStatus(t, w, http.StatusOK)
+
+	if got := api.getPost(alice, post.ID); got.CommentCount != 0 {
+		t.Errorf("post commentCount = %d after delete, want 0", got.CommentCount)
+	}
+
+	w = api.request(http.MethodGet, path, nil, alice)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestDeleteCommentWithReplies(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "Hello"})
+	comment := api.createComment(bob, gin.H{"postId": post.ID, "content": "first", "type": "normal"})
+	reply := api.createComment(alice, gin.H{"postId": post.ID, "parentId": comment.ID, "content": "reply", "type": "normal"})
+	nested := api.createComment(bob, gin.H{"postId": post.ID, "parentId": reply.ID, "content": "nested", "type": "normal"})
+	api.createComment(alice, gin.H{"postId": post.ID, "content": "second", "type": "normal"})
+
+	if got := api.getPost(alice, post.ID); got.CommentCount != 4 {
+		t.Fatalf("post commentCount = %d, want 4", got.CommentCount)
+	}
+
+	w := api.request(http.MethodDelete, fmt.Sprintf("/api/comments/%d", comment.ID), nil, bob)
+	expectStatus(t, w, http.StatusOK)
+
+	// The replies go with their parent and stop counting towards the post
+	if got := api.getPost(alice, post.ID); got.CommentCount != 1 {
+		t.Errorf("post commentCount = %d after delete, want 1", got.CommentCount)
+	}
+	for _, id := range []uint{reply.ID, nested.ID} {
+		w = api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d", id), nil, alice)
+		expectStatus(t, w, http.StatusNotFound)
+	}
+}
+
+func TestCreateCommentOnMissingPost(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	w := api.request(http.MethodPost, "/api/comments/", gin.H{"postId": 999, "content": "hello?", "type": "normal"}, alice)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+// fieldErrors decodes the per-field validation errors of a response.
+func fieldErrors(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+
+	var body struct {
+		Fields []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"fields"`
+	}
+	decode(t, w, &body)
+	fields := map[string]string{}
+	for _, f := range body.Fields {
+		fields[f.Field] = f.Message
+	}
+	return fields
+}
+
+func TestCommentValidation(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "post"})
+	other := api.createPost(alice, gin.H{"content": "other"})
+	root := api.createComment(alice, gin.H{"postId": post.ID, "content": "root", "type": "normal"})
+
+	cases := []struct {
+		name   string
+		body   gin.H
+		status int
+		fields []string
+	}{
+		{"orphan", gin.H{"content": "lost", "type": "normal"}, http.StatusBadRequest, []string{"postId"}},
+		{"cross-post reply", gin.H{"postId": other.ID, "parentId": root.ID, "content": "x", "type": "normal"}, http.StatusBadRequest, []string{"parentId"}},
+		{"missing parent", gin.H{"parentId": 999, "content": "x", "type": "normal"}, http.StatusNotFound, []string{"parentId"}},
+		{"unknown type", gin.H{"postId": post.ID, "content": "x", "type": "video"}, http.StatusBadRequest, []string{"type"}},
+		{"empty content", gin.H{"postId": post.ID, "content": "  ", "type": "normal"}, http.StatusBadRequest, []string{"content"}},
+		{"gif without payload", gin.H{"postId": post.ID, "type": "gif"}, http.StatusBadRequest, []string{"gif"}},
+		{"bad gif payload", gin.H{"postId": post.ID, "type": "gif", "gif": gin.H{"url": "javascript:alert(1)", "width": 0, "height": 10}}, http.StatusBadRequest, []string{"gif.url", "gif.width"}},
+		{"several problems", gin.H{"type": "video"}, http.StatusBadRequest, []string{"type", "postId"}},
+	}
+	for _, tc := range cases {
+		w := api.request(http.MethodPost, "/api/comments/", tc.body, alice)
+		if w.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d: %s", tc.name, w.Code, tc.status, w.Body)
+			continue
+		}
+		fields := fieldErrors(t, w)
+		for _, field := range tc.fields {
+			if fields[field] == "" {
+				t.Errorf("%s: no error for %s in %v", tc.name, field, fields)
+			}
+		}
+	}
+
+	if got := api.getPost(alice, post.ID); got.CommentCount != 1 {
+		t.Errorf("commentCount = %d after rejected comments, want 1", got.CommentCount)
+	}
+}
+
+func TestReplyDerivesPost(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "post"})
+	root := api.createComment(alice, gin.H{"postId": post.ID, "content": "root", "type": "normal"})
+
+	reply := api.createComment(alice, gin.H{"parentId": root.ID, "content": "reply", "type": "normal"})
+	nested := api.createComment(alice, gin.H{"parentId": reply.ID, "content": "nested", "type": "normal"})
+	for _, comment := range []models.Comment{reply, nested} {
+		if comment.PostID == nil || *comment.PostID != post.ID {
+			t.Errorf("comment %d postId = %v, want %d", comment.ID, comment.PostID, post.ID)
+		}
+	}
+}
+
+func TestGifComment(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "post"})
+
+	comment := api.createComment(alice, gin.H{
+		"postId": post.ID,
+		"type":   "gif",
+		"gif":    gin.H{"url": "https://media.example.com/cat.gif", "width": 320, "height": 240, "provider": "example"},
+	})
+	if comment.Type != models.GifComment || comment.Gif == nil || comment.Gif.URL != "https://media.example.com/cat.gif" || comment.Gif.Width != 320 {
+		t.Errorf("comment = %+v", comment)
+	}
+
+	// Switching to a normal comment requires dropping the payload
+	path := fmt.Sprintf("/api/comments/%d", comment.ID)
+	w := api.request(http.MethodPut, path, gin.H{"type": "normal", "content": "words", "gif": gin.H{"url": "https://x.example/a.gif", "width": 1, "height": 1}}, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+	if fields := fieldErrors(t, w); fields["gif"] == "" {
+		t.Errorf("fields = %v", fields)
+	}
+
+	w = api.request(http.MethodPut, path, gin.H{"type": "normal", "content": "words"}, alice)
+	expectStatus(t, w, http.StatusOK)
+	var updated models.Comment
+	decode(t, w, &updated)
+	if updated.Type != models.NormalComment || updated.Gif != nil {
+		t.Errorf("updated = %+v", updated)
+	}
+}
@@ -0,0 +1,175 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+// hashtagCounters returns the counters of the trending hashtags by name.
+func (a *testAPI) hashtagCounters() map[string]int {
+	a.t.Helper()
+
+	w := a.request(http.MethodGet, "/api/hashtags/trending", nil, nil)
+	expectStatus(a.t, w, http.StatusOK)
+	var hashtags []models.Hashtag
+	decode(a.t, w, &hashtags)
+
+	counters := map[string]int{}
+	for _, hashtag := range hashtags {
+		counters[hashtag.Name] = hashtag.Counter
+	}
+	return counters
+}
+
+func names[T any](items []T, name func(T) string) map[string]bool {
+	set := map[string]bool{}
+	for _, item := range items {
+		set[name(item)] = true
+	}
+	return set
+}
+
+func TestUpdatePostDiffsHashtagsAndTags(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	api.registerUser("bob")
+	api.registerUser("carol")
+
+	post := api.createPost(alice, gin.H{"content": "v1", "hashtags": []string{"go", "rust"}, "tags": []string{"bob"}})
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	w := api.request(http.MethodPut, path, gin.H{"content": "v2", "hashtags": []string{"go", "zig"}, "tags": []string{"carol"}}, alice)
+	expectStatus(t, w, http.StatusOK)
+	var updated models.Post
+	decode(t, w, &updated)
+
+	hashtags := names(updated.Hashtags, func(h models.Hashtag) string { return h.Name })
+	if len(hashtags) != 2 || !hashtags["go"] || !hashtags["zig"] {
+		t.Errorf("hashtags = %v", hashtags)
+	}
+	tags := names(updated.Tags, func(u models.User) string { return u.Username })
+	if len(tags) != 1 || !tags["carol"] || !updated.HasTag || !updated.HasHashtag {
+		t.Errorf("tags = %v, hasTag = %v, hasHashtag = %v", tags, updated.HasTag, updated.HasHashtag)
+	}
+
+	counters := api.hashtagCounters()
+	if counters["go"] != 1 || counters["rust"] != 0 || counters["zig"] != 1 {
+		t.Errorf("counters = %v", counters)
+	}
+
+	// Omitted arrays are left alone; empty ones clear the associations
+	w = api.request(http.MethodPut, path, gin.H{"content": "v3"}, alice)
+	expectStatus(t, w, http.StatusOK)
+	if got := api.getPost(alice, post.ID); len(got.Hashtags) != 2 || len(got.Tags) != 1 {
+		t.Errorf("after content-only edit: hashtags = %v, tags = %v", got.Hashtags, got.Tags)
+	}
+
+	w = api.request(http.MethodPut, path, gin.H{"content": "v4", "hashtags": []string{}, "tags": []string{}}, alice)
+	expectStatus(t, w, http.StatusOK)
+	got := api.getPost(alice, post.ID)
+	if len(got.Hashtags) != 0 || len(got.Tags) != 0 || got.HasHashtag || got.HasTag {
+		t.Errorf("after clearing: %+v", got)
+	}
+	if counters := api.hashtagCounters(); counters["go"] != 0 || counters["zig"] != 0 {
+		t.Errorf("counters after clearing = %v", counters)
+	}
+
+	w = api.request(http.MethodPut, path, gin.H{"content": "v5", "tags": []string{"nobody"}}, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestUpdateCommentDiffsHashtagsAndTags(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "post"})
+	comment := api.createComment(alice, gin.H{"postId": post.ID, "content": "c", "type": "normal", "hashtags": []string{"go"}})
+
+	w := api.request(http.MethodPut, fmt.Sprintf("/api/comments/%d", comment.ID), gin.H{
+		"content": "edited", "type": "normal", "hashtags": []string{}, "tags": []string{"bob"},
+	}, alice)
+	expectStatus(t, w, http.StatusOK)
+	var updated models.Comment
+	decode(t, w, &updated)
+
+	if len(updated.Hashtags) != 0 || updated.ContainsHashtag {
+		t.Errorf("hashtags = %v, containsHashtag = %v", updated.Hashtags, updated.ContainsHashtag)
+	}
+	if len(updated.Tags) != 1 || updated.Tags[0].Username != "bob" || !updated.ContainsTag {
+		t.Errorf("tags = %v, containsTag = %v", updated.Tags, updated.ContainsTag)
+	}
+}
+
+func TestContentEntities(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+
+	post := api.createPost(alice, gin.H{
+		"content":  "Hi @bob and @ghost! #GoLang see https://go.dev/#top `#notatag`",
+		"hashtags": []string{"#Explicit"},
+	})
+
+	hashtags := names(post.Hashtags, func(h models.Hashtag) string { return h.Name })
+	if len(hashtags) != 2 || !hashtags["golang"] || !hashtags["explicit"] {
+		t.Errorf("hashtags = %v", hashtags)
+	}
+	if len(post.Tags) != 1 || post.Tags[0].ID != bob.ID {
+		t.Errorf("tags = %+v", post.Tags)
+	}
+
+	want := []models.Entity{
+		{Type: models.MentionEntity, Text: "bob", Start: 3, End: 7, UserID: bob.ID},
+		{Type: models.HashtagEntity, Text: "golang", Start: 20, End: 27},
+	}
+	if len(post.Entities) != len(want) {
+		t.Fatalf("entities = %+v", post.Entities)
+	}
+	for i := range want {
+		if post.Entities[i] != want[i] {
+			t.Errorf("entity %d = %+v, want %+v", i, post.Entities[i], want[i])
+		}
+	}
+
+	// Lookups are case-insensitive once names are normalized
+	w := api.request(http.MethodGet, "/api/hashtags/GoLang/posts", nil, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	w = api.request(http.MethodPost, "/api/posts/", gin.H{"content": "x", "hashtags": []string{"not valid"}}, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestEditReextractsContent(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	api.registerUser("bob")
+	api.registerUser("carol")
+
+	post := api.createPost(alice, gin.H{"content": "#old with @bob", "hashtags": []string{"kept"}})
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	// Content-derived hashtags and tags follow the content; explicit ones stay
+	w := api.request(http.MethodPut, path, gin.H{"content": "#new with @carol"}, alice)
+	expectStatus(t, w, http.StatusOK)
+	var updated models.Post
+	decode(t, w, &updated)
+
+	hashtags := names(updated.Hashtags, func(h models.Hashtag) string { return h.Name })
+	if len(hashtags) != 2 || !hashtags["new"] || !hashtags["kept"] {
+		t.Errorf("hashtags = %v", hashtags)
+	}
+	tags := names(updated.Tags, func(u models.User) string { return u.Username })
+	if len(tags) != 1 || !tags["carol"] {
+		t.Errorf("tags = %v", tags)
+	}
+	if len(updated.Entities) != 2 || updated.Entities[0].Text != "new" {
+		t.Errorf("entities = %+v", updated.Entities)
+	}
+	if counters := api.hashtagCounters(); counters["old"] != 0 || counters["new"] != 1 {
+		t.Errorf("counters = %v", counters)
+	}
+}
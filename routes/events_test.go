@@ -0,0 +1,365 @@
+package routes_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"sinkedin/realtime"
+)
+
+// openStream connects to the SSE endpoint and returns the events it sends,
+// parsed, on a channel that is closed when the stream ends.
+func openStream(t *testing.T, server *httptest.Server, query string) (*http.Response, <-chan realtime.Event) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan realtime.Event, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var e realtime.Event
+			if json.Unmarshal([]byte(data), &e) == nil {
+				events <- e
+			}
+		}
+	}()
+	return resp, events
+}
+
+// nextEvent waits for the next event that is not a heartbeat.
+func nextEvent(t *testing.T, events <-chan realtime.Event) realtime.Event {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("stream ended")
+			}
+			if e.Type != realtime.HeartbeatEvent {
+				return e
+			}
+		case <-timeout:
+			t.Fatal("no event received")
+		}
+	}
+}
+
+// subscription renders an event as its type and, for the subscribed event,
+// the topics the connection is now subscribed to.
+func subscription(e realtime.Event) string {
+	var subscribed struct {
+		Topics []string `json:"topics"`
+	}
+	json.Unmarshal(e.Data, &subscribed)
+	return fmt.Sprintf("%s %v", e.Type, subscribed.Topics)
+}
+
+func TestEventStream(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+	post := api.createPost(alice, gin.H{"content": "hello"})
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+
+	for query, want := range map[string]int{
+		"": http.StatusUnauthorized,
+		"?access_token=" + bob.token + "&topics=x":        http.StatusBadRequest,
+		"?access_token=" + bob.token + "&topics=user:1":   http.StatusBadRequest,
+		"?access_token=" + bob.token + "&topics=post:999": http.StatusNotFound,
+	} {
+		resp, _ := openStream(t, server, query)
+		if resp.StatusCode != want {
+			t.Errorf("GET /api/events%s = %d, want %d", query, resp.StatusCode, want)
+		}
+	}
+
+	resp, events := openStream(t, server, fmt.Sprintf("?access_token=%s&topics=post:%d", bob.token, post.ID))
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream response = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	ready := nextEvent(t, events)
+	var connection struct {
+		ConnectionID string   `json:"connectionId"`
+		Topics       []string `json:"topics"`
+	}
+	if err := json.Unmarshal(ready.Data, &connection); err != nil || ready.Type != realtime.ReadyEvent {
+		t.Fatalf("ready = %+v, %v", ready, err)
+	}
+	if want := fmt.Sprintf("[post:%d user:%d]", post.ID, bob.ID); fmt.Sprint(connection.Topics) != want {
+		t.Errorf("topics = %v, want %s", connection.Topics, want)
+	}
+
+	// Activity on the watched post
+	api.createComment(carol, gin.H{"postId": post.ID, "type": "normal", "content": "nice"})
+	if e := nextEvent(t, events); e.Type != realtime.CommentEvent || e.Topic != realtime.PostTopic(post.ID) {
+		t.Errorf("after comment = %+v", e)
+	}
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, carol), http.StatusCreated)
+	e := nextEvent(t, events)
+	var like struct {
+		LikeCount int `json:"likeCount"`
+	}
+	if json.Unmarshal(e.Data, &like); e.Type != realtime.LikeEvent || like.LikeCount != 1 {
+		t.Errorf("after like = %+v", e)
+	}
+
+	// New posts from followed accounts and notifications of bob's own
+	fresh := api.createPost(alice, gin.H{"content": "news"})
+	e = nextEvent(t, events)
+	var feedPost struct {
+		ID uint `json:"id"`
+	}
+	if json.Unmarshal(e.Data, &feedPost); e.Type != realtime.FeedPostEvent || feedPost.ID != fresh.ID {
+		t.Errorf("after post = %+v", e)
+	}
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/bob", nil, carol), http.StatusCreated)
+	e = nextEvent(t, events)
+	var notification struct {
+		Summary     string `json:"summary"`
+		UnreadCount int    `json:"unreadCount"`
+	}
+	if json.Unmarshal(e.Data, &notification); e.Type != realtime.NotificationEvent || notification.Summary != "carol started following you" || notification.UnreadCount != 1 {
+		t.Errorf("after follow = %+v", e)
+	}
+
+	// Subscriptions can be changed by their owner only
+	path := "/api/events/" + connection.ConnectionID + "/subscriptions"
+	body := gin.H{"unsubscribe": []string{realtime.PostTopic(post.ID)}}
+	expectStatus(t, api.request(http.MethodPost, path, body, alice), http.StatusNotFound)
+	w := api.request(http.MethodPost, path, body, bob)
+	expectStatus(t, w, http.StatusOK)
+	api.createComment(carol, gin.H{"postId": post.ID, "type": "normal", "content": "again"})
+	expectStatus(t, api.request(http.MethodPost, "/api/notifications/read-all", nil, bob), http.StatusOK)
+	if e := nextEvent(t, events); e.Type != realtime.NotificationsReadEvent {
+		t.Errorf("after unsubscribing = %+v", e)
+	}
+}
+
+func TestEventWebSocket(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "hello"})
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/events/ws?access_token=" + bob.token
+	ws, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(2 * time.Second))
+
+	receive := func() realtime.Event {
+		t.Helper()
+		var e realtime.Event
+		if err := websocket.JSON.Receive(ws, &e); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	if e := receive(); e.Type != realtime.ReadyEvent {
+		t.Fatalf("first event = %+v", e)
+	}
+
+	websocket.JSON.Send(ws, gin.H{"action": "subscribe", "topics": []string{"post:999"}})
+	if e := receive(); e.Type != realtime.ErrorEvent {
+		t.Errorf("subscribing to a missing post = %+v", e)
+	}
+	websocket.JSON.Send(ws, gin.H{"action": "subscribe", "topics": []string{realtime.PostTopic(post.ID)}})
+	if e := receive(); e.Type != realtime.SubscribedEvent || !strings.Contains(string(e.Data), realtime.PostTopic(post.ID)) {
+		t.Errorf("subscribing = %+v", e)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, alice), http.StatusCreated)
+	if e := receive(); e.Type != realtime.LikeEvent || e.Topic != realtime.PostTopic(post.ID) {
+		t.Errorf("after like = %+v", e)
+	}
+
+	websocket.JSON.Send(ws, gin.H{"action": "ping"})
+	if e := receive(); e.Type != realtime.HeartbeatEvent {
+		t.Errorf("ping = %+v", e)
+	}
+}
+
+func TestEventStreamSkipsHiddenUsers(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+	dave := api.registerUser("dave")
+	post := api.createPost(alice, gin.H{"content": "hello"})
+	expectStatus(t, api.request(http.MethodPost, "/api/mutes/carol", nil, bob), http.StatusOK)
+
+	_, events := openStream(t, server, fmt.Sprintf("?access_token=%s&topics=post:%d", bob.token, post.ID))
+	if e := nextEvent(t, events); e.Type != realtime.ReadyEvent {
+		t.Fatalf("first event = %+v", e)
+	}
+
+	// Only dave's like gets through, carrying the count with carol's in it
+	api.createComment(carol, gin.H{"postId": post.ID, "type": "normal", "content": "nice"})
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, carol), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, dave), http.StatusCreated)
+	e := nextEvent(t, events)
+	var like struct {
+		LikeCount int `json:"likeCount"`
+	}
+	if json.Unmarshal(e.Data, &like); e.Type != realtime.LikeEvent || e.ActorID != dave.ID || like.LikeCount != 2 {
+		t.Errorf("first event after the mute = %+v", e)
+	}
+}
+
+func TestEventStreamRechecksPostTopics(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+	public := api.createPost(alice, gin.H{"content": "hello"})
+	forFollowers := api.createPost(alice, gin.H{"content": "friends", "visibility": "followers"})
+
+	_, bobEvents := openStream(t, server, fmt.Sprintf("?access_token=%s&topics=post:%d,post:%d", bob.token, public.ID, forFollowers.ID))
+	_, carolEvents := openStream(t, server, fmt.Sprintf("?access_token=%s&topics=post:%d", carol.token, public.ID))
+	nextEvent(t, bobEvents)
+	nextEvent(t, carolEvents)
+
+	// Turning the post private drops it for everyone watching
+	path := fmt.Sprintf("/api/posts/%d", public.ID)
+	expectStatus(t, api.request(http.MethodPut, path, gin.H{"content": "hello", "visibility": "followers"}, alice), http.StatusOK)
+	if got, want := subscription(nextEvent(t, carolEvents)), fmt.Sprintf("subscribed [user:%d]", carol.ID); got != want {
+		t.Errorf("carol after the visibility change = %s, want %s", got, want)
+	}
+
+	// Unfollowing drops the posts only followers may see
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusOK)
+	if got, want := subscription(nextEvent(t, bobEvents)), fmt.Sprintf("subscribed [user:%d]", bob.ID); got != want {
+		t.Errorf("bob after unfollowing = %s, want %s", got, want)
+	}
+
+	// So does a block, whoever blocked
+	fresh := api.createPost(alice, gin.H{"content": "again"})
+	_, carolEvents = openStream(t, server, fmt.Sprintf("?access_token=%s&topics=post:%d", carol.token, fresh.ID))
+	nextEvent(t, carolEvents)
+	expectStatus(t, api.request(http.MethodPost, "/api/blocks/carol", nil, alice), http.StatusOK)
+	if got, want := subscription(nextEvent(t, carolEvents)), fmt.Sprintf("subscribed [user:%d]", carol.ID); got != want {
+		t.Errorf("carol after the block = %s, want %s", got, want)
+	}
+}
+
+func TestEventStreamPublishedDraft(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+	_, events := openStream(t, server, "?access_token="+bob.token)
+	nextEvent(t, events)
+
+	// The draft reaches bob's live feed only once it is published
+	draft := api.createPost(alice, gin.H{"content": "soon", "visibility": "private"})
+	path := fmt.Sprintf("/api/posts/%d", draft.ID)
+	expectStatus(t, api.request(http.MethodPut, path, gin.H{"content": "now", "visibility": "public"}, alice), http.StatusOK)
+	e := nextEvent(t, events)
+	var feedPost struct {
+		ID      uint   `json:"id"`
+		Content string `json:"content"`
+	}
+	if json.Unmarshal(e.Data, &feedPost); e.Type != realtime.FeedPostEvent || feedPost.ID != draft.ID || feedPost.Content != "now" {
+		t.Errorf("after publishing = %+v", e)
+	}
+}
+
+func TestEventStreamDropsModeratedPosts(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	admin := makeAdmin(t, api, "admin")
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	hidden := api.createPost(alice, gin.H{"content": "hidden"})
+	removed := api.createPost(alice, gin.H{"content": "removed"})
+	_, events := openStream(t, server, fmt.Sprintf("?access_token=%s&topics=post:%d,post:%d", bob.token, hidden.ID, removed.ID))
+	nextEvent(t, events)
+
+	// Hiding a post drops it for everyone watching but its author, and so
+	// does removing one
+	w := api.request(http.MethodPost, "/api/admin/actions", gin.H{"action": "hide", "targetType": "post", "targetId": hidden.ID}, admin)
+	expectStatus(t, w, http.StatusCreated)
+	if got, want := subscription(nextEvent(t, events)), fmt.Sprintf("subscribed [post:%d user:%d]", removed.ID, bob.ID); got != want {
+		t.Errorf("after the hide = %s, want %s", got, want)
+	}
+	w = api.request(http.MethodPost, "/api/admin/actions", gin.H{"action": "remove", "targetType": "post", "targetId": removed.ID}, admin)
+	expectStatus(t, w, http.StatusCreated)
+	if got, want := subscription(nextEvent(t, events)), fmt.Sprintf("subscribed [user:%d]", bob.ID); got != want {
+		t.Errorf("after the removal = %s, want %s", got, want)
+	}
+}
+
+func TestEventStreamDropsPostsOfPrivateUsers(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+	post := api.createPost(alice, gin.H{"content": "hello"})
+	query := "?access_token=%s&topics=post:%d"
+	_, bobEvents := openStream(t, server, fmt.Sprintf(query, bob.token, post.ID))
+	_, carolEvents := openStream(t, server, fmt.Sprintf(query, carol.token, post.ID))
+	nextEvent(t, bobEvents)
+	nextEvent(t, carolEvents)
+
+	// Going private drops the post for everyone watching but followers
+	expectStatus(t, api.request(http.MethodPut, "/api/users/alice", gin.H{"isPrivate": true}, alice), http.StatusOK)
+	if got, want := subscription(nextEvent(t, carolEvents)), fmt.Sprintf("subscribed [user:%d]", carol.ID); got != want {
+		t.Errorf("carol after alice went private = %s, want %s", got, want)
+	}
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, bob), http.StatusCreated)
+	if e := nextEvent(t, bobEvents); e.Type != realtime.LikeEvent {
+		t.Errorf("bob after alice went private = %+v, want the like", e)
+	}
+}
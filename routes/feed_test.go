@@ -0,0 +1,65 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+func feedIDs(t *testing.T, api *testAPI, user *testUser, query string) []uint {
+	t.Helper()
+
+	w := api.request(http.MethodGet, "/api/feed"+query, nil, user)
+	expectStatus(t, w, http.StatusOK)
+
+	var page listPage[models.Post]
+	decode(t, w, &page)
+	ids := make([]uint, 0, len(page.Data))
+	for _, post := range page.Data {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+func TestFeedShowsOwnAndFollowedPosts(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+
+	own := api.createPost(alice, gin.H{"content": "mine"})
+	fromBob := api.createPost(bob, gin.H{"content": "from bob"})
+	api.createPost(carol, gin.H{"content": "from a stranger"})
+	taggedByCarol := api.createPost(carol, gin.H{"content": "hey @alice", "tags": []string{"alice"}})
+
+	if got := feedIDs(t, api, alice, ""); fmt.Sprint(got) != fmt.Sprint([]uint{own.ID}) {
+		t.Errorf("feed before following = %v", got)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/bob", nil, alice), http.StatusCreated)
+
+	want := []uint{fromBob.ID, own.ID}
+	if got := feedIDs(t, api, alice, ""); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("feed = %v, want %v", got, want)
+	}
+
+	want = []uint{taggedByCarol.ID, fromBob.ID, own.ID}
+	if got := feedIDs(t, api, alice, "?includeTagged=true"); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("feed with tags = %v, want %v", got, want)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/bob", nil, alice), http.StatusOK)
+	if got := feedIDs(t, api, alice, ""); fmt.Sprint(got) != fmt.Sprint([]uint{own.ID}) {
+		t.Errorf("feed after unfollowing = %v", got)
+	}
+}
+
+func TestFeedRequiresAuth(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.request(http.MethodGet, "/api/feed", nil, nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+}
@@ -0,0 +1,67 @@
+package routes_test
+
+import (
+	"net/http"
+	"testing"
+
+	"sinkedin/models"
+)
+
+func TestToggleFollow(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	api.registerUser("bob")
+
+	w := api.request(http.MethodPost, "/api/follow/bob", nil, alice)
+	expectStatus(t, w, http.StatusCreated)
+
+	if got := api.getUser("bob"); got.FollowersCount != 1 {
+		t.Errorf("bob followersCount = %d, want 1", got.FollowersCount)
+	}
+	if got := api.getUser("alice"); got.FollowingCount != 1 {
+		t.Errorf("alice followingCount = %d, want 1", got.FollowingCount)
+	}
+
+	w = api.request(http.MethodGet, "/api/follow/followers/bob", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var followers listPage[models.User]
+	decode(t, w, &followers)
+	if len(followers.Data) != 1 || followers.Data[0].Username != "alice" {
+		t.Errorf("followers = %+v", followers.Data)
+	}
+
+	w = api.request(http.MethodGet, "/api/follow/following/alice", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var following listPage[models.User]
+	decode(t, w, &following)
+	if len(following.Data) != 1 || following.Data[0].Username != "bob" {
+		t.Errorf("following = %+v", following.Data)
+	}
+
+	w = api.request(http.MethodPost, "/api/follow/bob", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	if got := api.getUser("bob"); got.FollowersCount != 0 {
+		t.Errorf("bob followersCount = %d after unfollow, want 0", got.FollowersCount)
+	}
+	if got := api.getUser("alice"); got.FollowingCount != 0 {
+		t.Errorf("alice followingCount = %d after unfollow, want 0", got.FollowingCount)
+	}
+
+	// Following again after an unfollow must work
+	w = api.request(http.MethodPost, "/api/follow/bob", nil, alice)
+	expectStatus(t, w, http.StatusCreated)
+}
+
+func TestFollowRejectsSelfAndUnknown(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	w := api.request(http.MethodPost, "/api/follow/alice", nil, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = api.request(http.MethodPost, "/api/follow/nobody", nil, alice)
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = api.request(http.MethodGet, "/api/follow/followers/nobody", nil, alice)
+	expectStatus(t, w, http.StatusNotFound)
+}
@@ -0,0 +1,218 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+func TestHashtagPosts(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	tagged := api.createPost(alice, gin.H{"content": "Hello #go", "hashtags": []string{"go"}})
+	api.createPost(alice, gin.H{"content": "No hashtags here"})
+
+	w := api.request(http.MethodGet, "/api/hashtags/go/posts", nil, nil)
+	expectStatus(t, w, http.StatusOK)
+	var posts listPage[models.Post]
+	decode(t, w, &posts)
+	if len(posts.Data) != 1 || posts.Data[0].ID != tagged.ID {
+		t.Errorf("hashtag posts = %+v", posts.Data)
+	}
+
+	w = api.request(http.MethodGet, "/api/hashtags/unknown/posts", nil, nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestTrendingHashtags(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	api.createPost(alice, gin.H{"content": "one", "hashtags": []string{"go", "rust"}})
+	api.createPost(alice, gin.H{"content": "two", "hashtags": []string{"go"}})
+
+	w := api.request(http.MethodGet, "/api/hashtags/trending", nil, nil)
+	expectStatus(t, w, http.StatusOK)
+	var hashtags []models.Hashtag
+	decode(t, w, &hashtags)
+	if len(hashtags) != 2 || hashtags[0].Name != "go" || hashtags[0].Counter != 2 || hashtags[1].Counter != 1 {
+		t.Errorf("trending = %+v", hashtags)
+	}
+}
+
+func TestTrendingWindowsAndCounters(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	post := api.createPost(alice, gin.H{"content": "#go and #rust"})
+	api.createComment(alice, gin.H{"postId": post.ID, "type": "normal", "content": "more #go"})
+
+	for _, window := range []string{"", "?window=1h", "?window=7d"} {
+		w := api.request(http.MethodGet, "/api/hashtags/trending"+window, nil, nil)
+		expectStatus(t, w, http.StatusOK)
+		var hashtags []struct {
+			models.Hashtag
+			Window string  `json:"window"`
+			Rank   int     `json:"rank"`
+			Score  float64 `json:"score"`
+			Uses   int     `json:"uses"`
+		}
+		decode(t, w, &hashtags)
+		if len(hashtags) != 2 || hashtags[0].Name != "go" || hashtags[0].Uses != 2 || hashtags[0].Rank != 1 ||
+			hashtags[0].Score <= hashtags[1].Score {
+			t.Errorf("trending%s = %+v", window, hashtags)
+		}
+		if window == "" && hashtags[0].Window != "24h" {
+			t.Errorf("default window = %q", hashtags[0].Window)
+		}
+	}
+
+	w := api.request(http.MethodGet, "/api/hashtags/trending?window=30d", nil, nil)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	// Comment hashtags count towards the counter, and deleting the post
+	// releases its uses while the comment's remain. The comment no longer
+	// trends, being on a post nobody can see
+	if counters := api.hashtagCounters(); counters["go"] != 2 || counters["rust"] != 1 {
+		t.Errorf("counters = %v", counters)
+	}
+	w = api.request(http.MethodDelete, fmt.Sprintf("/api/posts/%d", post.ID), nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	if counters := api.hashtagCounters(); len(counters) != 0 {
+		t.Errorf("trending after delete = %v", counters)
+	}
+	w = api.request(http.MethodGet, "/api/hashtags/go", nil, nil)
+	expectStatus(t, w, http.StatusOK)
+	var details struct {
+		models.Hashtag
+		CommentCount int `json:"commentCount"`
+	}
+	decode(t, w, &details)
+	if details.Counter != 1 || details.CommentCount != 0 {
+		t.Errorf("go after delete = %+v", details)
+	}
+}
+
+func TestHashtagDetails(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+
+	post := api.createPost(alice, gin.H{"content": "#go with #rust"})
+	api.createPost(alice, gin.H{"content": "#go and #rust again"})
+	api.createPost(bob, gin.H{"content": "#go on its own"})
+	api.createComment(bob, gin.H{"postId": post.ID, "type": "normal", "content": "#go #zig"})
+
+	w := api.request(http.MethodGet, "/api/hashtags/Go", nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	var details struct {
+		models.Hashtag
+		PostCount       int        `json:"postCount"`
+		CommentCount    int        `json:"commentCount"`
+		FirstUsedAt     *time.Time `json:"firstUsedAt"`
+		TopContributors []struct {
+			User models.User `json:"user"`
+			Uses int         `json:"uses"`
+		} `json:"topContributors"`
+		Related []struct {
+			models.Hashtag
+			Uses int `json:"uses"`
+		} `json:"related"`
+		FollowedByMe bool `json:"followedByMe"`
+	}
+	decode(t, w, &details)
+
+	if details.Name != "go" || details.PostCount != 3 || details.CommentCount != 1 || details.FirstUsedAt == nil {
+		t.Errorf("details = %+v", details)
+	}
+	if len(details.TopContributors) != 2 || details.TopContributors[0].User.Username != "alice" ||
+		details.TopContributors[0].Uses != 2 || details.TopContributors[1].Uses != 2 {
+		t.Errorf("top contributors = %+v", details.TopContributors)
+	}
+	if len(details.Related) != 2 || details.Related[0].Name != "rust" || details.Related[0].Uses != 2 ||
+		details.Related[1].Name != "zig" || details.Related[1].Uses != 1 {
+		t.Errorf("related = %+v", details.Related)
+	}
+	if details.FollowedByMe {
+		t.Error("followedByMe before following")
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/api/hashtags/unknown", nil, nil), http.StatusNotFound)
+}
+
+func TestHashtagContent(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	first := api.createPost(alice, gin.H{"content": "#go first"})
+	api.createPost(alice, gin.H{"content": "unrelated"})
+	comment := api.createComment(alice, gin.H{"postId": first.ID, "type": "normal", "content": "#go reply"})
+	second := api.createPost(alice, gin.H{"content": "#go second"})
+
+	type item struct {
+		Type    string          `json:"type"`
+		Post    *models.Post    `json:"post"`
+		Comment *models.Comment `json:"comment"`
+	}
+	w := api.request(http.MethodGet, "/api/hashtags/go/content?limit=2", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var page listPage[item]
+	decode(t, w, &page)
+	if len(page.Data) != 2 || page.NextCursor == nil ||
+		page.Data[0].Type != "post" || page.Data[0].Post.ID != second.ID ||
+		page.Data[1].Type != "comment" || page.Data[1].Comment.ID != comment.ID || page.Data[1].Comment.Viewer == nil {
+		t.Fatalf("first page = %+v", page)
+	}
+
+	w = api.request(http.MethodGet, "/api/hashtags/go/content?limit=2&cursor="+*page.NextCursor, nil, nil)
+	expectStatus(t, w, http.StatusOK)
+	page = listPage[item]{}
+	decode(t, w, &page)
+	if len(page.Data) != 1 || page.NextCursor != nil || page.Data[0].Post.ID != first.ID {
+		t.Errorf("second page = %+v", page)
+	}
+}
+
+func TestFollowHashtag(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+
+	tagged := api.createPost(bob, gin.H{"content": "#golang news"})
+	api.createPost(bob, gin.H{"content": "something else"})
+
+	expectStatus(t, api.request(http.MethodPost, "/api/hashtags/golang/follow", nil, nil), http.StatusUnauthorized)
+	expectStatus(t, api.request(http.MethodPost, "/api/hashtags/missing/follow", nil, alice), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodPost, "/api/hashtags/golang/follow", nil, alice), http.StatusCreated)
+
+	if ids := feedIDs(t, api, alice, ""); len(ids) != 1 || ids[0] != tagged.ID {
+		t.Errorf("feed = %v, want only post %d", ids, tagged.ID)
+	}
+
+	w := api.request(http.MethodGet, "/api/hashtags/followed", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var followed listPage[models.Hashtag]
+	decode(t, w, &followed)
+	if len(followed.Data) != 1 || followed.Data[0].Name != "golang" {
+		t.Errorf("followed = %+v", followed.Data)
+	}
+
+	w = api.request(http.MethodGet, "/api/hashtags/golang", nil, alice)
+	var details struct {
+		FollowedByMe bool `json:"followedByMe"`
+	}
+	decode(t, w, &details)
+	if !details.FollowedByMe {
+		t.Error("followedByMe = false after following")
+	}
+
+	expectStatus(t, api.request(http.MethodPost, "/api/hashtags/golang/follow", nil, alice), http.StatusOK)
+	if ids := feedIDs(t, api, alice, ""); len(ids) != 0 {
+		t.Errorf("feed after unfollowing = %v", ids)
+	}
+}
@@ -0,0 +1,79 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+func TestTogglePostLike(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "like me"})
+	path := fmt.Sprintf("/api/likes/post/%d", post.ID)
+
+	w := api.request(http.MethodPost, path, nil, bob)
+	expectStatus(t, w, http.StatusCreated)
+	if got := api.getPost(alice, post.ID); got.LikeCount != 1 {
+		t.Errorf("likeCount = %d after like, want 1", got.LikeCount)
+	}
+
+	w = api.request(http.MethodGet, path, nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var likes listPage[models.Like]
+	decode(t, w, &likes)
+	if len(likes.Data) != 1 || likes.Data[0].UserID != bob.ID || likes.Data[0].User.Username != "bob" || likes.Data[0].Type != models.PostLike {
+		t.Errorf("likes = %+v", likes.Data)
+	}
+
+	w = api.request(http.MethodPost, path, nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	if got := api.getPost(alice, post.ID); got.LikeCount != 0 {
+		t.Errorf("likeCount = %d after unlike, want 0", got.LikeCount)
+	}
+
+	// Liking again after an unlike must work
+	w = api.request(http.MethodPost, path, nil, bob)
+	expectStatus(t, w, http.StatusCreated)
+	if got := api.getPost(alice, post.ID); got.LikeCount != 1 {
+		t.Errorf("likeCount = %d after re-like, want 1", got.LikeCount)
+	}
+}
+
+func TestToggleCommentLike(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "post"})
+	comment := api.createComment(alice, gin.H{"postId": post.ID, "content": "comment", "type": "normal"})
+
+	w := api.request(http.MethodPost, fmt.Sprintf("/api/likes/comment/%d", comment.ID), nil, alice)
+	expectStatus(t, w, http.StatusCreated)
+
+	w = api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d", comment.ID), nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var got models.Comment
+	decode(t, w, &got)
+	if got.LikeCount != 1 {
+		t.Errorf("comment likeCount = %d, want 1", got.LikeCount)
+	}
+
+	// A comment like does not count towards the post
+	if p := api.getPost(alice, post.ID); p.LikeCount != 0 {
+		t.Errorf("post likeCount = %d, want 0", p.LikeCount)
+	}
+}
+
+func TestToggleLikeRejectsBadInput(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	w := api.request(http.MethodPost, "/api/likes/story/1", nil, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = api.request(http.MethodPost, "/api/likes/post/abc", nil, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+}
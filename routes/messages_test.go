@@ -0,0 +1,128 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+func (a *testAPI) startConversation(user *testUser, body gin.H, want int) models.Conversation {
+	a.t.Helper()
+	w := a.request(http.MethodPost, "/api/conversations", body, user)
+	expectStatus(a.t, w, want)
+	var conversation models.Conversation
+	decode(a.t, w, &conversation)
+	return conversation
+}
+
+func (a *testAPI) sendMessage(user *testUser, conversationID uint, content string) models.Message {
+	a.t.Helper()
+	w := a.request(http.MethodPost, fmt.Sprintf("/api/conversations/%d/messages", conversationID), gin.H{"content": content}, user)
+	expectStatus(a.t, w, http.StatusCreated)
+	var message models.Message
+	decode(a.t, w, &message)
+	return message
+}
+
+func conversationsOf(t *testing.T, api *testAPI, user *testUser) []models.Conversation {
+	t.Helper()
+	w := api.request(http.MethodGet, "/api/conversations", nil, user)
+	expectStatus(t, w, http.StatusOK)
+	var page listPage[models.Conversation]
+	decode(t, w, &page)
+	return page.Data
+}
+
+func TestConversations(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+
+	expectStatus(t, api.request(http.MethodPost, "/api/conversations", gin.H{"usernames": []string{"nobody"}}, alice), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodPost, "/api/conversations", gin.H{"usernames": []string{"alice"}}, alice), http.StatusBadRequest)
+
+	// A one-to-one conversation is found again from either side
+	direct := api.startConversation(alice, gin.H{"usernames": []string{"bob"}}, http.StatusCreated)
+	if direct.IsGroup || len(direct.Members) != 2 {
+		t.Fatalf("direct conversation = %+v", direct)
+	}
+	if again := api.startConversation(bob, gin.H{"usernames": []string{"alice"}}, http.StatusOK); again.ID != direct.ID {
+		t.Errorf("starting again gave conversation %d, want %d", again.ID, direct.ID)
+	}
+	group := api.startConversation(alice, gin.H{"usernames": []string{"bob", "carol"}, "title": "Lunch"}, http.StatusCreated)
+	if !group.IsGroup || group.Title != "Lunch" || len(group.Members) != 3 {
+		t.Fatalf("group conversation = %+v", group)
+	}
+
+	// Non-members cannot see or post to the conversation
+	path := fmt.Sprintf("/api/conversations/%d", direct.ID)
+	expectStatus(t, api.request(http.MethodGet, path, nil, carol), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodGet, path+"/messages", nil, carol), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodPost, path+"/messages", gin.H{"content": "hi"}, carol), http.StatusNotFound)
+
+	first := api.sendMessage(alice, direct.ID, "hi bob")
+	api.sendMessage(bob, group.ID, "lunch?")
+	second := api.sendMessage(alice, direct.ID, "are you there?")
+
+	// Conversations are listed by latest activity with unread counts
+	got := conversationsOf(t, api, bob)
+	if len(got) != 2 || got[0].ID != direct.ID || got[1].ID != group.ID {
+		t.Fatalf("bob's conversations = %+v", got)
+	}
+	if got[0].UnreadCount != 2 || got[0].LastMessage == nil || got[0].LastMessage.ID != second.ID {
+		t.Errorf("direct conversation summary = %+v", got[0])
+	}
+	if got[1].UnreadCount != 0 {
+		t.Errorf("bob has %d unread in his own group message", got[1].UnreadCount)
+	}
+
+	// Messages page newest first
+	w := api.request(http.MethodGet, path+"/messages?limit=1", nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	var messages listPage[models.Message]
+	decode(t, w, &messages)
+	if len(messages.Data) != 1 || messages.Data[0].ID != second.ID || messages.NextCursor == nil {
+		t.Fatalf("first page = %+v", messages)
+	}
+	w = api.request(http.MethodGet, path+"/messages?limit=1&cursor="+*messages.NextCursor, nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &messages)
+	if len(messages.Data) != 1 || messages.Data[0].ID != first.ID {
+		t.Fatalf("second page = %+v", messages)
+	}
+
+	// Read markers only move forwards
+	expectStatus(t, api.request(http.MethodPost, path+"/read", gin.H{"messageId": first.ID}, bob), http.StatusOK)
+	if got := conversationsOf(t, api, bob); got[0].UnreadCount != 1 {
+		t.Errorf("unread after reading the first message = %d", got[0].UnreadCount)
+	}
+	expectStatus(t, api.request(http.MethodPost, path+"/read", nil, bob), http.StatusOK)
+	w = api.request(http.MethodPost, path+"/read", gin.H{"messageId": first.ID}, bob)
+	expectStatus(t, w, http.StatusOK)
+	var member models.ConversationMember
+	decode(t, w, &member)
+	if member.LastReadMessageID != second.ID {
+		t.Errorf("read marker = %d, want %d", member.LastReadMessageID, second.ID)
+	}
+
+	// Only the sender may edit or delete a message
+	messagePath := fmt.Sprintf("%s/messages/%d", path, first.ID)
+	expectStatus(t, api.request(http.MethodPut, messagePath, gin.H{"content": "hijacked"}, bob), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodDelete, messagePath, nil, bob), http.StatusForbidden)
+	w = api.request(http.MethodPut, messagePath, gin.H{"content": "hi bob!"}, alice)
+	expectStatus(t, w, http.StatusOK)
+	var edited models.Message
+	decode(t, w, &edited)
+	if edited.Content != "hi bob!" || edited.EditedAt == nil {
+		t.Errorf("edited message = %+v", edited)
+	}
+	expectStatus(t, api.request(http.MethodDelete, fmt.Sprintf("%s/messages/%d", path, second.ID), nil, alice), http.StatusOK)
+	if got := conversationsOf(t, api, bob); got[0].LastMessage == nil || got[0].LastMessage.ID != first.ID {
+		t.Errorf("last message after deleting the latest = %+v", got[0].LastMessage)
+	}
+	expectStatus(t, api.request(http.MethodPut, fmt.Sprintf("%s/messages/%d", path, second.ID), gin.H{"content": "x"}, alice), http.StatusNotFound)
+}
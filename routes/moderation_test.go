@@ -0,0 +1,249 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+// report files a report and returns it.
+func report(t *testing.T, api *testAPI, user *testUser, targetType string, targetID uint, reason string) models.Report {
+	t.Helper()
+	w := api.request(http.MethodPost, "/api/reports", gin.H{"targetType": targetType, "targetId": targetID, "reason": reason}, user)
+	expectStatus(t, w, http.StatusCreated)
+	var r models.Report
+	decode(t, w, &r)
+	return r
+}
+
+// makeAdmin registers a user with the admin role.
+func makeAdmin(t *testing.T, api *testAPI, username string) *testUser {
+	t.Helper()
+	user := api.registerUser(username)
+	if err := api.store.Moderation.SetRole(user.ID, models.AdminRole); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func reportStatus(t *testing.T, api *testAPI, admin *testUser, id uint) (models.Report, []models.ModerationAction) {
+	t.Helper()
+	w := api.request(http.MethodGet, fmt.Sprintf("/api/admin/reports/%d", id), nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	var body struct {
+		Report  models.Report             `json:"report"`
+		Actions []models.ModerationAction `json:"actions"`
+	}
+	decode(t, w, &body)
+	return body.Report, body.Actions
+}
+
+func TestReport(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "buy now"})
+	private := api.createPost(alice, gin.H{"content": "just me", "visibility": "private"})
+
+	for _, tc := range []struct {
+		body gin.H
+		want int
+	}{
+		{gin.H{"targetType": "post", "targetId": post.ID, "reason": "bogus"}, http.StatusBadRequest},
+		{gin.H{"targetType": "hashtag", "targetId": post.ID, "reason": "spam"}, http.StatusBadRequest},
+		{gin.H{"targetType": "post", "targetId": 999, "reason": "spam"}, http.StatusNotFound},
+		{gin.H{"targetType": "post", "targetId": private.ID, "reason": "spam"}, http.StatusNotFound},
+		{gin.H{"targetType": "user", "targetId": bob.ID, "reason": "spam"}, http.StatusBadRequest},
+	} {
+		if w := api.request(http.MethodPost, "/api/reports", tc.body, bob); w.Code != tc.want {
+			t.Errorf("report %v = %d, want %d", tc.body, w.Code, tc.want)
+		}
+	}
+
+	r := report(t, api, bob, "post", post.ID, "spam")
+	if r.Status != models.ReportOpen || r.Reporter.Username != "bob" {
+		t.Errorf("report = %+v", r)
+	}
+	w := api.request(http.MethodPost, "/api/reports", gin.H{"targetType": "post", "targetId": post.ID, "reason": "hate"}, bob)
+	expectStatus(t, w, http.StatusConflict)
+	report(t, api, bob, "user", alice.ID, "harassment")
+
+	// Reporters list only their own reports, and only admins see the queue
+	if got := listIDs(t, api, bob, "/api/reports"); len(got) != 2 {
+		t.Errorf("bob's reports = %v", got)
+	}
+	if got := listIDs(t, api, alice, "/api/reports"); len(got) != 0 {
+		t.Errorf("alice's reports = %v", got)
+	}
+	w = api.request(http.MethodGet, "/api/admin/reports", nil, bob)
+	expectStatus(t, w, http.StatusForbidden)
+	if msg := errorMessage(t, w); msg != "Admin access required" {
+		t.Errorf("error = %q", msg)
+	}
+	expectStatus(t, api.request(http.MethodGet, "/api/admin/reports", nil, nil), http.StatusUnauthorized)
+}
+
+func TestModerationQueue(t *testing.T) {
+	api := newTestAPI(t)
+	admin := makeAdmin(t, api, "admin")
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+	post := api.createPost(alice, gin.H{"content": "buy now"})
+	comment := api.createComment(alice, gin.H{"postId": post.ID, "type": "normal", "content": "cheap pills"})
+
+	bobReport := report(t, api, bob, "post", post.ID, "spam")
+	carolReport := report(t, api, carol, "post", post.ID, "spam")
+	commentReport := report(t, api, bob, "comment", comment.ID, "spam")
+	userReport := report(t, api, carol, "user", alice.ID, "harassment")
+
+	// Triage moves open reports along, once
+	triage := fmt.Sprintf("/api/admin/reports/%d/triage", commentReport.ID)
+	expectStatus(t, api.request(http.MethodPost, triage, nil, admin), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, triage, nil, admin), http.StatusConflict)
+	if got := listIDs(t, api, admin, "/api/admin/reports?status=triaged"); fmt.Sprint(got) != fmt.Sprint([]uint{commentReport.ID}) {
+		t.Errorf("triaged reports = %v", got)
+	}
+	expectStatus(t, api.request(http.MethodGet, "/api/admin/reports?status=closed", nil, admin), http.StatusBadRequest)
+
+	// Hiding the post resolves every report on it and tells both reporters
+	path := fmt.Sprintf("/api/admin/reports/%d/actions", bobReport.ID)
+	expectStatus(t, api.request(http.MethodPost, path, gin.H{"action": "suspend", "days": -1}, admin), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodPost, path, gin.H{"action": "hide", "note": "spam"}, admin), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodPost, path, gin.H{"action": "remove"}, admin), http.StatusConflict)
+	for _, id := range []uint{bobReport.ID, carolReport.ID} {
+		if r, _ := reportStatus(t, api, admin, id); r.Status != models.ReportActioned || r.ResolvedAt == nil {
+			t.Errorf("report %d after hiding = %+v", id, r)
+		}
+	}
+	for _, user := range []*testUser{bob, carol} {
+		notices := notificationsOf(t, api, user, "")
+		if len(notices.Data) != 1 || notices.Data[0].Type != string(models.ReportActionedNotification) || notices.Data[0].ActorCount != 1 {
+			t.Errorf("%s's notifications = %+v", user.Username, notices.Data)
+		}
+	}
+	w := api.request(http.MethodGet, "/api/notifications", nil, bob)
+	var raw listPage[models.Notification]
+	decode(t, w, &raw)
+	if len(raw.Data) != 1 || len(raw.Data[0].Actors) != 0 {
+		t.Errorf("bob's notification names the moderator: %+v", raw.Data)
+	}
+
+	// Hidden posts, and their comments, are gone for everyone but the author
+	for _, path := range []string{fmt.Sprintf("/api/posts/%d", post.ID), fmt.Sprintf("/api/comments/%d", comment.ID)} {
+		expectStatus(t, api.request(http.MethodGet, path, nil, bob), http.StatusNotFound)
+		expectStatus(t, api.request(http.MethodGet, path, nil, alice), http.StatusOK)
+	}
+	if got := listIDs(t, api, bob, "/api/posts/"); slices.Contains(got, post.ID) {
+		t.Errorf("bob lists the hidden post: %v", got)
+	}
+	w = api.request(http.MethodPost, "/api/admin/actions", gin.H{"action": "unhide", "targetType": "post", "targetId": post.ID}, admin)
+	expectStatus(t, w, http.StatusCreated)
+	expectStatus(t, api.request(http.MethodGet, fmt.Sprintf("/api/posts/%d", post.ID), nil, bob), http.StatusOK)
+
+	// Removing the comment deletes it; actions must fit their target
+	path = fmt.Sprintf("/api/admin/reports/%d/actions", userReport.ID)
+	expectStatus(t, api.request(http.MethodPost, path, gin.H{"action": "hide"}, admin), http.StatusBadRequest)
+	path = fmt.Sprintf("/api/admin/reports/%d/actions", commentReport.ID)
+	expectStatus(t, api.request(http.MethodPost, path, gin.H{"action": "remove"}, admin), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d", comment.ID), nil, alice), http.StatusNotFound)
+
+	// Dismissing closes the report without touching the target
+	dismissed := report(t, api, bob, "post", post.ID, "other")
+	path = fmt.Sprintf("/api/admin/reports/%d/dismiss", dismissed.ID)
+	expectStatus(t, api.request(http.MethodPost, path, gin.H{"note": "not spam"}, admin), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, path, nil, admin), http.StatusConflict)
+	notices := notificationsOf(t, api, bob, "")
+	if len(notices.Data) != 3 || notices.Data[0].Type != string(models.ReportDismissedNotification) {
+		t.Errorf("bob's notifications after dismissal = %+v", notices.Data)
+	} else if notices.Data[0].Summary != "Moderators reviewed your report and found no violation" {
+		t.Errorf("summary = %q", notices.Data[0].Summary)
+	}
+
+	// Warnings reach the author
+	path = fmt.Sprintf("/api/admin/reports/%d/actions", userReport.ID)
+	expectStatus(t, api.request(http.MethodPost, path, gin.H{"action": "warn"}, admin), http.StatusCreated)
+	notices = notificationsOf(t, api, alice, "")
+	if len(notices.Data) == 0 || notices.Data[0].Summary != "Moderators warned you about your account" {
+		t.Errorf("alice's notifications = %+v", notices.Data)
+	}
+
+	// Every step is in the audit trail
+	_, actions := reportStatus(t, api, admin, commentReport.ID)
+	if len(actions) != 2 || actions[0].Action != models.RemoveAction || actions[1].Action != models.TriageAction || actions[0].Moderator.Username != "admin" {
+		t.Errorf("comment report actions = %+v", actions)
+	}
+	w = api.request(http.MethodGet, fmt.Sprintf("/api/admin/actions?targetType=post&targetId=%d", post.ID), nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	var trail listPage[models.ModerationAction]
+	decode(t, w, &trail)
+	var got []models.ModerationActionType
+	for _, action := range trail.Data {
+		got = append(got, action.Action)
+	}
+	want := []models.ModerationActionType{models.DismissAction, models.UnhideAction, models.HideAction}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("post's audit trail = %v, want %v", got, want)
+	}
+	expectStatus(t, api.request(http.MethodGet, "/api/admin/actions?targetType=post", nil, admin), http.StatusBadRequest)
+}
+
+func TestSuspension(t *testing.T) {
+	api := newTestAPI(t)
+	admin := makeAdmin(t, api, "admin")
+	alice := api.registerUser("alice")
+
+	w := api.request(http.MethodPost, "/api/admin/actions", gin.H{"action": "suspend", "targetType": "user", "targetId": alice.ID, "days": 3}, admin)
+	expectStatus(t, w, http.StatusCreated)
+	var action models.ModerationAction
+	decode(t, w, &action)
+	if action.Until == nil {
+		t.Error("suspension has no end")
+	}
+
+	// Suspension ends every session and blocks logging in again
+	expectStatus(t, api.request(http.MethodGet, "/api/feed", nil, alice), http.StatusUnauthorized)
+	if _, code := api.refresh(alice.refreshToken); code != http.StatusUnauthorized {
+		t.Errorf("refresh while suspended = %d", code)
+	}
+	login := gin.H{"email": alice.Email, "password": "password123"}
+	w = api.request(http.MethodPost, "/api/users/login", login, nil)
+	expectStatus(t, w, http.StatusForbidden)
+	if msg := errorMessage(t, w); msg != "Account suspended" {
+		t.Errorf("error = %q", msg)
+	}
+
+	w = api.request(http.MethodPost, "/api/admin/actions", gin.H{"action": "unsuspend", "targetType": "user", "targetId": alice.ID}, admin)
+	expectStatus(t, w, http.StatusCreated)
+	api.login(alice)
+	expectStatus(t, api.request(http.MethodPost, "/api/admin/actions", gin.H{"action": "warn", "targetType": "user", "targetId": 999}, admin), http.StatusNotFound)
+}
+
+func TestModerationStateIsPrivate(t *testing.T) {
+	api := newTestAPI(t)
+	admin := makeAdmin(t, api, "admin")
+	alice := api.registerUser("alice")
+	api.createPost(admin, gin.H{"content": "rules"})
+
+	for _, path := range []string{"/api/users/admin", "/api/posts/"} {
+		w := api.request(http.MethodGet, path, nil, alice)
+		expectStatus(t, w, http.StatusOK)
+		body := w.Body.String()
+		if strings.Contains(body, `"role"`) || strings.Contains(body, "suspendedUntil") {
+			t.Errorf("%s shows moderation state: %s", path, body)
+		}
+	}
+
+	w := api.request(http.MethodGet, "/api/users/admin", nil, admin)
+	expectStatus(t, w, http.StatusOK)
+	var raw map[string]interface{}
+	decode(t, w, &raw)
+	if raw["role"] != string(models.AdminRole) {
+		t.Errorf("own profile role = %v", raw["role"])
+	}
+}
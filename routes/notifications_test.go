@@ -0,0 +1,139 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type notificationView struct {
+	ID         uint    `json:"id"`
+	Type       string  `json:"type"`
+	TargetType string  `json:"targetType"`
+	TargetID   uint    `json:"targetId"`
+	ActorCount int     `json:"actorCount"`
+	Summary    string  `json:"summary"`
+	ReadAt     *string `json:"readAt"`
+}
+
+func notificationsOf(t *testing.T, api *testAPI, user *testUser, query string) listPage[notificationView] {
+	t.Helper()
+	w := api.request(http.MethodGet, "/api/notifications"+query, nil, user)
+	expectStatus(t, w, http.StatusOK)
+	var page listPage[notificationView]
+	decode(t, w, &page)
+	return page
+}
+
+func unreadCount(t *testing.T, api *testAPI, user *testUser) int {
+	t.Helper()
+	w := api.request(http.MethodGet, "/api/notifications/unread-count", nil, user)
+	expectStatus(t, w, http.StatusOK)
+	var body struct {
+		Count int `json:"count"`
+	}
+	decode(t, w, &body)
+	return body.Count
+}
+
+func TestNotifications(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+
+	expectStatus(t, api.request(http.MethodGet, "/api/notifications", nil, nil), http.StatusUnauthorized)
+
+	post := api.createPost(alice, gin.H{"content": "hello"})
+	for _, user := range []*testUser{alice, bob, carol} {
+		w := api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, user)
+		expectStatus(t, w, http.StatusCreated)
+	}
+	comment := api.createComment(bob, gin.H{"postId": post.ID, "type": "normal", "content": "nice"})
+	api.createComment(alice, gin.H{"postId": post.ID, "parentId": comment.ID, "type": "normal", "content": "thanks @carol"})
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+
+	got := notificationsOf(t, api, alice, "").Data
+	if len(got) != 3 {
+		t.Fatalf("alice's notifications = %+v", got)
+	}
+	if got[0].Type != "follow" || got[0].Summary != "bob started following you" {
+		t.Errorf("latest = %+v", got[0])
+	}
+	if got[1].Type != "comment" || got[1].TargetID != post.ID {
+		t.Errorf("comment notification = %+v", got[1])
+	}
+	if got[2].Type != "like" || got[2].ActorCount != 2 || got[2].Summary != "carol and bob liked your post" {
+		t.Errorf("like notification = %+v", got[2])
+	}
+
+	// The reply reaches bob and the mention reaches carol
+	if got := notificationsOf(t, api, bob, "").Data; len(got) != 1 || got[0].Summary != "alice replied to your comment" {
+		t.Errorf("bob's notifications = %+v", got)
+	}
+	if got := notificationsOf(t, api, carol, "").Data; len(got) != 1 || got[0].Summary != "alice mentioned you in a comment" {
+		t.Errorf("carol's notifications = %+v", got)
+	}
+
+	// Unfollowing withdraws the unread follow notification
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusOK)
+	if count := unreadCount(t, api, alice); count != 2 {
+		t.Errorf("unread count = %d, want 2", count)
+	}
+
+	page := notificationsOf(t, api, alice, "?limit=1")
+	if len(page.Data) != 1 || page.NextCursor == nil {
+		t.Fatalf("first page = %+v", page)
+	}
+	if rest := notificationsOf(t, api, alice, "?limit=1&cursor="+*page.NextCursor).Data; len(rest) != 1 || rest[0].ID == page.Data[0].ID {
+		t.Errorf("second page = %+v", rest)
+	}
+
+	// Marking is limited to the caller's own notifications
+	w := api.request(http.MethodPost, "/api/notifications/read", gin.H{"ids": []uint{page.Data[0].ID}}, bob)
+	expectStatus(t, w, http.StatusOK)
+	if count := unreadCount(t, api, alice); count != 2 {
+		t.Errorf("unread count after bob marked = %d, want 2", count)
+	}
+	expectStatus(t, api.request(http.MethodPost, "/api/notifications/read", gin.H{"ids": []uint{}}, alice), http.StatusBadRequest)
+	w = api.request(http.MethodPost, "/api/notifications/read", gin.H{"ids": []uint{page.Data[0].ID}}, alice)
+	expectStatus(t, w, http.StatusOK)
+	if got := notificationsOf(t, api, alice, "?unread=true").Data; len(got) != 1 || got[0].ID == page.Data[0].ID {
+		t.Errorf("unread after marking one = %+v", got)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, "/api/notifications/read-all", nil, alice), http.StatusOK)
+	if count := unreadCount(t, api, alice); count != 0 {
+		t.Errorf("unread count after marking all = %d", count)
+	}
+
+	// A like after reading starts a new notification
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, bob), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, bob), http.StatusCreated)
+	if got := notificationsOf(t, api, alice, "?unread=true").Data; len(got) != 1 || got[0].ActorCount != 1 {
+		t.Errorf("unread after reading = %+v", got)
+	}
+}
+
+func TestEditNotifiesNewlyTagged(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+
+	post := api.createPost(alice, gin.H{"content": "hi @bob"})
+	w := api.request(http.MethodPut, fmt.Sprintf("/api/posts/%d", post.ID), gin.H{"content": "hi @bob and @carol"}, alice)
+	expectStatus(t, w, http.StatusOK)
+
+	if got := notificationsOf(t, api, bob, "").Data; len(got) != 1 || got[0].Type != "mention" || got[0].TargetID != post.ID {
+		t.Errorf("bob's notifications = %+v", got)
+	}
+	if got := notificationsOf(t, api, carol, "").Data; len(got) != 1 || got[0].Type != "mention" {
+		t.Errorf("carol's notifications = %+v", got)
+	}
+	if got := notificationsOf(t, api, alice, "").Data; len(got) != 0 {
+		t.Errorf("alice's notifications = %+v", got)
+	}
+}
@@ -0,0 +1,140 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+func TestAnonymousReads(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "hello #go", "hashtags": []string{"go"}})
+	comment := api.createComment(alice, gin.H{"postId": post.ID, "content": "first", "type": "normal"})
+	api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, alice)
+
+	paths := []string{
+		"/api/posts/",
+		fmt.Sprintf("/api/posts/%d", post.ID),
+		fmt.Sprintf("/api/comments/post/%d", post.ID),
+		fmt.Sprintf("/api/comments/%d", comment.ID),
+		fmt.Sprintf("/api/likes/post/%d", post.ID),
+		"/api/hashtags/go/posts",
+	}
+	for _, path := range paths {
+		w := api.request(http.MethodGet, path, nil, nil)
+		expectStatus(t, w, http.StatusOK)
+	}
+
+	// Viewer state is only reported to authenticated readers
+	if got := api.getPost(nil, post.ID); got.Viewer != nil {
+		t.Errorf("anonymous viewer = %+v, want none", got.Viewer)
+	}
+
+	// Writes still need a token
+	w := api.request(http.MethodPost, "/api/posts/", gin.H{"content": "anon"}, nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+	w = api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestOptionalAuthRejectsInvalidToken(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "hello"})
+
+	forged := &testUser{token: "not-a-token"}
+	w := api.request(http.MethodGet, fmt.Sprintf("/api/posts/%d", post.ID), nil, forged)
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestLikedByMe(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	liked := api.createPost(alice, gin.H{"content": "liked"})
+	other := api.createPost(alice, gin.H{"content": "other"})
+	comment := api.createComment(alice, gin.H{"postId": liked.ID, "content": "comment", "type": "normal"})
+
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", liked.ID), nil, bob), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/comment/%d", comment.ID), nil, bob), http.StatusCreated)
+
+	w := api.request(http.MethodGet, "/api/posts/", nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	var posts listPage[models.Post]
+	decode(t, w, &posts)
+	for _, post := range posts.Data {
+		if post.Viewer == nil {
+			t.Fatalf("post %d has no viewer state", post.ID)
+		}
+		if want := post.ID == liked.ID; post.Viewer.LikedByMe != want {
+			t.Errorf("post %d likedByMe = %v, want %v", post.ID, post.Viewer.LikedByMe, want)
+		}
+	}
+
+	if got := api.getPost(alice, liked.ID); got.Viewer == nil || got.Viewer.LikedByMe {
+		t.Errorf("alice's viewer state = %+v, want not liked", got.Viewer)
+	}
+	if got := api.getPost(bob, other.ID); got.Viewer == nil || got.Viewer.LikedByMe {
+		t.Errorf("unliked post viewer state = %+v", got.Viewer)
+	}
+
+	w = api.request(http.MethodGet, fmt.Sprintf("/api/comments/post/%d", liked.ID), nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	var comments listPage[models.Comment]
+	decode(t, w, &comments)
+	if len(comments.Data) != 1 || comments.Data[0].Viewer == nil || !comments.Data[0].Viewer.LikedByMe {
+		t.Errorf("comments = %+v", comments.Data)
+	}
+}
+
+func TestViewerRelationships(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+
+	// bob follows alice, carol follows bob
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/bob", nil, carol), http.StatusCreated)
+
+	byAlice := api.createPost(alice, gin.H{"content": "from alice", "tags": []string{"bob"}})
+	byCarol := api.createPost(carol, gin.H{"content": "from carol", "hashtags": []string{"news"}})
+	byBob := api.createPost(bob, gin.H{"content": "from bob"})
+	comment := api.createComment(carol, gin.H{"postId": byAlice.ID, "content": "hi @bob", "type": "normal", "tags": []string{"bob"}})
+
+	want := map[uint]models.ViewerState{
+		byAlice.ID: {FollowingAuthor: true, TaggedMe: true},
+		byCarol.ID: {AuthorFollowsMe: true},
+		byBob.ID:   {},
+	}
+
+	w := api.request(http.MethodGet, "/api/posts/", nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	var posts listPage[models.Post]
+	decode(t, w, &posts)
+	for _, post := range posts.Data {
+		if post.Viewer == nil || *post.Viewer != want[post.ID] {
+			t.Errorf("post %d viewer = %+v, want %+v", post.ID, post.Viewer, want[post.ID])
+		}
+	}
+
+	w = api.request(http.MethodGet, "/api/hashtags/news/posts", nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	decode(t, w, &posts)
+	if len(posts.Data) != 1 || posts.Data[0].Viewer == nil || *posts.Data[0].Viewer != want[byCarol.ID] {
+		t.Errorf("hashtag posts = %+v", posts.Data)
+	}
+
+	w = api.request(http.MethodGet, fmt.Sprintf("/api/comments/post/%d", byAlice.ID), nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	var comments listPage[models.Comment]
+	decode(t, w, &comments)
+	wantComment := models.ViewerState{AuthorFollowsMe: true, TaggedMe: true}
+	if len(comments.Data) != 1 || comments.Data[0].ID != comment.ID || comments.Data[0].Viewer == nil || *comments.Data[0].Viewer != wantComment {
+		t.Errorf("comments = %+v", comments.Data)
+	}
+}
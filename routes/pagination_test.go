@@ -0,0 +1,114 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+// collectPages walks a listing with the given page size and returns the IDs
+// of every item in order along with the number of pages fetched.
+func collectPages[T any](t *testing.T, api *testAPI, user *testUser, path string, limit int, id func(T) uint) ([]uint, int) {
+	t.Helper()
+
+	var ids []uint
+	pages := 0
+	cursor := ""
+	for {
+		query := url.Values{"limit": {fmt.Sprint(limit)}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		w := api.request(http.MethodGet, path+"?"+query.Encode(), nil, user)
+		expectStatus(t, w, http.StatusOK)
+
+		var page listPage[T]
+		decode(t, w, &page)
+		pages++
+		if len(page.Data) > limit {
+			t.Fatalf("page %d has %d items, limit %d", pages, len(page.Data), limit)
+		}
+		for _, item := range page.Data {
+			ids = append(ids, id(item))
+		}
+		if page.NextCursor == nil {
+			return ids, pages
+		}
+		cursor = *page.NextCursor
+		if pages > 50 {
+			t.Fatal("pagination did not terminate")
+		}
+	}
+}
+
+func TestPostsPagination(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	var want []uint
+	for i := 0; i < 7; i++ {
+		post := api.createPost(alice, gin.H{"content": fmt.Sprintf("post %d", i), "hashtags": []string{"paged"}})
+		want = append([]uint{post.ID}, want...)
+	}
+
+	postID := func(p models.Post) uint { return p.ID }
+	for _, path := range []string{"/api/posts/", "/api/hashtags/paged/posts"} {
+		got, pages := collectPages(t, api, alice, path, 3, postID)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s ids = %v, want %v", path, got, want)
+		}
+		if pages != 3 {
+			t.Errorf("%s took %d pages, want 3", path, pages)
+		}
+	}
+}
+
+func TestCommentLikeAndFollowPagination(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "popular"})
+
+	var commentIDs, likerIDs, followerIDs []uint
+	for i := 0; i < 5; i++ {
+		user := api.registerUser(fmt.Sprintf("user%d", i))
+		comment := api.createComment(user, gin.H{"postId": post.ID, "content": "hi", "type": "normal"})
+		expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, user), http.StatusCreated)
+		expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, user), http.StatusCreated)
+
+		commentIDs = append([]uint{comment.ID}, commentIDs...)
+		likerIDs = append([]uint{user.ID}, likerIDs...)
+		followerIDs = append([]uint{user.ID}, followerIDs...)
+	}
+
+	comments, _ := collectPages(t, api, alice, fmt.Sprintf("/api/comments/post/%d", post.ID), 2,
+		func(c models.Comment) uint { return c.ID })
+	if fmt.Sprint(comments) != fmt.Sprint(commentIDs) {
+		t.Errorf("comment ids = %v, want %v", comments, commentIDs)
+	}
+
+	likers, _ := collectPages(t, api, alice, fmt.Sprintf("/api/likes/post/%d", post.ID), 2,
+		func(l models.Like) uint { return l.UserID })
+	if fmt.Sprint(likers) != fmt.Sprint(likerIDs) {
+		t.Errorf("liker ids = %v, want %v", likers, likerIDs)
+	}
+
+	followers, _ := collectPages(t, api, alice, "/api/follow/followers/alice", 2,
+		func(u models.User) uint { return u.ID })
+	if fmt.Sprint(followers) != fmt.Sprint(followerIDs) {
+		t.Errorf("follower ids = %v, want %v", followers, followerIDs)
+	}
+}
+
+func TestPaginationRejectsBadParameters(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	for _, query := range []string{"limit=0", "limit=abc", "cursor=not-a-cursor"} {
+		w := api.request(http.MethodGet, "/api/posts/?"+query, nil, alice)
+		expectStatus(t, w, http.StatusBadRequest)
+	}
+}
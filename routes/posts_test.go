@@ -0,0 +1,109 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+func TestCreatePost(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	api.registerUser("bob")
+
+	post := api.createPost(alice, gin.H{
+		"content":  "Hello world! #firstpost",
+		"hashtags": []string{"firstpost"},
+		"tags":     []string{"bob"},
+		"imageURL": "https://example.com/a.png",
+	})
+
+	if post.ID == 0 || post.UserID != alice.ID || post.User.Username != "alice" {
+		t.Errorf("post author = %d/%q", post.UserID, post.User.Username)
+	}
+	if !post.HasHashtag || !post.HasTag || !post.HasImage {
+		t.Errorf("flags hasHashtag=%v hasTag=%v hasImage=%v", post.HasHashtag, post.HasTag, post.HasImage)
+	}
+	if len(post.Hashtags) != 1 || post.Hashtags[0].Name != "firstpost" {
+		t.Errorf("hashtags = %+v", post.Hashtags)
+	}
+	if len(post.Tags) != 1 || post.Tags[0].Username != "bob" {
+		t.Errorf("tags = %+v", post.Tags)
+	}
+	if post.LikeCount != 0 || post.CommentCount != 0 {
+		t.Errorf("counters = %d likes, %d comments", post.LikeCount, post.CommentCount)
+	}
+}
+
+func TestCreatePostRejectsUnknownTag(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	w := api.request(http.MethodPost, "/api/posts/", gin.H{"content": "hi", "tags": []string{"nobody"}}, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = api.request(http.MethodPost, "/api/posts/", gin.H{"imageURL": "no content"}, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestListPostsNewestFirst(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	first := api.createPost(alice, gin.H{"content": "first"})
+	second := api.createPost(alice, gin.H{"content": "second"})
+
+	w := api.request(http.MethodGet, "/api/posts/", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+
+	var posts listPage[models.Post]
+	decode(t, w, &posts)
+	if len(posts.Data) != 2 || posts.Data[0].ID != second.ID || posts.Data[1].ID != first.ID {
+		t.Fatalf("posts = %+v", posts.Data)
+	}
+	if posts.NextCursor != nil {
+		t.Errorf("nextCursor = %q on the only page", *posts.NextCursor)
+	}
+}
+
+func TestUpdatePost(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "draft"})
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	w := api.request(http.MethodPut, path, gin.H{"content": "stolen"}, bob)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = api.request(http.MethodPut, path, gin.H{"content": "final", "isQuote": true, "quoteLines": "to be"}, alice)
+	expectStatus(t, w, http.StatusOK)
+
+	got := api.getPost(alice, post.ID)
+	if got.Content != "final" || !got.IsQuote || got.QuoteLines != "to be" {
+		t.Errorf("post after update = %+v", got)
+	}
+}
+
+func TestDeletePost(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	post := api.createPost(alice, gin.H{"content": "short lived"})
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	w := api.request(http.MethodDelete, path, nil, bob)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = api.request(http.MethodDelete, path, nil, alice)
+	expectStatus(t, w, http.StatusOK)
+
+	w = api.request(http.MethodGet, path, nil, alice)
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = api.request(http.MethodGet, "/api/posts/not-a-number", nil, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+}
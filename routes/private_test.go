@@ -0,0 +1,129 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+// requestFollow asks to follow a private user and returns the request.
+func requestFollow(t *testing.T, api *testAPI, user *testUser, username string) models.FollowRequest {
+	t.Helper()
+	w := api.request(http.MethodPost, "/api/follow/"+username, nil, user)
+	expectStatus(t, w, http.StatusAccepted)
+	var response struct {
+		Request models.FollowRequest `json:"request"`
+	}
+	decode(t, w, &response)
+	return response.Request
+}
+
+func TestPrivateAccount(t *testing.T) {
+	api := newTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+
+	w := api.request(http.MethodPut, "/api/users/alice", gin.H{"isPrivate": true}, alice)
+	expectStatus(t, w, http.StatusOK)
+	if !api.getUser("alice").IsPrivate {
+		t.Fatal("alice is not private after the update")
+	}
+	post := api.createPost(alice, gin.H{"content": "just for friends"})
+	comment := api.createComment(alice, gin.H{"postId": post.ID, "type": "normal", "content": "me too"})
+
+	// Only alice sees her content until she approves someone
+	outsider := []struct {
+		actor  *testUser
+		method string
+		path   string
+		body   gin.H
+		want   int
+	}{
+		{nil, http.MethodGet, fmt.Sprintf("/api/posts/%d", post.ID), nil, http.StatusNotFound},
+		{bob, http.MethodGet, fmt.Sprintf("/api/posts/%d", post.ID), nil, http.StatusNotFound},
+		{bob, http.MethodGet, fmt.Sprintf("/api/comments/post/%d", post.ID), nil, http.StatusNotFound},
+		{bob, http.MethodGet, fmt.Sprintf("/api/comments/%d", comment.ID), nil, http.StatusNotFound},
+		{bob, http.MethodGet, fmt.Sprintf("/api/comments/%d/thread", comment.ID), nil, http.StatusNotFound},
+		{bob, http.MethodPost, fmt.Sprintf("/api/likes/post/%d", post.ID), nil, http.StatusNotFound},
+		{bob, http.MethodPost, "/api/comments/", gin.H{"postId": post.ID, "type": "normal", "content": "hi"}, http.StatusNotFound},
+		{bob, http.MethodGet, "/api/follow/followers/alice", nil, http.StatusForbidden},
+		{bob, http.MethodGet, "/api/follow/following/alice", nil, http.StatusForbidden},
+		{alice, http.MethodGet, "/api/follow/followers/alice", nil, http.StatusOK},
+		{alice, http.MethodGet, fmt.Sprintf("/api/posts/%d", post.ID), nil, http.StatusOK},
+	}
+	for _, tc := range outsider {
+		if w := api.request(tc.method, tc.path, tc.body, tc.actor); w.Code != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+	if got := listIDs(t, api, bob, "/api/posts/"); slices.Contains(got, post.ID) {
+		t.Errorf("bob lists alice's private post: %v", got)
+	}
+	resp, _ := openStream(t, server, fmt.Sprintf("?access_token=%s&topics=post:%d", bob.token, post.ID))
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("bob subscribing to alice's post = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// Following asks for approval, and asking again withdraws the request
+	requestFollow(t, api, bob, "alice")
+	w = api.request(http.MethodPost, "/api/follow/alice", nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	bobRequest := requestFollow(t, api, bob, "alice")
+	carolRequest := requestFollow(t, api, carol, "alice")
+	if got := api.getUser("alice").FollowersCount; got != 0 {
+		t.Errorf("alice's followers before approval = %d", got)
+	}
+
+	w = api.request(http.MethodGet, "/api/follow/requests", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var pending listPage[models.FollowRequest]
+	decode(t, w, &pending)
+	if len(pending.Data) != 2 || pending.Data[0].Requester.Username != "carol" || pending.Data[1].Requester.Username != "bob" {
+		t.Errorf("alice's pending requests = %+v", pending.Data)
+	}
+
+	approve := fmt.Sprintf("/api/follow/requests/%d/approve", bobRequest.ID)
+	expectStatus(t, api.request(http.MethodPost, approve, nil, bob), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodPost, approve, nil, alice), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, approve, nil, alice), http.StatusConflict)
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/requests/999/approve", nil, alice), http.StatusNotFound)
+	reject := fmt.Sprintf("/api/follow/requests/%d/reject", carolRequest.ID)
+	expectStatus(t, api.request(http.MethodPost, reject, nil, alice), http.StatusOK)
+
+	// bob now sees everything, carol still nothing
+	if got := api.getUser("alice").FollowersCount; got != 1 {
+		t.Errorf("alice's followers after approval = %d", got)
+	}
+	expectStatus(t, api.request(http.MethodGet, fmt.Sprintf("/api/posts/%d", post.ID), nil, bob), http.StatusOK)
+	expectStatus(t, api.request(http.MethodGet, "/api/follow/followers/alice", nil, bob), http.StatusOK)
+	if got := feedIDs(t, api, bob, ""); !slices.Contains(got, post.ID) {
+		t.Errorf("bob's feed after approval = %v", got)
+	}
+	expectStatus(t, api.request(http.MethodGet, fmt.Sprintf("/api/posts/%d", post.ID), nil, carol), http.StatusNotFound)
+
+	w = api.request(http.MethodGet, "/api/notifications", nil, bob)
+	expectStatus(t, w, http.StatusOK)
+	var notices listPage[models.Notification]
+	decode(t, w, &notices)
+	if len(notices.Data) != 1 || notices.Data[0].Type != models.FollowAcceptedNotification {
+		t.Errorf("bob's notifications = %+v", notices.Data)
+	}
+
+	// Going public lets in whoever is still waiting
+	requestFollow(t, api, carol, "alice")
+	expectStatus(t, api.request(http.MethodPut, "/api/users/alice", gin.H{"isPrivate": false}, alice), http.StatusOK)
+	if got := api.getUser("alice").FollowersCount; got != 2 {
+		t.Errorf("alice's followers after going public = %d", got)
+	}
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, carol), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, carol), http.StatusCreated)
+}
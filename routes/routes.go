@@ -2,60 +2,189 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"sinkedin/auth"
 	"sinkedin/handlers"
+	"sinkedin/messaging"
 	"sinkedin/middleware"
+	"sinkedin/moderation"
+	"sinkedin/notifications"
+	"sinkedin/realtime"
+	"sinkedin/store"
+	"sinkedin/timeline"
+	"sinkedin/trending"
+	"sinkedin/typeahead"
 )
 
-func SetupRoutes(r *gin.Engine) {
+// Services bundles the long-lived dependencies the handlers are built from.
+type Services struct {
+	Store         *store.Store
+	Auth          *auth.Service
+	Timelines     *timeline.Service
+	Trending      *trending.Service
+	Typeahead     *typeahead.Service
+	Notifications *notifications.Service
+	Messaging     *messaging.Service
+	Moderation    *moderation.Service
+	Events        *realtime.Hub
+}
+
+func SetupRoutes(r *gin.Engine, svc Services) {
+	s := svc.Store
+	requireAuth := middleware.AuthMiddleware(svc.Auth)
+	optionalAuth := middleware.OptionalAuth(svc.Auth)
+	streamAuth := middleware.StreamAuth(svc.Auth)
+	requireAdmin := middleware.RequireAdmin(s.Users)
+
+	users := handlers.NewUserHandler(s, svc.Auth, svc.Timelines, svc.Notifications, svc.Events)
+	posts := handlers.NewPostHandler(s, svc.Timelines, svc.Notifications, svc.Events)
+	comments := handlers.NewCommentHandler(s, svc.Notifications, svc.Events)
+	likes := handlers.NewLikeHandler(s, svc.Notifications, svc.Events)
+	follows := handlers.NewFollowHandler(s, svc.Timelines, svc.Notifications)
+	blocks := handlers.NewBlockHandler(s, svc.Timelines, svc.Notifications, svc.Events)
+	hashtags := handlers.NewHashtagHandler(s, svc.Trending)
+	feed := handlers.NewFeedHandler(s, svc.Timelines)
+	search := handlers.NewSearchHandler(s)
+	suggestions := handlers.NewTypeaheadHandler(svc.Typeahead)
+	notices := handlers.NewNotificationHandler(svc.Notifications)
+	messages := handlers.NewMessageHandler(s, svc.Messaging)
+	events := handlers.NewEventsHandler(s, svc.Events, svc.Timelines)
+	reports := handlers.NewReportHandler(s)
+	admin := handlers.NewAdminHandler(s, svc.Moderation)
+
 	// User routes
 	userRoutes := r.Group("/api/users")
 	{
-		userRoutes.POST("/register", handlers.RegisterUser)
-		userRoutes.POST("/login", handlers.LoginUser)
-		userRoutes.GET("/:username", handlers.GetUserProfile)
-		userRoutes.PUT("/:username", middleware.AuthMiddleware(), handlers.UpdateUserProfile)
-		userRoutes.DELETE("/:username", middleware.AuthMiddleware(), handlers.DeleteUser)
+		userRoutes.POST("/register", users.RegisterUser)
+		userRoutes.POST("/login", users.LoginUser)
+		userRoutes.POST("/refresh", users.RefreshToken)
+		userRoutes.POST("/logout", requireAuth, users.Logout)
+		userRoutes.POST("/logout-all", requireAuth, users.LogoutAll)
+		userRoutes.GET("/:username", optionalAuth, users.GetUserProfile)
+		userRoutes.PUT("/:username", requireAuth, users.UpdateUserProfile)
+		userRoutes.DELETE("/:username", requireAuth, users.DeleteUser)
+	}
+
+	// Post routes; reads are public
+	postRoutes := r.Group("/api/posts")
+	{
+		postRoutes.POST("/", requireAuth, posts.CreatePost)
+		postRoutes.GET("/", optionalAuth, posts.GetPosts)
+		postRoutes.GET("/:id", optionalAuth, posts.GetPost)
+		postRoutes.PUT("/:id", requireAuth, posts.UpdatePost)
+		postRoutes.DELETE("/:id", requireAuth, posts.DeletePost)
 	}
 
-	// Post routes
-	postRoutes := r.Group("/api/posts", middleware.AuthMiddleware())
+	// Feed routes
+	feedRoutes := r.Group("/api/feed", requireAuth)
 	{
-		postRoutes.POST("/", handlers.CreatePost)
-		postRoutes.GET("/", handlers.GetPosts)
-		postRoutes.GET("/:id", handlers.GetPost)
-		postRoutes.PUT("/:id", handlers.UpdatePost)
-		postRoutes.DELETE("/:id", handlers.DeletePost)
+		feedRoutes.GET("", feed.GetFeed)
 	}
 
-	// Comment routes
-	commentRoutes := r.Group("/api/comments", middleware.AuthMiddleware())
+	// Comment routes; reads are public
+	commentRoutes := r.Group("/api/comments")
 	{
-		commentRoutes.POST("/", handlers.CreateComment)
-		commentRoutes.GET("/post/:postId", handlers.GetPostComments)
-		commentRoutes.GET("/:id", handlers.GetComment)
-		commentRoutes.PUT("/:id", handlers.UpdateComment)
-		commentRoutes.DELETE("/:id", handlers.DeleteComment)
+		commentRoutes.POST("/", requireAuth, comments.CreateComment)
+		commentRoutes.GET("/post/:postId", optionalAuth, comments.GetPostComments)
+		commentRoutes.GET("/:id", optionalAuth, comments.GetComment)
+		commentRoutes.GET("/:id/replies", optionalAuth, comments.GetCommentReplies)
+		commentRoutes.GET("/:id/thread", optionalAuth, comments.GetCommentThread)
+		commentRoutes.PUT("/:id", requireAuth, comments.UpdateComment)
+		commentRoutes.DELETE("/:id", requireAuth, comments.DeleteComment)
 	}
 
-	// Like routes
-	likeRoutes := r.Group("/api/likes", middleware.AuthMiddleware())
+	// Like routes; reads are public
+	likeRoutes := r.Group("/api/likes")
 	{
-		likeRoutes.POST("/:type/:id", handlers.ToggleLike)
-		likeRoutes.GET("/:type/:id", handlers.GetLikes)
+		likeRoutes.POST("/:type/:id", requireAuth, likes.ToggleLike)
+		likeRoutes.GET("/:type/:id", optionalAuth, likes.GetLikes)
 	}
 
 	// Follow routes
-	followRoutes := r.Group("/api/follow", middleware.AuthMiddleware())
+	followRoutes := r.Group("/api/follow", requireAuth)
 	{
-		followRoutes.POST("/:username", handlers.ToggleFollow)
-		followRoutes.GET("/followers/:username", handlers.GetFollowers)
-		followRoutes.GET("/following/:username", handlers.GetFollowing)
+		followRoutes.GET("/requests", follows.GetFollowRequests)
+		followRoutes.POST("/requests/:id/approve", follows.ApproveFollowRequest)
+		followRoutes.POST("/requests/:id/reject", follows.RejectFollowRequest)
+		followRoutes.POST("/:username", follows.ToggleFollow)
+		followRoutes.GET("/followers/:username", follows.GetFollowers)
+		followRoutes.GET("/following/:username", follows.GetFollowing)
+	}
+
+	// Block and mute routes
+	blockRoutes := r.Group("/api/blocks", requireAuth)
+	{
+		blockRoutes.GET("", blocks.GetBlocked)
+		blockRoutes.POST("/:username", blocks.Block)
+		blockRoutes.DELETE("/:username", blocks.Unblock)
+	}
+	muteRoutes := r.Group("/api/mutes", requireAuth)
+	{
+		muteRoutes.GET("", blocks.GetMuted)
+		muteRoutes.POST("/:username", blocks.Mute)
+		muteRoutes.DELETE("/:username", blocks.Unmute)
 	}
 
 	// Hashtag routes
-	hashtagRoutes := r.Group("/api/hashtags")
+	hashtagRoutes := r.Group("/api/hashtags", optionalAuth)
+	{
+		hashtagRoutes.GET("/trending", hashtags.GetTrendingHashtags)
+		hashtagRoutes.GET("/followed", requireAuth, hashtags.GetFollowedHashtags)
+		hashtagRoutes.GET("/:name", hashtags.GetHashtag)
+		hashtagRoutes.GET("/:name/posts", hashtags.GetHashtagPosts)
+		hashtagRoutes.GET("/:name/content", hashtags.GetHashtagContent)
+		hashtagRoutes.POST("/:name/follow", requireAuth, hashtags.ToggleFollowHashtag)
+	}
+
+	// Notification routes
+	notificationRoutes := r.Group("/api/notifications", requireAuth)
+	{
+		notificationRoutes.GET("", notices.GetNotifications)
+		notificationRoutes.GET("/unread-count", notices.GetUnreadCount)
+		notificationRoutes.POST("/read", notices.MarkRead)
+		notificationRoutes.POST("/read-all", notices.MarkAllRead)
+	}
+
+	// Direct message routes; conversations are only visible to their members
+	conversationRoutes := r.Group("/api/conversations", requireAuth)
+	{
+		conversationRoutes.POST("", messages.StartConversation)
+		conversationRoutes.GET("", messages.GetConversations)
+		conversationRoutes.GET("/:id", messages.GetConversation)
+		conversationRoutes.GET("/:id/messages", messages.GetMessages)
+		conversationRoutes.POST("/:id/messages", messages.SendMessage)
+		conversationRoutes.PUT("/:id/messages/:messageId", messages.UpdateMessage)
+		conversationRoutes.DELETE("/:id/messages/:messageId", messages.DeleteMessage)
+		conversationRoutes.POST("/:id/read", messages.MarkRead)
+	}
+
+	// Report routes; reporters only see their own reports
+	reportRoutes := r.Group("/api/reports", requireAuth)
 	{
-		hashtagRoutes.GET("/trending", handlers.GetTrendingHashtags)
-		hashtagRoutes.GET("/:name/posts", handlers.GetHashtagPosts)
+		reportRoutes.POST("", reports.CreateReport)
+		reportRoutes.GET("", reports.GetReports)
 	}
+
+	// Moderation routes, for admins only
+	adminRoutes := r.Group("/api/admin", requireAuth, requireAdmin)
+	{
+		adminRoutes.GET("/reports", admin.GetReports)
+		adminRoutes.GET("/reports/:id", admin.GetReport)
+		adminRoutes.POST("/reports/:id/triage", admin.TriageReport)
+		adminRoutes.POST("/reports/:id/dismiss", admin.DismissReport)
+		adminRoutes.POST("/reports/:id/actions", admin.ActOnReport)
+		adminRoutes.POST("/actions", admin.Act)
+		adminRoutes.GET("/actions", admin.GetActions)
+	}
+
+	// Event stream routes
+	eventRoutes := r.Group("/api/events")
+	{
+		eventRoutes.GET("", streamAuth, events.Stream)
+		eventRoutes.GET("/ws", streamAuth, events.WebSocket)
+		eventRoutes.POST("/:connection/subscriptions", requireAuth, events.UpdateSubscriptions)
+	}
+
+	// Search routes
+	r.GET("/api/search", optionalAuth, search.Search)
+	r.GET("/api/typeahead", optionalAuth, suggestions.Suggest)
 }
@@ -0,0 +1,153 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+type searchHit[T any] struct {
+	Item    T       `json:"item"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+func (a *testAPI) search(user *testUser, query url.Values) listPage[searchHit[models.Post]] {
+	a.t.Helper()
+
+	w := a.request(http.MethodGet, "/api/search?"+query.Encode(), nil, user)
+	expectStatus(a.t, w, http.StatusOK)
+	var page listPage[searchHit[models.Post]]
+	decode(a.t, w, &page)
+	return page
+}
+
+func hitIDs(page listPage[searchHit[models.Post]]) []uint {
+	ids := make([]uint, 0, len(page.Data))
+	for _, hit := range page.Data {
+		ids = append(ids, hit.Item.ID)
+	}
+	return ids
+}
+
+func TestSearchSections(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	gopher := api.registerUser("gopher")
+
+	post := api.createPost(alice, gin.H{"content": "Learning golang <fast> with friends"})
+	api.createComment(alice, gin.H{"postId": post.ID, "type": "normal", "content": "golang is fun"})
+	api.createPost(alice, gin.H{"content": "#golang tips"})
+	api.createPost(alice, gin.H{"content": "nothing to see"})
+	bio := "Writes golang all day"
+	expectStatus(t, api.request(http.MethodPut, "/api/users/gopher", gin.H{"bio": bio}, gopher), http.StatusOK)
+
+	w := api.request(http.MethodGet, "/api/search?q=golang", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var all struct {
+		Posts    listPage[searchHit[models.Post]]    `json:"posts"`
+		Comments listPage[searchHit[models.Comment]] `json:"comments"`
+		Users    listPage[searchHit[models.User]]    `json:"users"`
+		Hashtags listPage[searchHit[models.Hashtag]] `json:"hashtags"`
+	}
+	decode(t, w, &all)
+
+	if len(all.Posts.Data) != 2 || len(all.Comments.Data) != 1 || len(all.Users.Data) != 1 || len(all.Hashtags.Data) != 1 {
+		t.Fatalf("sections = %+v", all)
+	}
+	if all.Users.Data[0].Item.Username != "gopher" || all.Hashtags.Data[0].Item.Name != "golang" {
+		t.Errorf("users = %+v, hashtags = %+v", all.Users.Data, all.Hashtags.Data)
+	}
+	if all.Posts.Data[0].Item.Viewer == nil || all.Posts.Data[0].Rank <= 0 {
+		t.Errorf("post hit = %+v", all.Posts.Data[0])
+	}
+
+	// Snippets escape the content and mark the matches
+	for _, hit := range all.Posts.Data {
+		if hit.Item.ID == post.ID && hit.Snippet != "Learning <mark>golang</mark> &lt;fast&gt; with friends" {
+			t.Errorf("snippet = %q", hit.Snippet)
+		}
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/api/search", nil, nil), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodGet, "/api/search?q=go&type=groups", nil, nil), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodGet, "/api/search?q=go&cursor=abc", nil, nil), http.StatusBadRequest)
+}
+
+func TestSearchHashtagsBySimilarity(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	api.createPost(alice, gin.H{"content": "#javascript and #java"})
+
+	w := api.request(http.MethodGet, "/api/search?type=hashtags&q=%23javascrpt", nil, nil)
+	expectStatus(t, w, http.StatusOK)
+	var page listPage[searchHit[models.Hashtag]]
+	decode(t, w, &page)
+	if len(page.Data) == 0 || page.Data[0].Item.Name != "javascript" {
+		t.Errorf("misspelled search = %+v", page.Data)
+	}
+
+	w = api.request(http.MethodGet, "/api/search?type=hashtags&q=ja", nil, nil)
+	page = listPage[searchHit[models.Hashtag]]{}
+	decode(t, w, &page)
+	if len(page.Data) != 2 {
+		t.Errorf("prefix search = %+v", page.Data)
+	}
+}
+
+func TestSearchFiltersAndPagination(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+
+	first := api.createPost(alice, gin.H{"content": "rust rust rust"})
+	image := api.createPost(alice, gin.H{"content": "rust on metal", "imageURL": "https://example.com/a.png"})
+	quote := api.createPost(bob, gin.H{"content": "rust quote", "isQuote": true, "quoteLines": "a line"})
+	latest := api.createPost(bob, gin.H{"content": "rust but not -excluded go"})
+
+	page := api.search(nil, url.Values{"q": {"rust"}, "type": {"posts"}, "author": {"alice"}})
+	if ids := hitIDs(page); len(ids) != 2 || ids[0] != first.ID {
+		t.Errorf("author filter = %v, want %d ranked first", ids, first.ID)
+	}
+	if ids := hitIDs(api.search(nil, url.Values{"q": {"rust"}, "type": {"posts"}, "hasImage": {"true"}})); len(ids) != 1 || ids[0] != image.ID {
+		t.Errorf("hasImage filter = %v", ids)
+	}
+	if ids := hitIDs(api.search(nil, url.Values{"q": {"rust"}, "type": {"posts"}, "isQuote": {"true"}})); len(ids) != 1 || ids[0] != quote.ID {
+		t.Errorf("isQuote filter = %v", ids)
+	}
+	if ids := hitIDs(api.search(nil, url.Values{"q": {"rust -go"}, "type": {"posts"}})); len(ids) != 3 {
+		t.Errorf("excluded word = %v", ids)
+	}
+	if ids := hitIDs(api.search(nil, url.Values{"q": {"rust"}, "type": {"posts"}, "to": {"2000-01-01"}})); len(ids) != 0 {
+		t.Errorf("date filter = %v", ids)
+	}
+
+	// Walk every page of the relevance and recency orders
+	for _, sort := range []string{"relevance", "recent"} {
+		seen := map[uint]bool{}
+		query := url.Values{"q": {"rust"}, "type": {"posts"}, "sort": {sort}, "limit": {"1"}}
+		for i := 0; i < 10; i++ {
+			page := api.search(nil, query)
+			for _, id := range hitIDs(page) {
+				seen[id] = true
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			query.Set("cursor", *page.NextCursor)
+		}
+		if len(seen) != 4 {
+			t.Errorf("sort=%s saw %v", sort, seen)
+		}
+	}
+	if ids := hitIDs(api.search(nil, url.Values{"q": {"rust"}, "type": {"posts"}, "sort": {"recent"}})); ids[0] != latest.ID {
+		t.Errorf("recent order = %v", ids)
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/api/search?q=rust&author=nobody", nil, nil), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodGet, "/api/search?q=rust&from=yesterday", nil, nil), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodGet, "/api/search?q=rust&hasImage=maybe", nil, nil), http.StatusBadRequest)
+}
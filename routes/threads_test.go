@@ -0,0 +1,97 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+type threadNode struct {
+	models.Comment
+	Replies     []threadNode `json:"replies"`
+	MoreReplies bool         `json:"moreReplies"`
+	NextCursor  *string      `json:"nextCursor"`
+}
+
+// reply creates a reply to parent on the post, returning its ID.
+func (a *testAPI) reply(user *testUser, postID, parentID uint, content string) uint {
+	a.t.Helper()
+	return a.createComment(user, gin.H{"postId": postID, "parentId": parentID, "content": content, "type": "normal"}).ID
+}
+
+func TestCommentThread(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "post"})
+	root := api.createComment(alice, gin.H{"postId": post.ID, "content": "root", "type": "normal"})
+
+	// root has three replies; the newest has a chain two levels deep
+	api.reply(alice, post.ID, root.ID, "a")
+	api.reply(alice, post.ID, root.ID, "b")
+	c := api.reply(alice, post.ID, root.ID, "c")
+	c1 := api.reply(alice, post.ID, c, "c1")
+	api.reply(alice, post.ID, c1, "c1a")
+
+	w := api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d/thread?depth=2&replies=2", root.ID), nil, nil)
+	expectStatus(t, w, http.StatusOK)
+	var thread threadNode
+	decode(t, w, &thread)
+
+	if thread.ID != root.ID || len(thread.Replies) != 2 || !thread.MoreReplies || thread.NextCursor == nil {
+		t.Fatalf("root = %+v", thread)
+	}
+	if thread.Replies[0].Content != "c" || thread.Replies[1].Content != "b" {
+		t.Errorf("replies = %q, %q, want newest first", thread.Replies[0].Content, thread.Replies[1].Content)
+	}
+
+	// c1 sits at the depth limit, so its reply is left out but signalled
+	branch := thread.Replies[0]
+	if len(branch.Replies) != 1 || branch.Replies[0].ID != c1 || branch.MoreReplies {
+		t.Fatalf("branch = %+v", branch)
+	}
+	if leaf := branch.Replies[0]; len(leaf.Replies) != 0 || !leaf.MoreReplies || leaf.NextCursor != nil {
+		t.Errorf("leaf = %+v", leaf)
+	}
+
+	// The branch cursor resumes the flat reply listing
+	w = api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d/replies?cursor=%s", root.ID, *thread.NextCursor), nil, nil)
+	expectStatus(t, w, http.StatusOK)
+	var rest listPage[models.Comment]
+	decode(t, w, &rest)
+	if len(rest.Data) != 1 || rest.Data[0].Content != "a" || rest.NextCursor != nil {
+		t.Errorf("remaining replies = %+v", rest.Data)
+	}
+}
+
+func TestCommentReplies(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	post := api.createPost(alice, gin.H{"content": "post"})
+	root := api.createComment(alice, gin.H{"postId": post.ID, "content": "root", "type": "normal"})
+	for i := 0; i < 5; i++ {
+		api.reply(alice, post.ID, root.ID, fmt.Sprintf("reply %d", i))
+	}
+
+	path := fmt.Sprintf("/api/comments/%d/replies", root.ID)
+	ids, pages := collectPages(t, api, alice, path, 2, func(c models.Comment) uint { return c.ID })
+	if len(ids) != 5 || pages != 3 {
+		t.Fatalf("got %d replies over %d pages, want 5 over 3", len(ids), pages)
+	}
+
+	w := api.request(http.MethodGet, path, nil, alice)
+	expectStatus(t, w, http.StatusOK)
+	var replies listPage[models.Comment]
+	decode(t, w, &replies)
+	for _, reply := range replies.Data {
+		if reply.ParentCommentID == nil || *reply.ParentCommentID != root.ID || reply.Viewer == nil {
+			t.Errorf("reply = %+v", reply)
+		}
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/api/comments/999/replies", nil, nil), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodGet, "/api/comments/999/thread", nil, nil), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodGet, fmt.Sprintf("/api/comments/%d/thread?depth=x", root.ID), nil, nil), http.StatusBadRequest)
+}
@@ -0,0 +1,98 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type typeaheadResult struct {
+	Users []struct {
+		Username   string `json:"username"`
+		Following  bool   `json:"following"`
+		FollowsYou bool   `json:"followsYou"`
+	} `json:"users"`
+	Hashtags []struct {
+		Name       string `json:"name"`
+		RecentUses int    `json:"recentUses"`
+	} `json:"hashtags"`
+}
+
+func (a *testAPI) typeahead(user *testUser, q string) typeaheadResult {
+	a.t.Helper()
+
+	w := a.request(http.MethodGet, "/api/typeahead?q="+url.QueryEscape(q), nil, user)
+	expectStatus(a.t, w, http.StatusOK)
+	var result typeaheadResult
+	decode(a.t, w, &result)
+	return result
+}
+
+func TestTypeaheadRanksUsersByRelationship(t *testing.T) {
+	api := newTestAPI(t)
+	me := api.registerUser("sam")
+	followed := api.registerUser("sam_followed")
+	follower := api.registerUser("sam_follower")
+	popular := api.registerUser("sam_popular")
+	api.registerUser("sam_quiet")
+	api.registerUser("other")
+
+	follow := func(user *testUser, username string) {
+		expectStatus(t, api.request(http.MethodPost, "/api/follow/"+username, nil, user), http.StatusCreated)
+	}
+	follow(me, "sam_followed")
+	follow(follower, "sam")
+	follow(followed, "sam_popular")
+	follow(follower, "sam_popular")
+
+	result := api.typeahead(me, "@Sam")
+	var got []string
+	for _, u := range result.Users {
+		got = append(got, u.Username)
+	}
+	want := []string{"sam_followed", "sam_follower", "sam_popular", "sam_quiet"}
+	if fmt.Sprint(got) != fmt.Sprint(want) || len(result.Hashtags) != 0 {
+		t.Errorf("users = %v, want %v; hashtags = %v", got, want, result.Hashtags)
+	}
+	if !result.Users[0].Following || !result.Users[1].FollowsYou {
+		t.Errorf("relationships = %+v", result.Users)
+	}
+
+	// Anonymous callers get popularity order
+	if result := api.typeahead(nil, "@sam_"); result.Users[0].Username != popular.Username {
+		t.Errorf("anonymous users = %+v", result.Users)
+	}
+
+	if result := api.typeahead(me, "@"); len(result.Users) != 0 {
+		t.Errorf("bare @ = %+v", result.Users)
+	}
+	expectStatus(t, api.request(http.MethodGet, "/api/typeahead", nil, nil), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodGet, "/api/typeahead?q=a&limit=0", nil, nil), http.StatusBadRequest)
+}
+
+func TestTypeaheadRanksHashtagsByRecentUse(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+
+	api.createPost(alice, gin.H{"content": "#golang"})
+	api.createPost(alice, gin.H{"content": "#gopher #golang"})
+	api.createComment(alice, gin.H{"postId": api.createPost(alice, gin.H{"content": "#gopher"}).ID, "type": "normal", "content": "#gopher"})
+	api.createPost(alice, gin.H{"content": "#rust"})
+
+	result := api.typeahead(alice, "#go")
+	if len(result.Users) != 0 || len(result.Hashtags) != 2 ||
+		result.Hashtags[0].Name != "gopher" || result.Hashtags[0].RecentUses != 3 || result.Hashtags[1].Name != "golang" {
+		t.Errorf("result = %+v", result)
+	}
+
+	// Without a sigil both kinds are suggested
+	if result := api.typeahead(alice, "al"); len(result.Users) != 0 {
+		t.Errorf("users = %+v, the caller is never suggested", result.Users)
+	}
+	if result := api.typeahead(alice, "ru"); len(result.Hashtags) != 1 {
+		t.Errorf("hashtags = %+v", result.Hashtags)
+	}
+}
@@ -0,0 +1,107 @@
+package routes_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	api := newTestAPI(t)
+
+	alice := api.registerUser("alice")
+	if alice.token == "" {
+		t.Fatal("login returned an empty token")
+	}
+	if alice.Username != "alice" || alice.Email != "alice@example.com" {
+		t.Errorf("login user = %+v", alice.User)
+	}
+
+	// The password hash must never be serialized
+	w := api.request(http.MethodGet, "/api/users/alice", nil, nil)
+	expectStatus(t, w, http.StatusOK)
+	var profile map[string]interface{}
+	decode(t, w, &profile)
+	if _, ok := profile["password"]; ok {
+		t.Error("profile exposes the password field")
+	}
+	for _, key := range []string{"id", "name", "username", "email", "bio", "followersCount", "followingCount", "createdAt"} {
+		if _, ok := profile[key]; !ok {
+			t.Errorf("profile is missing %q", key)
+		}
+	}
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	api := newTestAPI(t)
+	api.registerUser("alice")
+
+	w := api.request(http.MethodPost, "/api/users/register", gin.H{
+		"name":     "Another Alice",
+		"username": "alice",
+		"email":    "other@example.com",
+		"password": "password123",
+	}, nil)
+	expectStatus(t, w, http.StatusBadRequest)
+	if msg := errorMessage(t, w); msg != "Username or email already exists" {
+		t.Errorf("error = %q", msg)
+	}
+}
+
+func TestRegisterValidatesInput(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.request(http.MethodPost, "/api/users/register", gin.H{
+		"name":     "Short",
+		"username": "short",
+		"email":    "not-an-email",
+		"password": "123",
+	}, nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestLoginRejectsWrongPassword(t *testing.T) {
+	api := newTestAPI(t)
+	api.registerUser("alice")
+
+	w := api.request(http.MethodPost, "/api/users/login", gin.H{
+		"email":    "alice@example.com",
+		"password": "wrong-password",
+	}, nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestProtectedRoutesRequireToken(t *testing.T) {
+	api := newTestAPI(t)
+
+	w := api.request(http.MethodPost, "/api/posts/", gin.H{"content": "hello"}, nil)
+	expectStatus(t, w, http.StatusUnauthorized)
+
+	w = api.request(http.MethodPost, "/api/posts/", gin.H{"content": "hello"}, &testUser{token: "garbage"})
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestUpdateAndDeleteProfile(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+
+	w := api.request(http.MethodPut, "/api/users/alice", gin.H{"bio": "Hello there"}, alice)
+	expectStatus(t, w, http.StatusOK)
+	if got := api.getUser("alice"); got.Bio != "Hello there" || got.Name != "User alice" {
+		t.Errorf("profile after update = %+v", got)
+	}
+
+	w = api.request(http.MethodPut, "/api/users/alice", gin.H{"bio": "hijacked"}, bob)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = api.request(http.MethodDelete, "/api/users/alice", nil, bob)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = api.request(http.MethodDelete, "/api/users/alice", nil, alice)
+	expectStatus(t, w, http.StatusOK)
+
+	w = api.request(http.MethodGet, "/api/users/alice", nil, nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
@@ -0,0 +1,127 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sinkedin/models"
+)
+
+func TestPostVisibility(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+	dave := api.registerUser("dave")
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+
+	public := api.createPost(alice, gin.H{"content": "news for all", "hashtags": []string{"news"}})
+	followers := api.createPost(alice, gin.H{"content": "news for followers", "hashtags": []string{"news"}, "visibility": "followers"})
+	mentioned := api.createPost(alice, gin.H{"content": "news for carol", "hashtags": []string{"news"}, "tags": []string{"carol"}, "visibility": "mentioned"})
+	draft := api.createPost(alice, gin.H{"content": "news draft", "hashtags": []string{"news"}, "tags": []string{"dave"}, "visibility": "private"})
+	if public.Visibility != models.PublicVisibility || public.ReplyPolicy != models.EveryoneReplies {
+		t.Errorf("defaults = %q/%q", public.Visibility, public.ReplyPolicy)
+	}
+	w := api.request(http.MethodPost, "/api/posts/", gin.H{"content": "x", "visibility": "friends"}, alice)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	// Each reader sees what the visibility of each post lets them
+	sees := map[*testUser][]uint{
+		alice: {draft.ID, mentioned.ID, followers.ID, public.ID},
+		bob:   {followers.ID, public.ID},
+		carol: {mentioned.ID, public.ID},
+		dave:  {public.ID},
+		nil:   {public.ID},
+	}
+	for user, want := range sees {
+		if got := listIDs(t, api, user, "/api/posts/"); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("posts listed for %v = %v, want %v", user, got, want)
+		}
+		ids := hitIDs(api.search(user, url.Values{"q": {"news"}, "type": {"posts"}}))
+		slices.Sort(ids)
+		sorted := slices.Clone(want)
+		slices.Sort(sorted)
+		if fmt.Sprint(ids) != fmt.Sprint(sorted) {
+			t.Errorf("posts found for %v = %v, want %v", user, ids, sorted)
+		}
+		for _, post := range []models.Post{public, followers, mentioned, draft} {
+			want := http.StatusNotFound
+			if slices.Contains(sees[user], post.ID) {
+				want = http.StatusOK
+			}
+			for _, path := range []string{"/api/posts/%d", "/api/comments/post/%d", "/api/likes/post/%d"} {
+				path = fmt.Sprintf(path, post.ID)
+				if w := api.request(http.MethodGet, path, nil, user); w.Code != want {
+					t.Errorf("GET %s for %v = %d, want %d", path, user, w.Code, want)
+				}
+			}
+		}
+	}
+	// Hashtag listings are public, so they only ever show public posts
+	if got := listIDs(t, api, nil, "/api/hashtags/news/posts"); fmt.Sprint(got) != fmt.Sprint([]uint{public.ID}) {
+		t.Errorf("hashtag posts = %v, want %v", got, []uint{public.ID})
+	}
+	body := gin.H{"postId": followers.ID, "type": "normal", "content": "me too"}
+	expectStatus(t, api.request(http.MethodPost, "/api/comments/", body, dave), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodPost, fmt.Sprintf("/api/likes/post/%d", followers.ID), nil, dave), http.StatusNotFound)
+
+	// Drafts tag nobody until they are published
+	if got := unreadCount(t, api, dave); got != 0 {
+		t.Errorf("dave's unread count for the draft = %d", got)
+	}
+	w = api.request(http.MethodPut, fmt.Sprintf("/api/posts/%d", draft.ID), gin.H{"content": "news at last", "visibility": "public"}, alice)
+	expectStatus(t, w, http.StatusOK)
+	if got := unreadCount(t, api, dave); got != 1 {
+		t.Errorf("dave's unread count after publishing = %d", got)
+	}
+	expectStatus(t, api.request(http.MethodGet, fmt.Sprintf("/api/posts/%d", draft.ID), nil, dave), http.StatusOK)
+}
+
+func TestReplyPolicy(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.registerUser("alice")
+	bob := api.registerUser("bob")
+	carol := api.registerUser("carol")
+	expectStatus(t, api.request(http.MethodPost, "/api/follow/alice", nil, bob), http.StatusCreated)
+
+	for _, tc := range []struct {
+		policy  string
+		allowed []*testUser
+		denied  []*testUser
+	}{
+		{"everyone", []*testUser{alice, bob, carol}, nil},
+		{"followers", []*testUser{alice, bob}, []*testUser{carol}},
+		{"mentioned", []*testUser{alice, carol}, []*testUser{bob}},
+		{"nobody", []*testUser{alice}, []*testUser{bob, carol}},
+	} {
+		post := api.createPost(alice, gin.H{"content": "replies: " + tc.policy, "tags": []string{"carol"}, "replyPolicy": tc.policy})
+		comment := api.createComment(alice, gin.H{"postId": post.ID, "type": "normal", "content": "first"})
+		for _, user := range tc.allowed {
+			body := gin.H{"postId": post.ID, "type": "normal", "content": "hi"}
+			expectStatus(t, api.request(http.MethodPost, "/api/comments/", body, user), http.StatusCreated)
+		}
+		for _, user := range tc.denied {
+			// Replying to a comment counts as replying to the post
+			for _, body := range []gin.H{
+				{"postId": post.ID, "type": "normal", "content": "hi"},
+				{"parentId": comment.ID, "type": "normal", "content": "hi"},
+			} {
+				expectStatus(t, api.request(http.MethodPost, "/api/comments/", body, user), http.StatusForbidden)
+			}
+		}
+	}
+
+	// The policy can change later
+	post := api.createPost(alice, gin.H{"content": "open for now"})
+	w := api.request(http.MethodPut, fmt.Sprintf("/api/posts/%d", post.ID), gin.H{"content": "closed", "replyPolicy": "nobody"}, alice)
+	expectStatus(t, w, http.StatusOK)
+	w = api.request(http.MethodPost, "/api/comments/", gin.H{"postId": post.ID, "type": "normal", "content": "hi"}, bob)
+	expectStatus(t, w, http.StatusForbidden)
+	if msg := errorMessage(t, w); msg != "Replies to this post are turned off" {
+		t.Errorf("error = %q", msg)
+	}
+}
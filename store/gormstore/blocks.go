@@ -0,0 +1,125 @@
+package gormstore
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type blockStore struct {
+	db *gorm.DB
+}
+
+func (s *blockStore) Block(blockerID, blockedID uint) (store.Relationship, error) {
+	var removed store.Relationship
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		block := models.Block{BlockerID: blockerID, BlockedID: blockedID}
+		if err := tx.Omit("Blocked").Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("status = ? AND ((requester_id = ? AND target_id = ?) OR (requester_id = ? AND target_id = ?))",
+			models.FollowRequestPending, blockerID, blockedID, blockedID, blockerID).
+			Delete(&models.FollowRequest{}).Error
+		if err != nil {
+			return err
+		}
+
+		if removed.Following, err = unfollow(tx, blockerID, blockedID); err != nil {
+			return err
+		}
+		removed.FollowedBy, err = unfollow(tx, blockedID, blockerID)
+		return err
+	})
+	return removed, translate(err)
+}
+
+func (s *blockStore) Unblock(blockerID, blockedID uint) (bool, error) {
+	result := s.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&models.Block{})
+	return result.RowsAffected > 0, translate(result.Error)
+}
+
+// userRow is a user joined with the time a block or mute was created.
+type userRow struct {
+	models.User
+	At time.Time
+}
+
+// listUsers pages through the users on the otherCol side of table's rows
+// whose ownerCol is userID, most recent first.
+func (s *blockStore) listUsers(table, ownerCol, otherCol string, userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	var rows []userRow
+	q := s.db.Table("users").
+		Select("users.*, "+table+".created_at AS at").
+		Joins("JOIN "+table+" ON users.id = "+table+"."+otherCol).
+		Where(table+"."+ownerCol+" = ? AND users.deleted_at IS NULL", userID)
+	if err := paginate(q, table+".created_at", "users.id", page).Find(&rows).Error; err != nil {
+		return store.Page[models.User]{}, translate(err)
+	}
+
+	rowPage := store.NewPage(rows, page.Limit, func(row userRow) store.Cursor {
+		return store.Cursor{CreatedAt: row.At, ID: row.ID}
+	})
+	users := make([]models.User, 0, len(rowPage.Items))
+	for _, row := range rowPage.Items {
+		users = append(users, row.User)
+	}
+	return store.Page[models.User]{Items: users, Next: rowPage.Next}, nil
+}
+
+func (s *blockStore) Blocked(userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	return s.listUsers("blocks", "blocker_id", "blocked_id", userID, page)
+}
+
+func (s *blockStore) Blocking(userID uint, otherIDs []uint) (map[uint]bool, error) {
+	blocking := map[uint]bool{}
+	if len(otherIDs) == 0 {
+		return blocking, nil
+	}
+
+	var blocks []models.Block
+	if err := s.db.Select("blocker_id", "blocked_id").
+		Where("(blocker_id = ? AND blocked_id IN ?) OR (blocked_id = ? AND blocker_id IN ?)", userID, otherIDs, userID, otherIDs).
+		Find(&blocks).Error; err != nil {
+		return nil, translate(err)
+	}
+	for _, block := range blocks {
+		if block.BlockerID == userID {
+			blocking[block.BlockedID] = true
+		} else {
+			blocking[block.BlockerID] = true
+		}
+	}
+	return blocking, nil
+}
+
+func (s *blockStore) Mute(userID, mutedID uint) error {
+	mute := models.Mute{UserID: userID, MutedID: mutedID}
+	return translate(s.db.Omit("Muted").Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error)
+}
+
+func (s *blockStore) Unmute(userID, mutedID uint) (bool, error) {
+	result := s.db.Where("user_id = ? AND muted_id = ?", userID, mutedID).Delete(&models.Mute{})
+	return result.RowsAffected > 0, translate(result.Error)
+}
+
+func (s *blockStore) Muted(userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	return s.listUsers("mutes", "user_id", "muted_id", userID, page)
+}
+
+func (s *blockStore) HiddenIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := s.db.Raw(`
+		SELECT blocked_id FROM blocks WHERE blocker_id = @user
+		UNION SELECT blocker_id FROM blocks WHERE blocked_id = @user
+		UNION SELECT muted_id FROM mutes WHERE user_id = @user
+		ORDER BY 1`,
+		map[string]interface{}{"user": userID}).Scan(&ids).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,234 @@
+package gormstore
+
+import (
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type commentStore struct {
+	db *gorm.DB
+}
+
+func (s *commentStore) Create(comment *models.Comment, hashtags []string, tagUserIDs []uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		comment.ContainsHashtag = len(hashtags) > 0
+		comment.ContainsTag = len(tagUserIDs) > 0
+		if err := tx.Omit("User", "Post", "ParentComment", "Tags", "Hashtags").Create(comment).Error; err != nil {
+			return err
+		}
+
+		// Handle hashtags
+		for _, name := range hashtags {
+			hashtag, err := findOrCreateHashtag(tx, name)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(hashtag).UpdateColumn("counter", gorm.Expr("counter + ?", 1)).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.CommentHashtag{CommentID: comment.ID, HashtagID: hashtag.ID}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Handle tags
+		for _, userID := range tagUserIDs {
+			if err := tx.Create(&models.CommentTag{CommentID: comment.ID, UserID: userID}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Update comment count on parent
+		return adjustCommentCounts(tx, comment, 1)
+	})
+	if err != nil {
+		return translate(err)
+	}
+
+	// Load the complete comment with associations
+	return translate(s.db.Preload("User").Preload("Tags").Preload("Hashtags").First(comment, comment.ID).Error)
+}
+
+func (s *commentStore) GetByID(id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := s.db.Preload("User").Preload("Tags").Preload("Hashtags").
+		Preload("ParentComment").First(&comment, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &comment, nil
+}
+
+func (s *commentStore) ListByPost(postID uint, page store.PageRequest) (store.Page[models.Comment], error) {
+	var comments []models.Comment
+	q := s.db.Preload("User").Preload("Tags").Preload("Hashtags").
+		Where("post_id = ? AND parent_comment_id IS NULL", postID)
+	if err := paginate(q, "created_at", "id", page).Find(&comments).Error; err != nil {
+		return store.Page[models.Comment]{}, translate(err)
+	}
+	return store.NewPage(comments, page.Limit, commentCursor), nil
+}
+
+func (s *commentStore) ListReplies(parentID uint, page store.PageRequest) (store.Page[models.Comment], error) {
+	var comments []models.Comment
+	q := s.db.Preload("User").Preload("Tags").Preload("Hashtags").
+		Where("parent_comment_id = ?", parentID)
+	if err := paginate(q, "created_at", "id", page).Find(&comments).Error; err != nil {
+		return store.Page[models.Comment]{}, translate(err)
+	}
+	return store.NewPage(comments, page.Limit, commentCursor), nil
+}
+
+// threadQuery walks the subtree below a comment, taking the newest
+// replies+1 replies of each comment through a lateral join. Only the first
+// replies of them are descended into; the extra one just signals that more
+// remain.
+const threadQuery = `
+WITH RECURSIVE tree AS (
+	SELECT id, 0 AS depth, 1::bigint AS rank
+	FROM comments
+	WHERE id = @root AND deleted_at IS NULL
+	UNION ALL
+	SELECT reply.id, tree.depth + 1, reply.rank
+	FROM tree
+	CROSS JOIN LATERAL (
+		SELECT c.id, row_number() OVER (ORDER BY c.created_at DESC, c.id DESC) AS rank
+		FROM comments c
+		WHERE c.parent_comment_id = tree.id AND c.deleted_at IS NULL
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT @fetch
+	) reply
+	WHERE tree.depth < @depth AND tree.rank <= @replies
+)
+SELECT id FROM tree`
+
+func (s *commentStore) Thread(rootID uint, opts store.ThreadOptions) (*store.CommentThread, error) {
+	var ids []uint
+	if err := s.db.Raw(threadQuery, map[string]interface{}{
+		"root":    rootID,
+		"fetch":   opts.Replies + 1,
+		"depth":   opts.Depth,
+		"replies": opts.Replies,
+	}).Scan(&ids).Error; err != nil {
+		return nil, translate(err)
+	}
+	if len(ids) == 0 {
+		return nil, store.ErrNotFound
+	}
+
+	var comments []models.Comment
+	if err := s.db.Preload("User").Preload("Tags").Preload("Hashtags").
+		Where("id IN ?", ids).Find(&comments).Error; err != nil {
+		return nil, translate(err)
+	}
+
+	thread, ok := store.BuildThread(rootID, comments, opts)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &thread, nil
+}
+
+func (s *commentStore) Update(comment *models.Comment, hashtags []string, tagUserIDs []uint) ([]uint, error) {
+	var tagged []uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		columns := []interface{}{"entities", "type", "gif"}
+
+		if hashtags != nil {
+			added, removed, err := syncHashtags(tx, "comment_hashtags", "comment_id", comment.ID, hashtags)
+			if err != nil {
+				return err
+			}
+			if err := adjustHashtagCounters(tx, added, 1); err != nil {
+				return err
+			}
+			if err := adjustHashtagCounters(tx, removed, -1); err != nil {
+				return err
+			}
+			comment.ContainsHashtag = len(hashtags) > 0
+			columns = append(columns, "contains_hashtag")
+		}
+
+		if tagUserIDs != nil {
+			added, _, err := syncJoin(tx, "comment_tags", "comment_id", "user_id", comment.ID, tagUserIDs)
+			if err != nil {
+				return err
+			}
+			tagged = added
+			comment.ContainsTag = len(tagUserIDs) > 0
+			columns = append(columns, "contains_tag")
+		}
+
+		return tx.Model(comment).Select("content", columns...).Updates(comment).Error
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	return tagged, translate(s.db.Preload("User").Preload("Tags").Preload("Hashtags").First(comment, comment.ID).Error)
+}
+
+// subtreeQuery lists the live replies below a comment, at any depth, with
+// the posts they count towards.
+const subtreeQuery = `
+WITH RECURSIVE subtree AS (
+	SELECT id, post_id FROM comments
+	WHERE parent_comment_id = @id AND deleted_at IS NULL
+	UNION ALL
+	SELECT c.id, c.post_id FROM comments c
+	JOIN subtree ON c.parent_comment_id = subtree.id
+	WHERE c.deleted_at IS NULL
+)
+SELECT id, post_id FROM subtree`
+
+func (s *commentStore) Delete(comment *models.Comment) error {
+	return translate(s.db.Transaction(func(tx *gorm.DB) error {
+		var replies []struct {
+			ID     uint
+			PostID *uint
+		}
+		if err := tx.Raw(subtreeQuery, map[string]interface{}{"id": comment.ID}).Scan(&replies).Error; err != nil {
+			return err
+		}
+
+		// The replies' own parents go with them, so only the post they
+		// count towards needs adjusting
+		ids := []uint{comment.ID}
+		for _, reply := range replies {
+			ids = append(ids, reply.ID)
+			if reply.PostID != nil {
+				if err := tx.Model(&models.Post{}).Where("id = ?", *reply.PostID).
+					UpdateColumn("comment_count", gorm.Expr("comment_count - 1")).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := adjustCommentCounts(tx, comment, -1); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := releaseHashtags(tx, "comment_hashtags", "comment_id", id); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Comment{}, ids).Error
+	}))
+}
+
+// adjustCommentCounts moves the comment count of the comment's post and
+// parent comment by delta.
+func adjustCommentCounts(tx *gorm.DB, comment *models.Comment, delta int) error {
+	if comment.PostID != nil {
+		if err := tx.Model(&models.Post{}).Where("id = ?", *comment.PostID).
+			UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error; err != nil {
+			return err
+		}
+	}
+	if comment.ParentCommentID != nil {
+		if err := tx.Model(&models.Comment{}).Where("id = ?", *comment.ParentCommentID).
+			UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
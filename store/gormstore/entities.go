@@ -0,0 +1,87 @@
+package gormstore
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// syncHashtags makes the hashtags joined to ownerID through joinTable match
+// names, creating hashtags on first use. It returns the IDs of the hashtags
+// it linked and unlinked.
+func syncHashtags(tx *gorm.DB, joinTable, ownerCol string, ownerID uint, names []string) (added, removed []uint, err error) {
+	wanted := make([]uint, 0, len(names))
+	for _, name := range names {
+		hashtag, err := findOrCreateHashtag(tx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		wanted = append(wanted, hashtag.ID)
+	}
+	return syncJoin(tx, joinTable, ownerCol, "hashtag_id", ownerID, wanted)
+}
+
+// syncJoin makes the rows of joinTable for ownerID reference exactly the
+// wanted IDs in targetCol, returning the IDs it inserted and deleted.
+func syncJoin(tx *gorm.DB, joinTable, ownerCol, targetCol string, ownerID uint, wanted []uint) (added, removed []uint, err error) {
+	var current []uint
+	if err := tx.Table(joinTable).Where(ownerCol+" = ?", ownerID).Pluck(targetCol, &current).Error; err != nil {
+		return nil, nil, err
+	}
+
+	added, removed = diffIDs(current, wanted)
+	for _, id := range added {
+		row := map[string]interface{}{ownerCol: ownerID, targetCol: id}
+		if err := tx.Table(joinTable).Create(row).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(removed) > 0 {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s IN ?", joinTable, ownerCol, targetCol)
+		if err := tx.Exec(query, ownerID, removed).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	return added, removed, nil
+}
+
+// diffIDs returns the IDs of next missing from current and the IDs of
+// current missing from next, ignoring duplicates.
+func diffIDs(current, next []uint) (added, removed []uint) {
+	have := make(map[uint]bool, len(current))
+	for _, id := range current {
+		have[id] = true
+	}
+	want := make(map[uint]bool, len(next))
+	for _, id := range next {
+		if !want[id] && !have[id] {
+			added = append(added, id)
+		}
+		want[id] = true
+	}
+	for _, id := range current {
+		if !want[id] {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+// adjustHashtagCounters moves the usage counter of each hashtag by delta.
+func adjustHashtagCounters(tx *gorm.DB, ids []uint, delta int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Table("hashtags").Where("id IN ?", ids).
+		UpdateColumn("counter", gorm.Expr("counter + ?", delta)).Error
+}
+
+// releaseHashtags decrements the counters of the hashtags joined to owner,
+// for when the owner is deleted.
+func releaseHashtags(tx *gorm.DB, table, ownerCol string, owner uint) error {
+	var ids []uint
+	if err := tx.Table(table).Where(ownerCol+" = ?", owner).Pluck("hashtag_id", &ids).Error; err != nil {
+		return err
+	}
+	return adjustHashtagCounters(tx, ids, -1)
+}
@@ -0,0 +1,137 @@
+package gormstore
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type followRequestStore struct {
+	db *gorm.DB
+}
+
+func (s *followRequestStore) Create(requesterID, targetID uint) (*models.FollowRequest, error) {
+	request := models.FollowRequest{
+		RequesterID: requesterID,
+		TargetID:    targetID,
+		Status:      models.FollowRequestPending,
+		CreatedAt:   time.Now(),
+	}
+	err := s.db.Omit("Requester").Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "requester_id"}, {Name: "target_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":     models.FollowRequestPending,
+			"created_at": request.CreatedAt,
+			"decided_at": nil,
+		}),
+	}).Create(&request).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return s.get("requester_id = ? AND target_id = ?", requesterID, targetID)
+}
+
+func (s *followRequestStore) get(query string, args ...interface{}) (*models.FollowRequest, error) {
+	var request models.FollowRequest
+	if err := s.db.Preload("Requester").Where(query, args...).First(&request).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &request, nil
+}
+
+func (s *followRequestStore) GetByID(id uint) (*models.FollowRequest, error) {
+	return s.get("id = ?", id)
+}
+
+func (s *followRequestStore) Pending(requesterID, targetID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.FollowRequest{}).
+		Where("requester_id = ? AND target_id = ? AND status = ?", requesterID, targetID, models.FollowRequestPending).
+		Count(&count).Error
+	return count > 0, translate(err)
+}
+
+func (s *followRequestStore) Cancel(requesterID, targetID uint) (bool, error) {
+	result := s.db.Where("requester_id = ? AND target_id = ? AND status = ?", requesterID, targetID, models.FollowRequestPending).
+		Delete(&models.FollowRequest{})
+	return result.RowsAffected > 0, translate(result.Error)
+}
+
+func (s *followRequestStore) Incoming(userID uint, page store.PageRequest) (store.Page[models.FollowRequest], error) {
+	var requests []models.FollowRequest
+	q := s.db.Preload("Requester").Where("target_id = ? AND status = ?", userID, models.FollowRequestPending)
+	if err := paginate(q, "created_at", "id", page).Find(&requests).Error; err != nil {
+		return store.Page[models.FollowRequest]{}, translate(err)
+	}
+	return store.NewPage(requests, page.Limit, func(request models.FollowRequest) store.Cursor {
+		return store.Cursor{CreatedAt: request.CreatedAt, ID: request.ID}
+	}), nil
+}
+
+// decide moves the pending requests selected by query to status, following
+// their targets if they were accepted, and returns them.
+func decide(tx *gorm.DB, status models.FollowRequestStatus, query string, args ...interface{}) ([]models.FollowRequest, error) {
+	var requests []models.FollowRequest
+	err := tx.Model(&requests).Clauses(clause.Returning{}).
+		Where("status = ?", models.FollowRequestPending).Where(query, args...).
+		Updates(map[string]interface{}{"status": status, "decided_at": time.Now()}).Error
+	if err != nil {
+		return nil, err
+	}
+	if status == models.FollowRequestAccepted {
+		for _, request := range requests {
+			if _, err := follow(tx, request.RequesterID, request.TargetID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return requests, nil
+}
+
+func (s *followRequestStore) resolve(id uint, status models.FollowRequestStatus) (*models.FollowRequest, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		decided, err := decide(tx, status, "id = ?", id)
+		if err != nil || len(decided) > 0 {
+			return err
+		}
+		// Tell a missing request from one already decided
+		var count int64
+		if err := tx.Model(&models.FollowRequest{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrNotFound
+		}
+		return store.ErrConflict
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	return s.GetByID(id)
+}
+
+func (s *followRequestStore) Accept(id uint) (*models.FollowRequest, error) {
+	return s.resolve(id, models.FollowRequestAccepted)
+}
+
+func (s *followRequestStore) Reject(id uint) (*models.FollowRequest, error) {
+	return s.resolve(id, models.FollowRequestRejected)
+}
+
+func (s *followRequestStore) AcceptAll(userID uint) ([]uint, error) {
+	var ids []uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		decided, err := decide(tx, models.FollowRequestAccepted, "target_id = ?", userID)
+		for _, request := range decided {
+			ids = append(ids, request.RequesterID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,143 @@
+package gormstore
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type followStore struct {
+	db *gorm.DB
+}
+
+func (s *followStore) Toggle(followerID, followingID uint) (bool, error) {
+	following := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		removed, err := unfollow(tx, followerID, followingID)
+		if err != nil || removed {
+			return err
+		}
+		following, err = follow(tx, followerID, followingID)
+		return err
+	})
+	return following, translate(err)
+}
+
+// follow creates the follow unless it exists, keeping the follow counts in
+// step, and reports whether it was created.
+func follow(tx *gorm.DB, followerID, followingID uint) (bool, error) {
+	f := models.Follow{FollowerID: followerID, FollowingID: followingID}
+	result := tx.Omit("Follower", "Following").Clauses(clause.OnConflict{DoNothing: true}).Create(&f)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	return true, adjustFollowCounts(tx, followerID, followingID, 1)
+}
+
+// unfollow hard deletes the follow, if any, keeping the follow counts in
+// step, and reports whether there was one.
+func unfollow(tx *gorm.DB, followerID, followingID uint) (bool, error) {
+	result := tx.Unscoped().Where("follower_id = ? AND following_id = ?", followerID, followingID).Delete(&models.Follow{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	return true, adjustFollowCounts(tx, followerID, followingID, -1)
+}
+
+func adjustFollowCounts(tx *gorm.DB, followerID, followingID uint, delta int) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", followerID).
+		UpdateColumn("following_count", gorm.Expr("following_count + ?", delta)).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).Where("id = ?", followingID).
+		UpdateColumn("followers_count", gorm.Expr("followers_count + ?", delta)).Error
+}
+
+// followRow is a user joined with the time of the follow that relates them.
+type followRow struct {
+	models.User
+	FollowedAt time.Time
+}
+
+// related pages through the users on the joinCol side of the follows whose
+// matchCol is userID, most recent follow first.
+func (s *followStore) related(joinCol, matchCol string, userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	var rows []followRow
+	q := s.db.Table("users").
+		Select("users.*, follows.created_at AS followed_at").
+		Joins(fmt.Sprintf("JOIN follows ON users.id = follows.%s", joinCol)).
+		Where(fmt.Sprintf("follows.%s = ?", matchCol), userID)
+	if err := paginate(q, "follows.created_at", "users.id", page).Find(&rows).Error; err != nil {
+		return store.Page[models.User]{}, translate(err)
+	}
+
+	rowPage := store.NewPage(rows, page.Limit, func(row followRow) store.Cursor {
+		return store.Cursor{CreatedAt: row.FollowedAt, ID: row.ID}
+	})
+	users := make([]models.User, 0, len(rowPage.Items))
+	for _, row := range rowPage.Items {
+		users = append(users, row.User)
+	}
+	return store.Page[models.User]{Items: users, Next: rowPage.Next}, nil
+}
+
+func (s *followStore) Followers(userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	return s.related("follower_id", "following_id", userID, page)
+}
+
+func (s *followStore) Following(userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	return s.related("following_id", "follower_id", userID, page)
+}
+
+func (s *followStore) FollowerIDs(userID, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	if err := s.db.Model(&models.Follow{}).
+		Where("following_id = ? AND follower_id > ?", userID, afterID).
+		Order("follower_id").Limit(limit).
+		Pluck("follower_id", &ids).Error; err != nil {
+		return nil, translate(err)
+	}
+	return ids, nil
+}
+
+func (s *followStore) PopularFollowingIDs(userID uint, minFollowers int) ([]uint, error) {
+	var ids []uint
+	if err := s.db.Model(&models.Follow{}).
+		Joins("JOIN users ON users.id = follows.following_id").
+		Where("follows.follower_id = ? AND users.followers_count >= ? AND users.deleted_at IS NULL", userID, minFollowers).
+		Pluck("follows.following_id", &ids).Error; err != nil {
+		return nil, translate(err)
+	}
+	return ids, nil
+}
+
+func (s *followStore) Relationships(userID uint, otherIDs []uint) (map[uint]store.Relationship, error) {
+	relationships := map[uint]store.Relationship{}
+	if len(otherIDs) == 0 {
+		return relationships, nil
+	}
+
+	var follows []models.Follow
+	if err := s.db.Select("follower_id", "following_id").
+		Where("(follower_id = ? AND following_id IN ?) OR (following_id = ? AND follower_id IN ?)", userID, otherIDs, userID, otherIDs).
+		Find(&follows).Error; err != nil {
+		return nil, translate(err)
+	}
+
+	for _, follow := range follows {
+		if follow.FollowerID == userID {
+			r := relationships[follow.FollowingID]
+			r.Following = true
+			relationships[follow.FollowingID] = r
+		} else {
+			r := relationships[follow.FollowerID]
+			r.FollowedBy = true
+			relationships[follow.FollowerID] = r
+		}
+	}
+	return relationships, nil
+}
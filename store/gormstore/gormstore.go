@@ -0,0 +1,70 @@
+package gormstore
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+// New returns a Store backed by the given GORM connection.
+func New(db *gorm.DB) *store.Store {
+	return &store.Store{
+		Users:          &userStore{db: db},
+		Posts:          &postStore{db: db},
+		Comments:       &commentStore{db: db},
+		Likes:          &likeStore{db: db},
+		Follows:        &followStore{db: db},
+		Hashtags:       &hashtagStore{db: db},
+		Timelines:      &timelineStore{db: db},
+		Sessions:       &sessionStore{db: db},
+		Search:         &searchStore{db: db},
+		Notifications:  &notificationStore{db: db},
+		Messages:       &messageStore{db: db},
+		Blocks:         &blockStore{db: db},
+		FollowRequests: &followRequestStore{db: db},
+		Moderation:     &moderationStore{db: db},
+	}
+}
+
+// translate maps GORM errors onto the store sentinel errors.
+func translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, gorm.ErrForeignKeyViolated):
+		return store.ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return store.ErrConflict
+	}
+	return err
+}
+
+// findOrCreateHashtag returns the hashtag with the given name, creating it if
+// it does not exist yet.
+func findOrCreateHashtag(tx *gorm.DB, name string) (*models.Hashtag, error) {
+	var hashtag models.Hashtag
+	if err := tx.Where("name = ?", name).FirstOrCreate(&hashtag, models.Hashtag{Name: name}).Error; err != nil {
+		return nil, err
+	}
+	return &hashtag, nil
+}
+
+// paginate applies keyset pagination over (createdCol, idCol), newest first,
+// fetching one extra row so store.NewPage can tell whether more remain.
+func paginate(q *gorm.DB, createdCol, idCol string, page store.PageRequest) *gorm.DB {
+	if page.After != nil {
+		q = q.Where(fmt.Sprintf("(%s, %s) < (?, ?)", createdCol, idCol), page.After.CreatedAt, page.After.ID)
+	}
+	return q.Order(createdCol + " desc").Order(idCol + " desc").Limit(page.Limit + 1)
+}
+
+func postCursor(post models.Post) store.Cursor {
+	return store.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+}
+
+func commentCursor(comment models.Comment) store.Cursor {
+	return store.Cursor{CreatedAt: comment.CreatedAt, ID: comment.ID}
+}
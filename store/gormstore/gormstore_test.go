@@ -0,0 +1,44 @@
+package gormstore_test
+
+import (
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"sinkedin/migrations"
+	"sinkedin/store"
+	"sinkedin/store/gormstore"
+	"sinkedin/store/storetest"
+)
+
+// TestStore runs the shared store checks against Postgres. It needs
+// DATABASE_URL to point at a database it may migrate, and is skipped
+// otherwise. Each check runs in a transaction that is rolled back, so the
+// database is left as migrated.
+func TestStore(t *testing.T) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	storetest.Run(t, func(t *testing.T) *store.Store {
+		tx := db.Begin()
+		if tx.Error != nil {
+			t.Fatal(tx.Error)
+		}
+		t.Cleanup(func() { tx.Rollback() })
+		return gormstore.New(tx)
+	})
+}
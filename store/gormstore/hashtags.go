@@ -0,0 +1,373 @@
+package gormstore
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type hashtagStore struct {
+	db *gorm.DB
+}
+
+func (s *hashtagStore) GetByName(name string) (*models.Hashtag, error) {
+	var hashtag models.Hashtag
+	if err := s.db.Where("name = ?", name).First(&hashtag).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &hashtag, nil
+}
+
+// publicPost completes a join on posts to the ones whose hashtag uses count
+// towards a hashtag's aggregates: live, public, not hidden by moderators and
+// by a public author. Anything else would give away content that not
+// everyone may see.
+const publicPost = ` AND posts.deleted_at IS NULL
+	AND posts.visibility = 'public' AND NOT posts.is_hidden
+JOIN users post_authors ON post_authors.id = posts.user_id AND NOT post_authors.is_private`
+
+// publicComment does the same for a join on comments, which count when they
+// are not hidden, by a public author and on a post that counts.
+const publicComment = ` AND comments.deleted_at IS NULL AND NOT comments.is_hidden
+JOIN users comment_authors ON comment_authors.id = comments.user_id AND NOT comment_authors.is_private
+JOIN posts ON posts.id = comments.post_id` + publicPost
+
+// activityQuery aggregates the uses of each hashtag by public posts and
+// comments over the window and the one before it.
+const activityQuery = `
+SELECT hashtag_id,
+	count(*) FILTER (WHERE created_at >= @start) AS uses,
+	count(*) FILTER (WHERE created_at < @start) AS previous_uses,
+	coalesce(sum(power(0.5, extract(epoch FROM @now - created_at) / @half_life)) FILTER (WHERE created_at >= @start), 0) AS decayed
+FROM (
+	SELECT post_hashtags.hashtag_id, post_hashtags.created_at
+	FROM post_hashtags
+	JOIN posts ON posts.id = post_hashtags.post_id` + publicPost + `
+	WHERE post_hashtags.created_at >= @since AND post_hashtags.created_at <= @now
+	UNION ALL
+	SELECT comment_hashtags.hashtag_id, comment_hashtags.created_at
+	FROM comment_hashtags
+	JOIN comments ON comments.id = comment_hashtags.comment_id` + publicComment + `
+	WHERE comment_hashtags.created_at >= @since AND comment_hashtags.created_at <= @now
+) uses
+GROUP BY hashtag_id`
+
+func (s *hashtagStore) Activity(now time.Time, window, halfLife time.Duration) ([]store.HashtagActivity, error) {
+	var activity []store.HashtagActivity
+	err := s.db.Raw(activityQuery, map[string]interface{}{
+		"now":       now,
+		"start":     now.Add(-window),
+		"since":     now.Add(-2 * window),
+		"half_life": halfLife.Seconds(),
+	}).Scan(&activity).Error
+	return activity, translate(err)
+}
+
+func (s *hashtagStore) ReplaceTrending(window string, entries []models.TrendingHashtag) error {
+	return translate(s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(`"window" = ?`, window).Delete(&models.TrendingHashtag{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.Omit("Hashtag").Create(&entries).Error
+	}))
+}
+
+func (s *hashtagStore) Trending(window string, limit int) ([]models.TrendingHashtag, error) {
+	var trending []models.TrendingHashtag
+	if err := s.db.Preload("Hashtag").Where(`"window" = ?`, window).
+		Order("rank").Limit(limit).Find(&trending).Error; err != nil {
+		return nil, translate(err)
+	}
+	return trending, nil
+}
+
+// usesQuery lists the uses of a hashtag by public posts and comments.
+const usesQuery = `
+SELECT posts.user_id, post_hashtags.created_at AS used_at, 'post' AS kind
+FROM post_hashtags
+JOIN posts ON posts.id = post_hashtags.post_id` + publicPost + `
+WHERE post_hashtags.hashtag_id = @id
+UNION ALL
+SELECT comments.user_id, comment_hashtags.created_at, 'comment'
+FROM comment_hashtags
+JOIN comments ON comments.id = comment_hashtags.comment_id` + publicComment + `
+WHERE comment_hashtags.hashtag_id = @id`
+
+const usageQuery = `
+SELECT count(*) FILTER (WHERE kind = 'post') AS post_count,
+	count(*) FILTER (WHERE kind = 'comment') AS comment_count,
+	min(used_at) AS first_used_at
+FROM (` + usesQuery + `) uses`
+
+const contributorsQuery = `
+SELECT user_id AS id, count(*) AS uses
+FROM (` + usesQuery + `) uses
+GROUP BY user_id
+ORDER BY uses DESC, user_id
+LIMIT @limit`
+
+// relatedQuery counts the other hashtags on the public posts and comments
+// that use the hashtag.
+const relatedQuery = `
+SELECT hashtag_id AS id, count(*) AS uses
+FROM (
+	SELECT other.hashtag_id
+	FROM post_hashtags own
+	JOIN posts ON posts.id = own.post_id` + publicPost + `
+	JOIN post_hashtags other ON other.post_id = own.post_id AND other.hashtag_id <> own.hashtag_id
+	WHERE own.hashtag_id = @id
+	UNION ALL
+	SELECT other.hashtag_id
+	FROM comment_hashtags own
+	JOIN comments ON comments.id = own.comment_id` + publicComment + `
+	JOIN comment_hashtags other ON other.comment_id = own.comment_id AND other.hashtag_id <> own.hashtag_id
+	WHERE own.hashtag_id = @id
+) related
+GROUP BY hashtag_id
+ORDER BY uses DESC, hashtag_id
+LIMIT @limit`
+
+// countRow is an ID with a number of uses.
+type countRow struct {
+	ID   uint
+	Uses int
+}
+
+func (s *hashtagStore) Details(hashtagID uint, limit int) (*store.HashtagDetails, error) {
+	if err := s.db.Select("id").First(&models.Hashtag{}, hashtagID).Error; err != nil {
+		return nil, translate(err)
+	}
+	args := map[string]interface{}{"id": hashtagID, "limit": limit}
+
+	var usage struct {
+		PostCount    int
+		CommentCount int
+		FirstUsedAt  *time.Time
+	}
+	if err := s.db.Raw(usageQuery, args).Scan(&usage).Error; err != nil {
+		return nil, translate(err)
+	}
+	details := &store.HashtagDetails{
+		PostCount:       usage.PostCount,
+		CommentCount:    usage.CommentCount,
+		FirstUsedAt:     usage.FirstUsedAt,
+		TopContributors: []store.HashtagContributor{},
+		Related:         []store.RelatedHashtag{},
+	}
+
+	var contributors []countRow
+	if err := s.db.Raw(contributorsQuery, args).Scan(&contributors).Error; err != nil {
+		return nil, translate(err)
+	}
+	var users []models.User
+	if len(contributors) > 0 {
+		if err := s.db.Where("id IN ?", rowIDs(contributors)).Find(&users).Error; err != nil {
+			return nil, translate(err)
+		}
+	}
+	for _, row := range contributors {
+		for _, user := range users {
+			if user.ID == row.ID {
+				details.TopContributors = append(details.TopContributors, store.HashtagContributor{User: user, Uses: row.Uses})
+			}
+		}
+	}
+
+	var related []countRow
+	if err := s.db.Raw(relatedQuery, args).Scan(&related).Error; err != nil {
+		return nil, translate(err)
+	}
+	var hashtags []models.Hashtag
+	if len(related) > 0 {
+		if err := s.db.Where("id IN ?", rowIDs(related)).Find(&hashtags).Error; err != nil {
+			return nil, translate(err)
+		}
+	}
+	for _, row := range related {
+		for _, hashtag := range hashtags {
+			if hashtag.ID == row.ID {
+				details.Related = append(details.Related, store.RelatedHashtag{Hashtag: hashtag, Uses: row.Uses})
+			}
+		}
+	}
+	return details, nil
+}
+
+func rowIDs(rows []countRow) []uint {
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return ids
+}
+
+// contentQuery lists the live posts and comments using a hashtag by the
+// created_at and id that hashtag listings are keyed on.
+const contentQuery = `
+SELECT 'post' AS kind, posts.id, posts.created_at
+FROM post_hashtags
+JOIN posts ON posts.id = post_hashtags.post_id AND posts.deleted_at IS NULL
+WHERE post_hashtags.hashtag_id = @id
+UNION ALL
+SELECT 'comment', comments.id, comments.created_at
+FROM comment_hashtags
+JOIN comments ON comments.id = comment_hashtags.comment_id AND comments.deleted_at IS NULL
+WHERE comment_hashtags.hashtag_id = @id`
+
+// contentRow is one entry of contentQuery.
+type contentRow struct {
+	Kind      string
+	ID        uint
+	CreatedAt time.Time
+}
+
+func (s *hashtagStore) ListContent(hashtagID uint, page store.PageRequest) (store.Page[store.HashtagItem], error) {
+	var rows []contentRow
+	content := s.db.Raw(contentQuery, map[string]interface{}{"id": hashtagID})
+	q := s.db.Table("(?) AS items", content).Select("kind, id, created_at")
+	if err := paginate(q, "created_at", "id", page).Scan(&rows).Error; err != nil {
+		return store.Page[store.HashtagItem]{}, translate(err)
+	}
+
+	var postIDs, commentIDs []uint
+	for _, row := range rows {
+		if row.Kind == "post" {
+			postIDs = append(postIDs, row.ID)
+		} else {
+			commentIDs = append(commentIDs, row.ID)
+		}
+	}
+
+	posts := map[uint]models.Post{}
+	if len(postIDs) > 0 {
+		var found []models.Post
+		if err := s.db.Preload("User").Preload("Tags").Preload("Hashtags").
+			Where("id IN ?", postIDs).Find(&found).Error; err != nil {
+			return store.Page[store.HashtagItem]{}, translate(err)
+		}
+		for _, post := range found {
+			posts[post.ID] = post
+		}
+	}
+	comments := map[uint]models.Comment{}
+	if len(commentIDs) > 0 {
+		var found []models.Comment
+		if err := s.db.Preload("User").Preload("Tags").Preload("Hashtags").
+			Where("id IN ?", commentIDs).Find(&found).Error; err != nil {
+			return store.Page[store.HashtagItem]{}, translate(err)
+		}
+		for _, comment := range found {
+			comments[comment.ID] = comment
+		}
+	}
+
+	// Keep the listing order, skipping anything deleted in between
+	items := make([]store.HashtagItem, 0, len(rows))
+	for _, row := range rows {
+		if post, ok := posts[row.ID]; ok && row.Kind == "post" {
+			items = append(items, store.HashtagItem{Post: &post})
+		} else if comment, ok := comments[row.ID]; ok && row.Kind == "comment" {
+			items = append(items, store.HashtagItem{Comment: &comment})
+		}
+	}
+	return store.NewPage(items, page.Limit, store.HashtagItem.Cursor), nil
+}
+
+func (s *hashtagStore) ToggleFollow(userID, hashtagID uint) (bool, error) {
+	following := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND hashtag_id = ?", userID, hashtagID).Delete(&models.HashtagFollow{})
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+		following = true
+		return tx.Create(&models.HashtagFollow{UserID: userID, HashtagID: hashtagID}).Error
+	})
+	return following, translate(err)
+}
+
+func (s *hashtagStore) FollowedIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	if err := s.db.Model(&models.HashtagFollow{}).Where("user_id = ?", userID).
+		Order("hashtag_id").Pluck("hashtag_id", &ids).Error; err != nil {
+		return nil, translate(err)
+	}
+	return ids, nil
+}
+
+// followedHashtagRow is a hashtag joined with the time the user followed it.
+type followedHashtagRow struct {
+	models.Hashtag
+	FollowedAt time.Time
+}
+
+func (s *hashtagStore) Followed(userID uint, page store.PageRequest) (store.Page[models.Hashtag], error) {
+	var rows []followedHashtagRow
+	q := s.db.Table("hashtags").
+		Select("hashtags.*, hashtag_follows.created_at AS followed_at").
+		Joins("JOIN hashtag_follows ON hashtags.id = hashtag_follows.hashtag_id").
+		Where("hashtag_follows.user_id = ? AND hashtags.deleted_at IS NULL", userID)
+	if err := paginate(q, "hashtag_follows.created_at", "hashtags.id", page).Find(&rows).Error; err != nil {
+		return store.Page[models.Hashtag]{}, translate(err)
+	}
+
+	rowPage := store.NewPage(rows, page.Limit, func(row followedHashtagRow) store.Cursor {
+		return store.Cursor{CreatedAt: row.FollowedAt, ID: row.ID}
+	})
+	hashtags := make([]models.Hashtag, 0, len(rowPage.Items))
+	for _, row := range rowPage.Items {
+		hashtags = append(hashtags, row.Hashtag)
+	}
+	return store.Page[models.Hashtag]{Items: hashtags, Next: rowPage.Next}, nil
+}
+
+// suggestQuery ranks the hashtags starting with a prefix by their uses
+// since a given time. Only the most used candidates by counter are
+// considered, which keeps short prefixes cheap.
+const suggestQuery = `
+WITH candidates AS (
+	SELECT * FROM hashtags
+	WHERE name LIKE @pattern AND deleted_at IS NULL
+	ORDER BY counter DESC
+	LIMIT 200
+)
+SELECT candidates.*, (
+	SELECT count(*) FROM post_hashtags
+	JOIN posts ON posts.id = post_hashtags.post_id` + publicPost + `
+	WHERE post_hashtags.hashtag_id = candidates.id AND post_hashtags.created_at >= @since
+) + (
+	SELECT count(*) FROM comment_hashtags
+	JOIN comments ON comments.id = comment_hashtags.comment_id` + publicComment + `
+	WHERE comment_hashtags.hashtag_id = candidates.id AND comment_hashtags.created_at >= @since
+) AS recent_uses
+FROM candidates
+ORDER BY recent_uses DESC, counter DESC, name
+LIMIT @limit`
+
+// hashtagSuggestionRow is a hashtag with its recent uses.
+type hashtagSuggestionRow struct {
+	models.Hashtag
+	RecentUses int
+}
+
+func (s *hashtagStore) Suggest(prefix string, since time.Time, limit int) ([]store.HashtagSuggestion, error) {
+	var rows []hashtagSuggestionRow
+	if err := s.db.Raw(suggestQuery, map[string]interface{}{
+		"pattern": escapeLike(prefix) + "%",
+		"since":   since,
+		"limit":   limit,
+	}).Scan(&rows).Error; err != nil {
+		return nil, translate(err)
+	}
+
+	suggestions := make([]store.HashtagSuggestion, 0, len(rows))
+	for _, row := range rows {
+		suggestions = append(suggestions, store.HashtagSuggestion{Hashtag: row.Hashtag, RecentUses: row.RecentUses})
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,76 @@
+package gormstore
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type likeStore struct {
+	db *gorm.DB
+}
+
+func (s *likeStore) Toggle(userID, parentID uint, likeType models.LikeType) (bool, error) {
+	liked := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var like models.Like
+		err := tx.Where("user_id = ? AND parent_id = ? AND type = ?", userID, parentID, likeType).First(&like).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		delta := -1
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Create new like
+			like = models.Like{UserID: userID, ParentID: parentID, Type: likeType}
+			if err := tx.Omit("User").Create(&like).Error; err != nil {
+				return err
+			}
+			liked = true
+			delta = 1
+		} else {
+			// Remove existing like; hard delete so the unique index allows a re-like
+			if err := tx.Unscoped().Delete(&like).Error; err != nil {
+				return err
+			}
+		}
+
+		if likeType == models.PostLike {
+			return tx.Model(&models.Post{}).Where("id = ?", parentID).UpdateColumn("like_count", gorm.Expr("like_count + ?", delta)).Error
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", parentID).UpdateColumn("like_count", gorm.Expr("like_count + ?", delta)).Error
+	})
+	return liked, translate(err)
+}
+
+func (s *likeStore) List(parentID uint, likeType models.LikeType, page store.PageRequest) (store.Page[models.Like], error) {
+	var likes []models.Like
+	q := s.db.Preload("User").Where("parent_id = ? AND type = ?", parentID, likeType)
+	if err := paginate(q, "created_at", "id", page).Find(&likes).Error; err != nil {
+		return store.Page[models.Like]{}, translate(err)
+	}
+	return store.NewPage(likes, page.Limit, func(like models.Like) store.Cursor {
+		return store.Cursor{CreatedAt: like.CreatedAt, ID: like.ID}
+	}), nil
+}
+
+func (s *likeStore) Liked(userID uint, likeType models.LikeType, parentIDs []uint) (map[uint]bool, error) {
+	liked := map[uint]bool{}
+	if len(parentIDs) == 0 {
+		return liked, nil
+	}
+
+	var ids []uint
+	err := s.db.Model(&models.Like{}).
+		Where("user_id = ? AND type = ? AND parent_id IN ?", userID, likeType, parentIDs).
+		Pluck("parent_id", &ids).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, nil
+}
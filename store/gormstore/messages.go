@@ -0,0 +1,216 @@
+package gormstore
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type messageStore struct {
+	db *gorm.DB
+}
+
+func (s *messageStore) withMembers() *gorm.DB {
+	return s.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("joined_at, user_id")
+	}).Preload("Members.User")
+}
+
+func (s *messageStore) CreateConversation(conversation *models.Conversation, memberIDs []uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		conversation.LastMessageAt = now
+		if err := tx.Omit("Members").Create(conversation).Error; err != nil {
+			return err
+		}
+
+		members := make([]models.ConversationMember, 0, len(memberIDs))
+		for _, userID := range memberIDs {
+			members = append(members, models.ConversationMember{
+				ConversationID: conversation.ID,
+				UserID:         userID,
+				JoinedAt:       now,
+			})
+		}
+		return tx.Omit("User").Create(&members).Error
+	})
+	if err != nil {
+		return translate(err)
+	}
+	return translate(s.withMembers().First(conversation, conversation.ID).Error)
+}
+
+func (s *messageStore) GetConversation(id uint) (*models.Conversation, error) {
+	var conversation models.Conversation
+	if err := s.withMembers().First(&conversation, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &conversation, nil
+}
+
+func (s *messageStore) GetDirect(directKey string) (*models.Conversation, error) {
+	var conversation models.Conversation
+	if err := s.withMembers().Where("direct_key = ?", directKey).First(&conversation).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &conversation, nil
+}
+
+func (s *messageStore) Member(conversationID, userID uint) (*models.ConversationMember, error) {
+	var member models.ConversationMember
+	err := s.db.Preload("User").
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &member, nil
+}
+
+func (s *messageStore) ListConversations(userID uint, page store.PageRequest) (store.Page[models.Conversation], error) {
+	var conversations []models.Conversation
+	q := s.withMembers().
+		Joins("JOIN conversation_members mine ON mine.conversation_id = conversations.id AND mine.user_id = ?", userID)
+	if err := paginate(q, "conversations.last_message_at", "conversations.id", page).Find(&conversations).Error; err != nil {
+		return store.Page[models.Conversation]{}, translate(err)
+	}
+	if err := s.summarize(userID, conversations); err != nil {
+		return store.Page[models.Conversation]{}, translate(err)
+	}
+	return store.NewPage(conversations, page.Limit, func(conversation models.Conversation) store.Cursor {
+		return store.Cursor{CreatedAt: conversation.LastMessageAt, ID: conversation.ID}
+	}), nil
+}
+
+// summarize fills in the last live message of each conversation and the
+// number of messages from others the user has not read.
+func (s *messageStore) summarize(userID uint, conversations []models.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(conversations))
+	index := make(map[uint]int, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+		index[conversation.ID] = i
+	}
+
+	var last []models.Message
+	err := s.db.Preload("Sender").
+		Table("(?) AS messages", s.db.Raw(`
+			SELECT DISTINCT ON (conversation_id) * FROM messages
+			WHERE conversation_id IN ? AND deleted_at IS NULL
+			ORDER BY conversation_id, created_at DESC, id DESC`, ids)).
+		Find(&last).Error
+	if err != nil {
+		return err
+	}
+	for i := range last {
+		conversations[index[last[i].ConversationID]].LastMessage = &last[i]
+	}
+
+	var unread []struct {
+		ConversationID uint
+		Count          int
+	}
+	err = s.db.Raw(`
+		SELECT m.conversation_id, count(*) AS count FROM messages m
+		JOIN conversation_members cm ON cm.conversation_id = m.conversation_id AND cm.user_id = @user
+		WHERE m.conversation_id IN @ids AND m.deleted_at IS NULL
+			AND m.id > cm.last_read_message_id AND m.sender_id <> @user
+		GROUP BY m.conversation_id`,
+		map[string]interface{}{"user": userID, "ids": ids}).Scan(&unread).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range unread {
+		conversations[index[row.ConversationID]].UnreadCount = row.Count
+	}
+	return nil
+}
+
+func (s *messageStore) Send(message *models.Message) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", message.ConversationID, message.SenderID).
+			Limit(1).Find(&models.ConversationMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return store.ErrNotFound
+		}
+
+		if err := tx.Omit("Sender").Create(message).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.Conversation{}).Where("id = ?", message.ConversationID).
+			Updates(map[string]interface{}{"last_message_at": message.CreatedAt, "updated_at": message.CreatedAt}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", message.ConversationID, message.SenderID).
+			Updates(map[string]interface{}{"last_read_message_id": message.ID, "last_read_at": message.CreatedAt}).Error
+	})
+	if err != nil {
+		return translate(err)
+	}
+	return translate(s.db.Preload("Sender").First(message, message.ID).Error)
+}
+
+func (s *messageStore) GetMessage(id uint) (*models.Message, error) {
+	var message models.Message
+	if err := s.db.Preload("Sender").First(&message, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &message, nil
+}
+
+func (s *messageStore) ListMessages(conversationID uint, page store.PageRequest) (store.Page[models.Message], error) {
+	var messages []models.Message
+	q := s.db.Preload("Sender").Where("conversation_id = ?", conversationID)
+	if err := paginate(q, "created_at", "id", page).Find(&messages).Error; err != nil {
+		return store.Page[models.Message]{}, translate(err)
+	}
+	return store.NewPage(messages, page.Limit, func(message models.Message) store.Cursor {
+		return store.Cursor{CreatedAt: message.CreatedAt, ID: message.ID}
+	}), nil
+}
+
+func (s *messageStore) UpdateMessage(message *models.Message) error {
+	now := time.Now()
+	result := s.db.Model(&models.Message{}).Where("id = ?", message.ID).
+		Updates(map[string]interface{}{"content": message.Content, "edited_at": now})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return store.ErrNotFound
+	}
+	return translate(s.db.Preload("Sender").First(message, message.ID).Error)
+}
+
+func (s *messageStore) DeleteMessage(id uint) error {
+	return translate(s.db.Delete(&models.Message{}, id).Error)
+}
+
+func (s *messageStore) MarkRead(conversationID, userID, messageID uint) (*models.ConversationMember, error) {
+	target := interface{}(messageID)
+	if messageID == 0 {
+		target = s.db.Model(&models.Message{}).Unscoped().
+			Select("COALESCE(max(id), 0)").Where("conversation_id = ?", conversationID)
+	}
+	err := s.db.Model(&models.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ? AND last_read_message_id < (?)", conversationID, userID, target).
+		Updates(map[string]interface{}{
+			"last_read_message_id": gorm.Expr("(?)", target),
+			"last_read_at":         time.Now(),
+		}).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return s.Member(conversationID, userID)
+}
@@ -0,0 +1,168 @@
+package gormstore
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type moderationStore struct {
+	db *gorm.DB
+}
+
+var unresolvedStatuses = []models.ReportStatus{models.ReportOpen, models.ReportTriaged}
+
+func (s *moderationStore) CreateReport(report *models.Report) error {
+	report.Status = models.ReportOpen
+	// The partial unique index turns a second unresolved report into
+	// ErrConflict
+	if err := s.db.Omit("Reporter").Create(report).Error; err != nil {
+		return translate(err)
+	}
+	return translate(s.db.Preload("Reporter").First(report, report.ID).Error)
+}
+
+func (s *moderationStore) GetReport(id uint) (*models.Report, error) {
+	var report models.Report
+	if err := s.db.Preload("Reporter").First(&report, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &report, nil
+}
+
+func (s *moderationStore) Reports(filter store.ReportFilter, page store.PageRequest) (store.Page[models.Report], error) {
+	q := s.db.Preload("Reporter")
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.ReporterID != 0 {
+		q = q.Where("reporter_id = ?", filter.ReporterID)
+	}
+	var reports []models.Report
+	if err := paginate(q, "created_at", "id", page).Find(&reports).Error; err != nil {
+		return store.Page[models.Report]{}, translate(err)
+	}
+	return store.NewPage(reports, page.Limit, func(report models.Report) store.Cursor {
+		return store.Cursor{CreatedAt: report.CreatedAt, ID: report.ID}
+	}), nil
+}
+
+// move moves the reports selected by query from one of the given statuses to
+// status and returns them.
+func move(tx *gorm.DB, from []models.ReportStatus, status models.ReportStatus, moderatorID uint, query string, args ...interface{}) ([]models.Report, error) {
+	now := time.Now()
+	updates := map[string]interface{}{"status": status, "handled_by_id": moderatorID, "updated_at": now}
+	if status.Resolved() {
+		updates["resolved_at"] = now
+	}
+	var reports []models.Report
+	err := tx.Model(&reports).Clauses(clause.Returning{}).
+		Where("status IN ?", from).Where(query, args...).
+		Updates(updates).Error
+	return reports, err
+}
+
+func (s *moderationStore) MoveReport(id uint, from []models.ReportStatus, status models.ReportStatus, moderatorID uint) (*models.Report, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		moved, err := move(tx, from, status, moderatorID, "id = ?", id)
+		if err != nil || len(moved) > 0 {
+			return err
+		}
+		// Tell a missing report from one in another status
+		var count int64
+		if err := tx.Model(&models.Report{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrNotFound
+		}
+		return store.ErrConflict
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	return s.GetReport(id)
+}
+
+func (s *moderationStore) ResolveTarget(target models.ReportTargetType, targetID, moderatorID uint) ([]models.Report, error) {
+	resolved, err := move(s.db, unresolvedStatuses, models.ReportActioned, moderatorID,
+		"target_type = ? AND target_id = ?", target, targetID)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return resolved, nil
+}
+
+func (s *moderationStore) Record(action *models.ModerationAction) error {
+	if err := s.db.Omit("Moderator").Create(action).Error; err != nil {
+		return translate(err)
+	}
+	return translate(s.db.First(&action.Moderator, action.ModeratorID).Error)
+}
+
+func (s *moderationStore) Actions(filter store.ActionFilter, page store.PageRequest) (store.Page[models.ModerationAction], error) {
+	q := s.db.Preload("Moderator")
+	if filter.ReportID != 0 {
+		q = q.Where("report_id = ?", filter.ReportID)
+	}
+	if filter.TargetType != "" {
+		q = q.Where("target_type = ? AND target_id = ?", filter.TargetType, filter.TargetID)
+	}
+	var actions []models.ModerationAction
+	if err := paginate(q, "created_at", "id", page).Find(&actions).Error; err != nil {
+		return store.Page[models.ModerationAction]{}, translate(err)
+	}
+	return store.NewPage(actions, page.Limit, func(action models.ModerationAction) store.Cursor {
+		return store.Cursor{CreatedAt: action.CreatedAt, ID: action.ID}
+	}), nil
+}
+
+func (s *moderationStore) SetHidden(target models.ReportTargetType, targetID uint, hidden bool) error {
+	var model interface{}
+	switch target {
+	case models.ReportedPost:
+		model = &models.Post{}
+	case models.ReportedComment:
+		model = &models.Comment{}
+	default:
+		return store.ErrNotFound
+	}
+	result := s.db.Model(model).Where("id = ?", targetID).UpdateColumn("is_hidden", hidden)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// updateUser sets one column of the user, returning ErrNotFound when there
+// is no such user.
+func (s *moderationStore) updateUser(userID uint, column string, value interface{}) error {
+	result := s.db.Model(&models.User{}).Where("id = ?", userID).UpdateColumn(column, value)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *moderationStore) Suspend(userID uint, until *time.Time) error {
+	return s.updateUser(userID, "suspended_until", until)
+}
+
+func (s *moderationStore) SetRole(userID uint, role models.Role) error {
+	return s.updateUser(userID, "role", role)
+}
+
+func (s *moderationStore) Transaction(fn func(tx *store.Store) error) error {
+	return translate(s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(New(tx))
+	}))
+}
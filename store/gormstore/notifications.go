@@ -0,0 +1,187 @@
+package gormstore
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type notificationStore struct {
+	db *gorm.DB
+}
+
+func (s *notificationStore) Add(n models.Notification, actorID uint) (*models.Notification, bool, error) {
+	var record models.Notification
+	added := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// Join the unread notification of the group, or start one; the no-op
+		// update locks an existing row until the transaction ends
+		err := tx.Raw(`
+			INSERT INTO notifications (user_id, type, target_type, target_id, group_key, actor_count, last_actor_id, created_at, updated_at)
+			VALUES (@user, @type, @targetType, @targetID, @group, 0, @actor, @now, @now)
+			ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+			DO UPDATE SET group_key = EXCLUDED.group_key
+			RETURNING *`,
+			map[string]interface{}{
+				"user":       n.UserID,
+				"type":       n.Type,
+				"targetType": n.TargetType,
+				"targetID":   n.TargetID,
+				"group":      n.GroupKey,
+				"actor":      actorID,
+				"now":        now,
+			}).Scan(&record).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.NotificationActor{
+			NotificationID: record.ID,
+			UserID:         actorID,
+			CreatedAt:      now,
+		})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		added = true
+
+		return tx.Raw(`
+			UPDATE notifications SET actor_count = actor_count + 1, last_actor_id = ?, updated_at = ?
+			WHERE id = ? RETURNING *`, actorID, now, record.ID).Scan(&record).Error
+	})
+	if err != nil {
+		return nil, false, translate(err)
+	}
+	return &record, added, nil
+}
+
+func (s *notificationStore) Retract(userID uint, groupKey string, actorID uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var record models.Notification
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND group_key = ? AND read_at IS NULL", userID, groupKey).
+			First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("notification_id = ? AND user_id = ?", record.ID, actorID).Delete(&models.NotificationActor{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if record.ActorCount <= 1 {
+			return tx.Delete(&record).Error
+		}
+		return tx.Exec(`
+			UPDATE notifications SET actor_count = actor_count - 1, last_actor_id = (
+				SELECT user_id FROM notification_actors WHERE notification_id = @id
+				ORDER BY created_at DESC, user_id DESC LIMIT 1
+			)
+			WHERE id = @id`, map[string]interface{}{"id": record.ID}).Error
+	})
+	return translate(err)
+}
+
+func (s *notificationStore) List(userID uint, unreadOnly bool, actors int, page store.PageRequest) (store.Page[models.Notification], error) {
+	var notifications []models.Notification
+	q := s.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	if err := paginate(q, "updated_at", "id", page).Find(&notifications).Error; err != nil {
+		return store.Page[models.Notification]{}, translate(err)
+	}
+	if err := s.loadActors(notifications, actors); err != nil {
+		return store.Page[models.Notification]{}, translate(err)
+	}
+	return store.NewPage(notifications, page.Limit, func(n models.Notification) store.Cursor {
+		return store.Cursor{CreatedAt: n.UpdatedAt, ID: n.ID}
+	}), nil
+}
+
+// loadActors fills in up to limit of the most recent actors of each
+// notification.
+func (s *notificationStore) loadActors(notifications []models.Notification, limit int) error {
+	ids := make([]uint, len(notifications))
+	for i, n := range notifications {
+		ids[i] = n.ID
+		notifications[i].Actors = []models.User{}
+	}
+	if len(ids) == 0 || limit <= 0 {
+		return nil
+	}
+
+	var rows []models.NotificationActor
+	err := s.db.Raw(`
+		SELECT notification_id, user_id, created_at FROM (
+			SELECT notification_id, user_id, created_at,
+				row_number() OVER (PARTITION BY notification_id ORDER BY created_at DESC, user_id DESC) AS n
+			FROM notification_actors WHERE notification_id IN @ids
+		) ranked
+		WHERE n <= @limit
+		ORDER BY notification_id, n`,
+		map[string]interface{}{"ids": ids, "limit": limit}).Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uint, len(rows))
+	for i, row := range rows {
+		userIDs[i] = row.UserID
+	}
+	var users []models.User
+	if len(userIDs) > 0 {
+		if err := s.db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return err
+		}
+	}
+	byID := make(map[uint]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	index := make(map[uint]int, len(notifications))
+	for i, n := range notifications {
+		index[n.ID] = i
+	}
+	for _, row := range rows {
+		if user, ok := byID[row.UserID]; ok {
+			i := index[row.NotificationID]
+			notifications[i].Actors = append(notifications[i].Actors, user)
+		}
+	}
+	return nil
+}
+
+func (s *notificationStore) MarkRead(userID uint, ids []uint) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND id IN ? AND read_at IS NULL", userID, ids).
+		Update("read_at", time.Now())
+	return int(result.RowsAffected), translate(result.Error)
+}
+
+func (s *notificationStore) MarkAllRead(userID uint) (int, error) {
+	result := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return int(result.RowsAffected), translate(result.Error)
+}
+
+func (s *notificationStore) UnreadCount(userID uint) (int, error) {
+	var count int64
+	err := s.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return int(count), translate(err)
+}
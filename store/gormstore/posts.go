@@ -0,0 +1,180 @@
+package gormstore
+
+import (
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type postStore struct {
+	db *gorm.DB
+}
+
+func (s *postStore) withAssociations() *gorm.DB {
+	return s.db.Preload("User").Preload("Tags").Preload("Hashtags")
+}
+
+func (s *postStore) Create(post *models.Post, hashtags []string, tagUserIDs []uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		post.HasHashtag = len(hashtags) > 0
+		post.HasTag = len(tagUserIDs) > 0
+		if err := tx.Omit("User", "Tags", "Hashtags").Create(post).Error; err != nil {
+			return err
+		}
+
+		// Handle hashtags
+		for _, name := range hashtags {
+			hashtag, err := findOrCreateHashtag(tx, name)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(hashtag).UpdateColumn("counter", gorm.Expr("counter + ?", 1)).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.PostHashtag{PostID: post.ID, HashtagID: hashtag.ID}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Handle tags
+		for _, userID := range tagUserIDs {
+			if err := tx.Create(&models.PostTag{PostID: post.ID, UserID: userID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return translate(err)
+	}
+
+	// Load the complete post with associations
+	return translate(s.withAssociations().First(post, post.ID).Error)
+}
+
+func (s *postStore) GetByID(id uint) (*models.Post, error) {
+	var post models.Post
+	if err := s.withAssociations().First(&post, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &post, nil
+}
+
+func (s *postStore) GetByIDs(ids []uint) ([]models.Post, error) {
+	posts := []models.Post{}
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	if err := s.withAssociations().Where("posts.id IN ?", ids).Find(&posts).Error; err != nil {
+		return nil, translate(err)
+	}
+	return posts, nil
+}
+
+func (s *postStore) List(page store.PageRequest) (store.Page[models.Post], error) {
+	var posts []models.Post
+	if err := paginate(s.withAssociations(), "posts.created_at", "posts.id", page).
+		Find(&posts).Error; err != nil {
+		return store.Page[models.Post]{}, translate(err)
+	}
+	return store.NewPage(posts, page.Limit, postCursor), nil
+}
+
+func (s *postStore) ListByHashtag(hashtagID uint, page store.PageRequest) (store.Page[models.Post], error) {
+	var posts []models.Post
+	q := s.withAssociations().
+		Joins("JOIN post_hashtags ON posts.id = post_hashtags.post_id").
+		Where("post_hashtags.hashtag_id = ?", hashtagID)
+	if err := paginate(q, "posts.created_at", "posts.id", page).Find(&posts).Error; err != nil {
+		return store.Page[models.Post]{}, translate(err)
+	}
+	return store.NewPage(posts, page.Limit, postCursor), nil
+}
+
+func (s *postStore) ListByAuthor(authorID uint, page store.PageRequest) (store.Page[models.Post], error) {
+	var posts []models.Post
+	q := s.withAssociations().Where("posts.user_id = ?", authorID)
+	if err := paginate(q, "posts.created_at", "posts.id", page).Find(&posts).Error; err != nil {
+		return store.Page[models.Post]{}, translate(err)
+	}
+	return store.NewPage(posts, page.Limit, postCursor), nil
+}
+
+func (s *postStore) Timeline(userID uint, pullAuthorIDs, hashtagIDs []uint, includeTagged bool, page store.PageRequest) (store.Page[models.Post], error) {
+	// Entries only count while the follow that produced them exists, so a
+	// backfill racing an unfollow cannot leave stale posts behind.
+	entries := s.db.Model(&models.TimelineEntry{}).Select("timeline_entries.post_id").
+		Joins("JOIN follows ON follows.follower_id = timeline_entries.user_id AND follows.following_id = timeline_entries.author_id AND follows.deleted_at IS NULL").
+		Where("timeline_entries.user_id = ?", userID)
+	conditions := "posts.id IN (?) OR posts.user_id = ?"
+	args := []interface{}{entries, userID}
+	if len(pullAuthorIDs) > 0 {
+		conditions += " OR posts.user_id IN ?"
+		args = append(args, pullAuthorIDs)
+	}
+	if len(hashtagIDs) > 0 {
+		followed := s.db.Model(&models.PostHashtag{}).Select("post_id").Where("hashtag_id IN ?", hashtagIDs)
+		conditions += " OR posts.id IN (?)"
+		args = append(args, followed)
+	}
+	if includeTagged {
+		tagged := s.db.Model(&models.PostTag{}).Select("post_id").Where("user_id = ?", userID)
+		conditions += " OR posts.id IN (?)"
+		args = append(args, tagged)
+	}
+
+	var posts []models.Post
+	q := s.withAssociations().Where(conditions, args...)
+	if err := paginate(q, "posts.created_at", "posts.id", page).Find(&posts).Error; err != nil {
+		return store.Page[models.Post]{}, translate(err)
+	}
+	return store.NewPage(posts, page.Limit, postCursor), nil
+}
+
+func (s *postStore) Update(post *models.Post, hashtags []string, tagUserIDs []uint) ([]uint, error) {
+	var tagged []uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		columns := []interface{}{"entities", "image_url", "has_image", "is_quote", "quote_lines", "visibility", "reply_policy"}
+
+		if hashtags != nil {
+			added, removed, err := syncHashtags(tx, "post_hashtags", "post_id", post.ID, hashtags)
+			if err != nil {
+				return err
+			}
+			if err := adjustHashtagCounters(tx, added, 1); err != nil {
+				return err
+			}
+			if err := adjustHashtagCounters(tx, removed, -1); err != nil {
+				return err
+			}
+			post.HasHashtag = len(hashtags) > 0
+			columns = append(columns, "has_hashtag")
+		}
+
+		if tagUserIDs != nil {
+			added, _, err := syncJoin(tx, "post_tags", "post_id", "user_id", post.ID, tagUserIDs)
+			if err != nil {
+				return err
+			}
+			tagged = added
+			post.HasTag = len(tagUserIDs) > 0
+			columns = append(columns, "has_tag")
+		}
+
+		return tx.Model(post).Select("content", columns...).Updates(post).Error
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	return tagged, translate(s.withAssociations().First(post, post.ID).Error)
+}
+
+func (s *postStore) Delete(id uint) error {
+	return translate(s.db.Transaction(func(tx *gorm.DB) error {
+		if err := releaseHashtags(tx, "post_hashtags", "post_id", id); err != nil {
+			return err
+		}
+		return tx.Delete(&models.Post{}, id).Error
+	}))
+}
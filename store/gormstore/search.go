@@ -0,0 +1,189 @@
+package gormstore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type searchStore struct {
+	db *gorm.DB
+}
+
+// headlineOptions configures ts_headline snippets.
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+
+// headline returns the SQL for a snippet of col highlighting the matches of
+// the tsquery named query. The text is HTML-escaped first so that only the
+// <mark> tags are markup.
+func headline(col, query string) string {
+	escaped := fmt.Sprintf("replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')", col)
+	return fmt.Sprintf("ts_headline('english', %s, %s, '%s')", escaped, query, headlineOptions)
+}
+
+// searchRow is one hit, before the matching record is loaded.
+type searchRow struct {
+	ID        uint
+	CreatedAt time.Time
+	Rank      float64
+	Snippet   string
+}
+
+// searchRows pages through the hits selected by q, which must select id,
+// created_at, rank and snippet.
+func (s *searchStore) searchRows(q *gorm.DB, sortBy store.SearchSort, page store.PageRequest) (store.Page[searchRow], error) {
+	hits := s.db.Table("(?) AS hits", q)
+	key := func(row searchRow) store.Cursor { return store.Cursor{ID: row.ID, Rank: row.Rank} }
+	if sortBy == store.SortRecent {
+		hits = paginate(hits, "created_at", "id", page)
+		key = func(row searchRow) store.Cursor { return store.Cursor{CreatedAt: row.CreatedAt, ID: row.ID} }
+	} else {
+		if page.After != nil {
+			hits = hits.Where("(rank, id) < (?, ?)", page.After.Rank, page.After.ID)
+		}
+		hits = hits.Order("rank desc").Order("id desc").Limit(page.Limit + 1)
+	}
+
+	var rows []searchRow
+	if err := hits.Scan(&rows).Error; err != nil {
+		return store.Page[searchRow]{}, translate(err)
+	}
+	return store.NewPage(rows, page.Limit, key), nil
+}
+
+// hitsOf pairs the rows with their loaded records, in the rows' order and
+// skipping records deleted since the search.
+func hitsOf[T any](rows store.Page[searchRow], records []T, id func(T) uint) store.Page[store.SearchHit[T]] {
+	byID := make(map[uint]T, len(records))
+	for _, record := range records {
+		byID[id(record)] = record
+	}
+
+	hits := make([]store.SearchHit[T], 0, len(rows.Items))
+	for _, row := range rows.Items {
+		if record, ok := byID[row.ID]; ok {
+			hits = append(hits, store.SearchHit[T]{Item: record, Rank: row.Rank, Snippet: row.Snippet})
+		}
+	}
+	return store.Page[store.SearchHit[T]]{Items: hits, Next: rows.Next}
+}
+
+func rowIDsOf(rows []searchRow) []uint {
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return ids
+}
+
+// filter applies the author and date range filters to the table.
+func filter(q *gorm.DB, table string, query store.SearchQuery) *gorm.DB {
+	if query.AuthorID != nil {
+		q = q.Where(table+".user_id = ?", *query.AuthorID)
+	}
+	if query.From != nil {
+		q = q.Where(table+".created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		q = q.Where(table+".created_at < ?", *query.To)
+	}
+	return q
+}
+
+func (s *searchStore) Posts(query store.SearchQuery, page store.PageRequest) (store.Page[store.SearchHit[models.Post]], error) {
+	q := s.db.Table("posts, websearch_to_tsquery('english', ?) query", query.Text).
+		Select("posts.id, posts.created_at, ts_rank(posts.search_vector, query)::float8 AS rank, " +
+			headline("posts.content", "query") + " AS snippet").
+		Where("posts.search_vector @@ query AND posts.deleted_at IS NULL")
+	q = filter(q, "posts", query)
+	if query.HasImage != nil {
+		q = q.Where("posts.has_image = ?", *query.HasImage)
+	}
+	if query.IsQuote != nil {
+		q = q.Where("posts.is_quote = ?", *query.IsQuote)
+	}
+
+	rows, err := s.searchRows(q, query.Sort, page)
+	if err != nil || len(rows.Items) == 0 {
+		return store.Page[store.SearchHit[models.Post]]{Items: []store.SearchHit[models.Post]{}}, err
+	}
+	var posts []models.Post
+	if err := s.db.Preload("User").Preload("Tags").Preload("Hashtags").
+		Where("id IN ?", rowIDsOf(rows.Items)).Find(&posts).Error; err != nil {
+		return store.Page[store.SearchHit[models.Post]]{}, translate(err)
+	}
+	return hitsOf(rows, posts, func(post models.Post) uint { return post.ID }), nil
+}
+
+func (s *searchStore) Comments(query store.SearchQuery, page store.PageRequest) (store.Page[store.SearchHit[models.Comment]], error) {
+	empty := store.Page[store.SearchHit[models.Comment]]{Items: []store.SearchHit[models.Comment]{}}
+	if query.HasImage != nil || query.IsQuote != nil {
+		// Comments have neither images nor quotes
+		return empty, nil
+	}
+
+	q := s.db.Table("comments, websearch_to_tsquery('english', ?) query", query.Text).
+		Select("comments.id, comments.created_at, ts_rank(comments.search_vector, query)::float8 AS rank, " +
+			headline("comments.content", "query") + " AS snippet").
+		Where("comments.search_vector @@ query AND comments.deleted_at IS NULL")
+	q = filter(q, "comments", query)
+
+	rows, err := s.searchRows(q, query.Sort, page)
+	if err != nil || len(rows.Items) == 0 {
+		return empty, err
+	}
+	var comments []models.Comment
+	if err := s.db.Preload("User").Preload("Tags").Preload("Hashtags").
+		Where("id IN ?", rowIDsOf(rows.Items)).Find(&comments).Error; err != nil {
+		return store.Page[store.SearchHit[models.Comment]]{}, translate(err)
+	}
+	return hitsOf(rows, comments, func(comment models.Comment) uint { return comment.ID }), nil
+}
+
+func (s *searchStore) Users(query store.SearchQuery, page store.PageRequest) (store.Page[store.SearchHit[models.User]], error) {
+	// Names and usernames are indexed without stemming, so also try the
+	// query as given
+	q := s.db.Table("users, websearch_to_tsquery('english', ?) stemmed, websearch_to_tsquery('simple', ?) plain", query.Text, query.Text).
+		Select("users.id, users.created_at, ts_rank(users.search_vector, stemmed || plain)::float8 AS rank, " +
+			"CASE WHEN users.bio <> '' THEN " + headline("users.bio", "stemmed") + " ELSE '' END AS snippet").
+		Where("(users.search_vector @@ stemmed OR users.search_vector @@ plain) AND users.deleted_at IS NULL")
+
+	rows, err := s.searchRows(q, store.SortRelevance, page)
+	if err != nil || len(rows.Items) == 0 {
+		return store.Page[store.SearchHit[models.User]]{Items: []store.SearchHit[models.User]{}}, err
+	}
+	var users []models.User
+	if err := s.db.Where("id IN ?", rowIDsOf(rows.Items)).Find(&users).Error; err != nil {
+		return store.Page[store.SearchHit[models.User]]{}, translate(err)
+	}
+	return hitsOf(rows, users, func(user models.User) uint { return user.ID }), nil
+}
+
+func (s *searchStore) Hashtags(query store.SearchQuery, page store.PageRequest) (store.Page[store.SearchHit[models.Hashtag]], error) {
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(query.Text), "#"))
+
+	// % uses the trigram index; the prefix match catches short partial names
+	// whose similarity falls below the threshold
+	q := s.db.Table("hashtags").
+		Select("hashtags.id, hashtags.created_at, similarity(hashtags.name, ?)::float8 AS rank, '' AS snippet", name).
+		Where("(hashtags.name % ? OR hashtags.name LIKE ?) AND hashtags.deleted_at IS NULL", name, escapeLike(name)+"%")
+
+	rows, err := s.searchRows(q, store.SortRelevance, page)
+	if err != nil || len(rows.Items) == 0 {
+		return store.Page[store.SearchHit[models.Hashtag]]{Items: []store.SearchHit[models.Hashtag]{}}, err
+	}
+	var hashtags []models.Hashtag
+	if err := s.db.Where("id IN ?", rowIDsOf(rows.Items)).Find(&hashtags).Error; err != nil {
+		return store.Page[store.SearchHit[models.Hashtag]]{}, translate(err)
+	}
+	return hitsOf(rows, hashtags, func(hashtag models.Hashtag) uint { return hashtag.ID }), nil
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,55 @@
+package gormstore
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type sessionStore struct {
+	db *gorm.DB
+}
+
+func (s *sessionStore) Create(session *models.Session) error {
+	return translate(s.db.Omit("User").Create(session).Error)
+}
+
+func (s *sessionStore) GetByID(id uint) (*models.Session, error) {
+	var session models.Session
+	if err := s.db.First(&session, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+func (s *sessionStore) Rotate(id uint, currentHash, newHash string, expiresAt time.Time) error {
+	result := s.db.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, currentHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  newHash,
+			"previous_token_hash": currentHash,
+			"expires_at":          expiresAt,
+			"last_used_at":        time.Now(),
+		})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *sessionStore) Revoke(id uint) error {
+	return translate(s.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error)
+}
+
+func (s *sessionStore) RevokeAll(userID uint) error {
+	return translate(s.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error)
+}
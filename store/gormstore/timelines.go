@@ -0,0 +1,26 @@
+package gormstore
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sinkedin/models"
+)
+
+type timelineStore struct {
+	db *gorm.DB
+}
+
+func (s *timelineStore) Add(entries []models.TimelineEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return translate(s.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(entries, 500).Error)
+}
+
+func (s *timelineStore) RemovePost(postID uint) error {
+	return translate(s.db.Where("post_id = ?", postID).Delete(&models.TimelineEntry{}).Error)
+}
+
+func (s *timelineStore) RemoveAuthor(userID, authorID uint) error {
+	return translate(s.db.Where("user_id = ? AND author_id = ?", userID, authorID).Delete(&models.TimelineEntry{}).Error)
+}
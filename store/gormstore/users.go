@@ -0,0 +1,110 @@
+package gormstore
+
+import (
+	"gorm.io/gorm"
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type userStore struct {
+	db *gorm.DB
+}
+
+func (s *userStore) Create(user *models.User) error {
+	return translate(s.db.Create(user).Error)
+}
+
+func (s *userStore) GetByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (s *userStore) GetByUsername(username string) (*models.User, error) {
+	var user models.User
+	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (s *userStore) GetByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (s *userStore) UpdateProfile(id uint, update store.ProfileUpdate) (*models.User, error) {
+	changes := map[string]interface{}{}
+	if update.Name != nil {
+		changes["name"] = *update.Name
+	}
+	if update.Username != nil {
+		changes["username"] = *update.Username
+	}
+	if update.Email != nil {
+		changes["email"] = *update.Email
+	}
+	if update.PhoneNumber != nil {
+		changes["phone_number"] = *update.PhoneNumber
+	}
+	if update.Bio != nil {
+		changes["bio"] = *update.Bio
+	}
+	if update.DOB != nil {
+		changes["dob"] = *update.DOB
+	}
+	if update.PhotoURL != nil {
+		changes["photo_url"] = *update.PhotoURL
+	}
+	if update.BannerURL != nil {
+		changes["banner_url"] = *update.BannerURL
+	}
+	if update.IsPrivate != nil {
+		changes["is_private"] = *update.IsPrivate
+	}
+
+	if len(changes) > 0 {
+		if err := s.db.Model(&models.User{}).Where("id = ?", id).Updates(changes).Error; err != nil {
+			return nil, translate(err)
+		}
+	}
+	return s.GetByID(id)
+}
+
+func (s *userStore) Delete(id uint) error {
+	return translate(s.db.Delete(&models.User{}, id).Error)
+}
+
+// suggestionRow is a user with their relationship to the viewer.
+type suggestionRow struct {
+	models.User
+	Following  bool
+	FollowedBy bool
+}
+
+func (s *userStore) Suggest(viewerID uint, prefix string, limit int) ([]store.UserSuggestion, error) {
+	var rows []suggestionRow
+	pattern := escapeLike(prefix) + "%"
+	err := s.db.Table("users").
+		Select("users.*, f1.follower_id IS NOT NULL AS following, f2.follower_id IS NOT NULL AS followed_by").
+		Joins("LEFT JOIN follows f1 ON f1.follower_id = ? AND f1.following_id = users.id AND f1.deleted_at IS NULL", viewerID).
+		Joins("LEFT JOIN follows f2 ON f2.follower_id = users.id AND f2.following_id = ? AND f2.deleted_at IS NULL", viewerID).
+		Where("(lower(users.username) LIKE ? OR lower(users.name) LIKE ?) AND users.id <> ? AND users.deleted_at IS NULL", pattern, pattern, viewerID).
+		Order("CASE WHEN f1.follower_id IS NOT NULL THEN 0 WHEN f2.follower_id IS NOT NULL THEN 1 ELSE 2 END").
+		Order("users.followers_count DESC").Order("users.username").
+		Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	suggestions := make([]store.UserSuggestion, 0, len(rows))
+	for _, row := range rows {
+		suggestions = append(suggestions, store.UserSuggestion{User: row.User, Relationship: store.Relationship{Following: row.Following, FollowedBy: row.FollowedBy}})
+	}
+	return suggestions, nil
+}
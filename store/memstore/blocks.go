@@ -0,0 +1,164 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type blockStore struct {
+	*state
+}
+
+func (s *blockStore) Block(blockerID, blockedID uint) (store.Relationship, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[blockerID]; !ok {
+		return store.Relationship{}, store.ErrNotFound
+	}
+	if _, ok := s.users[blockedID]; !ok {
+		return store.Relationship{}, store.ErrNotFound
+	}
+
+	key := pair{blockerID, blockedID}
+	if _, exists := s.blocks[key]; !exists {
+		s.blocks[key] = models.Block{BlockerID: blockerID, BlockedID: blockedID, CreatedAt: time.Now()}
+	}
+	for key, request := range s.followRequests {
+		if request.Status == models.FollowRequestPending && (key == pair{blockerID, blockedID} || key == pair{blockedID, blockerID}) {
+			delete(s.followRequests, key)
+		}
+	}
+	return store.Relationship{
+		Following:  s.unfollow(blockerID, blockedID),
+		FollowedBy: s.unfollow(blockedID, blockerID),
+	}, nil
+}
+
+func (s *blockStore) Unblock(blockerID, blockedID uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := pair{blockerID, blockedID}
+	_, exists := s.blocks[key]
+	delete(s.blocks, key)
+	return exists, nil
+}
+
+// listUsers pages through the users keyed by ID in at, most recent first.
+func (s *state) listUsers(at map[uint]time.Time, page store.PageRequest) store.Page[models.User] {
+	var entries []followEntry
+	for id, created := range at {
+		if user, ok := s.users[id]; ok {
+			entries = append(entries, followEntry{user, created})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return newestFirst(entries[i].followed, entries[j].followed, entries[i].user.ID, entries[j].user.ID)
+	})
+
+	entryPage := paginate(entries, page, func(e followEntry) store.Cursor {
+		return store.Cursor{CreatedAt: e.followed, ID: e.user.ID}
+	})
+	users := make([]models.User, 0, len(entryPage.Items))
+	for _, e := range entryPage.Items {
+		users = append(users, e.user)
+	}
+	return store.Page[models.User]{Items: users, Next: entryPage.Next}
+}
+
+func (s *blockStore) Blocked(userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	at := map[uint]time.Time{}
+	for key, block := range s.blocks {
+		if key.a == userID {
+			at[key.b] = block.CreatedAt
+		}
+	}
+	return s.listUsers(at, page), nil
+}
+
+func (s *blockStore) Blocking(userID uint, otherIDs []uint) (map[uint]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blocking := map[uint]bool{}
+	for _, id := range otherIDs {
+		_, blocked := s.blocks[pair{userID, id}]
+		_, blockedBy := s.blocks[pair{id, userID}]
+		if blocked || blockedBy {
+			blocking[id] = true
+		}
+	}
+	return blocking, nil
+}
+
+func (s *blockStore) Mute(userID, mutedID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.users[mutedID]; !ok {
+		return store.ErrNotFound
+	}
+	key := pair{userID, mutedID}
+	if _, exists := s.mutes[key]; !exists {
+		s.mutes[key] = models.Mute{UserID: userID, MutedID: mutedID, CreatedAt: time.Now()}
+	}
+	return nil
+}
+
+func (s *blockStore) Unmute(userID, mutedID uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := pair{userID, mutedID}
+	_, exists := s.mutes[key]
+	delete(s.mutes, key)
+	return exists, nil
+}
+
+func (s *blockStore) Muted(userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	at := map[uint]time.Time{}
+	for key, mute := range s.mutes {
+		if key.a == userID {
+			at[key.b] = mute.CreatedAt
+		}
+	}
+	return s.listUsers(at, page), nil
+}
+
+func (s *blockStore) HiddenIDs(userID uint) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hidden := map[uint]bool{}
+	for key := range s.blocks {
+		if key.a == userID {
+			hidden[key.b] = true
+		} else if key.b == userID {
+			hidden[key.a] = true
+		}
+	}
+	for key := range s.mutes {
+		if key.a == userID {
+			hidden[key.b] = true
+		}
+	}
+	ids := make([]uint, 0, len(hidden))
+	for id := range hidden {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
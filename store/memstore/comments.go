@@ -0,0 +1,244 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type commentStore struct {
+	*state
+}
+
+// hydrate fills in the associations GORM would preload.
+func (s *commentStore) hydrate(comment models.Comment) models.Comment {
+	comment.User = s.users[comment.UserID]
+	comment.Tags = s.usersFor(s.commentTags, comment.ID)
+	comment.Hashtags = s.hashtagsFor(s.commentHashtags, comment.ID)
+	return comment
+}
+
+func (s *commentStore) Create(comment *models.Comment, hashtags []string, tagUserIDs []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[comment.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	if comment.PostID != nil {
+		if _, ok := s.posts[*comment.PostID]; !ok {
+			return store.ErrNotFound
+		}
+	}
+	if comment.ParentCommentID != nil {
+		if _, ok := s.comments[*comment.ParentCommentID]; !ok {
+			return store.ErrNotFound
+		}
+	}
+	for _, userID := range tagUserIDs {
+		if _, ok := s.users[userID]; !ok {
+			return store.ErrNotFound
+		}
+	}
+
+	now := time.Now()
+	record := *comment
+	record.ID = s.nextID("comments")
+	record.ContainsHashtag = len(hashtags) > 0
+	record.ContainsTag = len(tagUserIDs) > 0
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	record.User, record.Post, record.ParentComment, record.Tags, record.Hashtags = models.User{}, nil, nil, nil, nil
+	s.comments[record.ID] = record
+
+	// Handle hashtags
+	for _, name := range hashtags {
+		hashtag := s.findOrCreateHashtag(name, now)
+		hashtag.Counter++
+		s.hashtags[hashtag.ID] = hashtag
+		s.commentHashtags[pair{record.ID, hashtag.ID}] = now
+	}
+
+	// Handle tags
+	for _, userID := range tagUserIDs {
+		s.commentTags[pair{record.ID, userID}] = now
+	}
+
+	// Update comment count on parent
+	s.adjustCommentCounts(record, 1)
+
+	*comment = s.hydrate(record)
+	return nil
+}
+
+func (s *commentStore) GetByID(id uint) (*models.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	comment = s.hydrate(comment)
+	if comment.ParentCommentID != nil {
+		if parent, ok := s.comments[*comment.ParentCommentID]; ok {
+			comment.ParentComment = &parent
+		}
+	}
+	return &comment, nil
+}
+
+func (s *commentStore) ListByPost(postID uint, page store.PageRequest) (store.Page[models.Comment], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, comment := range s.comments {
+		if comment.PostID != nil && *comment.PostID == postID && comment.ParentCommentID == nil {
+			comments = append(comments, s.hydrate(comment))
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return newestFirst(comments[i].CreatedAt, comments[j].CreatedAt, comments[i].ID, comments[j].ID)
+	})
+	return paginate(comments, page, commentCursor), nil
+}
+
+func (s *commentStore) ListReplies(parentID uint, page store.PageRequest) (store.Page[models.Comment], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return paginate(s.replies(parentID), page, commentCursor), nil
+}
+
+// replies returns the hydrated direct replies to a comment, newest first.
+func (s *commentStore) replies(parentID uint) []models.Comment {
+	comments := []models.Comment{}
+	for _, comment := range s.comments {
+		if comment.ParentCommentID != nil && *comment.ParentCommentID == parentID {
+			comments = append(comments, s.hydrate(comment))
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return newestFirst(comments[i].CreatedAt, comments[j].CreatedAt, comments[i].ID, comments[j].ID)
+	})
+	return comments
+}
+
+func (s *commentStore) Thread(rootID uint, opts store.ThreadOptions) (*store.CommentThread, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	root, ok := s.comments[rootID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	// Collect the same rows the recursive query in gormstore selects
+	comments := []models.Comment{s.hydrate(root)}
+	level := []uint{rootID}
+	for depth := 0; depth < opts.Depth && len(level) > 0; depth++ {
+		var next []uint
+		for _, id := range level {
+			replies := s.replies(id)
+			if len(replies) > opts.Replies+1 {
+				replies = replies[:opts.Replies+1]
+			}
+			comments = append(comments, replies...)
+			for i, reply := range replies {
+				if i < opts.Replies {
+					next = append(next, reply.ID)
+				}
+			}
+		}
+		level = next
+	}
+
+	thread, _ := store.BuildThread(rootID, comments, opts)
+	return &thread, nil
+}
+
+func (s *commentStore) Update(comment *models.Comment, hashtags []string, tagUserIDs []uint) ([]uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.comments[comment.ID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	for _, userID := range tagUserIDs {
+		if _, ok := s.users[userID]; !ok {
+			return nil, store.ErrNotFound
+		}
+	}
+
+	now := time.Now()
+	if hashtags != nil {
+		added, removed := s.syncHashtags(s.commentHashtags, comment.ID, hashtags, now)
+		s.adjustHashtagCounters(added, 1)
+		s.adjustHashtagCounters(removed, -1)
+		record.ContainsHashtag = len(hashtags) > 0
+	}
+	var tagged []uint
+	if tagUserIDs != nil {
+		tagged, _ = syncJoin(s.commentTags, comment.ID, tagUserIDs, now)
+		record.ContainsTag = len(tagUserIDs) > 0
+	}
+
+	record.Content = comment.Content
+	record.Entities = comment.Entities
+	record.Type = comment.Type
+	record.Gif = comment.Gif
+	record.UpdatedAt = now
+	s.comments[comment.ID] = record
+
+	*comment = s.hydrate(record)
+	return tagged, nil
+}
+
+func (s *commentStore) Delete(comment *models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.comments[comment.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	// Replies below it go too, so the post loses their counts as well
+	removed := []models.Comment{record}
+	for i := 0; i < len(removed); i++ {
+		for _, reply := range s.comments {
+			if reply.ParentCommentID != nil && *reply.ParentCommentID == removed[i].ID {
+				removed = append(removed, reply)
+			}
+		}
+	}
+	for _, c := range removed {
+		s.adjustCommentCounts(c, -1)
+		s.adjustHashtagCounters(joinedIDs(s.commentHashtags, c.ID), -1)
+	}
+	for _, c := range removed {
+		delete(s.comments, c.ID)
+	}
+	return nil
+}
+
+// adjustCommentCounts moves the comment count of the comment's post and
+// parent comment by delta.
+func (s *commentStore) adjustCommentCounts(comment models.Comment, delta int) {
+	if comment.PostID != nil {
+		if post, ok := s.posts[*comment.PostID]; ok {
+			post.CommentCount += delta
+			s.posts[post.ID] = post
+		}
+	}
+	if comment.ParentCommentID != nil {
+		if parent, ok := s.comments[*comment.ParentCommentID]; ok {
+			parent.CommentCount += delta
+			s.comments[parent.ID] = parent
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type followRequestStore struct {
+	*state
+}
+
+func (s *followRequestStore) hydrate(request models.FollowRequest) models.FollowRequest {
+	request.Requester = s.users[request.RequesterID]
+	return request
+}
+
+func (s *followRequestStore) Create(requesterID, targetID uint) (*models.FollowRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[requesterID]; !ok {
+		return nil, store.ErrNotFound
+	}
+	if _, ok := s.users[targetID]; !ok {
+		return nil, store.ErrNotFound
+	}
+
+	key := pair{requesterID, targetID}
+	request, exists := s.followRequests[key]
+	if !exists {
+		request = models.FollowRequest{ID: s.nextID("follow_requests"), RequesterID: requesterID, TargetID: targetID}
+	}
+	request.Status = models.FollowRequestPending
+	request.CreatedAt = time.Now()
+	request.DecidedAt = nil
+	s.followRequests[key] = request
+
+	request = s.hydrate(request)
+	return &request, nil
+}
+
+// byID returns the key and request with the given ID. The caller must hold
+// the lock.
+func (s *followRequestStore) byID(id uint) (pair, models.FollowRequest, bool) {
+	for key, request := range s.followRequests {
+		if request.ID == id {
+			return key, request, true
+		}
+	}
+	return pair{}, models.FollowRequest{}, false
+}
+
+func (s *followRequestStore) GetByID(id uint) (*models.FollowRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, request, ok := s.byID(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	request = s.hydrate(request)
+	return &request, nil
+}
+
+func (s *followRequestStore) Pending(requesterID, targetID uint) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	request, ok := s.followRequests[pair{requesterID, targetID}]
+	return ok && request.Status == models.FollowRequestPending, nil
+}
+
+func (s *followRequestStore) Cancel(requesterID, targetID uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := pair{requesterID, targetID}
+	request, ok := s.followRequests[key]
+	if !ok || request.Status != models.FollowRequestPending {
+		return false, nil
+	}
+	delete(s.followRequests, key)
+	return true, nil
+}
+
+func (s *followRequestStore) Incoming(userID uint, page store.PageRequest) (store.Page[models.FollowRequest], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	requests := []models.FollowRequest{}
+	for key, request := range s.followRequests {
+		if key.b == userID && request.Status == models.FollowRequestPending {
+			requests = append(requests, s.hydrate(request))
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return newestFirst(requests[i].CreatedAt, requests[j].CreatedAt, requests[i].ID, requests[j].ID)
+	})
+	return paginate(requests, page, func(request models.FollowRequest) store.Cursor {
+		return store.Cursor{CreatedAt: request.CreatedAt, ID: request.ID}
+	}), nil
+}
+
+// decide moves the pending request to status, following the target if it
+// was accepted. The caller must hold the lock.
+func (s *followRequestStore) decide(key pair, request models.FollowRequest, status models.FollowRequestStatus) models.FollowRequest {
+	now := time.Now()
+	request.Status = status
+	request.DecidedAt = &now
+	s.followRequests[key] = request
+	if status == models.FollowRequestAccepted {
+		s.follow(request.RequesterID, request.TargetID, now)
+	}
+	return s.hydrate(request)
+}
+
+func (s *followRequestStore) resolve(id uint, status models.FollowRequestStatus) (*models.FollowRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, request, ok := s.byID(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if request.Status != models.FollowRequestPending {
+		return nil, store.ErrConflict
+	}
+	request = s.decide(key, request, status)
+	return &request, nil
+}
+
+func (s *followRequestStore) Accept(id uint) (*models.FollowRequest, error) {
+	return s.resolve(id, models.FollowRequestAccepted)
+}
+
+func (s *followRequestStore) Reject(id uint) (*models.FollowRequest, error) {
+	return s.resolve(id, models.FollowRequestRejected)
+}
+
+func (s *followRequestStore) AcceptAll(userID uint) ([]uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []uint
+	for key, request := range s.followRequests {
+		if key.b == userID && request.Status == models.FollowRequestPending {
+			s.decide(key, request, models.FollowRequestAccepted)
+			ids = append(ids, key.a)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
@@ -0,0 +1,161 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type followStore struct {
+	*state
+}
+
+func (s *followStore) Toggle(followerID, followingID uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[followerID]; !ok {
+		return false, store.ErrNotFound
+	}
+	if _, ok := s.users[followingID]; !ok {
+		return false, store.ErrNotFound
+	}
+
+	if s.unfollow(followerID, followingID) {
+		return false, nil
+	}
+	s.follow(followerID, followingID, time.Now())
+	return true, nil
+}
+
+// follow creates the follow unless it exists, keeping the follow counts in
+// step.
+func (s *state) follow(followerID, followingID uint, now time.Time) {
+	key := pair{followerID, followingID}
+	if _, ok := s.follows[key]; ok {
+		return
+	}
+	s.follows[key] = models.Follow{FollowerID: followerID, FollowingID: followingID, CreatedAt: now}
+	s.adjustFollowCounts(followerID, followingID, 1)
+}
+
+// unfollow removes the follow, if any, keeping the follow counts in step, and
+// reports whether there was one.
+func (s *state) unfollow(followerID, followingID uint) bool {
+	key := pair{followerID, followingID}
+	if _, ok := s.follows[key]; !ok {
+		return false
+	}
+	delete(s.follows, key)
+	s.adjustFollowCounts(followerID, followingID, -1)
+	return true
+}
+
+func (s *state) adjustFollowCounts(followerID, followingID uint, delta int) {
+	if follower, ok := s.users[followerID]; ok {
+		follower.FollowingCount += delta
+		s.users[followerID] = follower
+	}
+	if following, ok := s.users[followingID]; ok {
+		following.FollowersCount += delta
+		s.users[followingID] = following
+	}
+}
+
+// followEntry is a user paired with the time of the follow that relates them.
+type followEntry struct {
+	user     models.User
+	followed time.Time
+}
+
+// related pages through the users on the other side of the follows selected
+// by match, most recent follow first.
+func (s *followStore) related(match func(pair) (uint, bool), page store.PageRequest) store.Page[models.User] {
+	var entries []followEntry
+	for key, follow := range s.follows {
+		otherID, ok := match(key)
+		if !ok {
+			continue
+		}
+		if user, ok := s.users[otherID]; ok {
+			entries = append(entries, followEntry{user, follow.CreatedAt})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return newestFirst(entries[i].followed, entries[j].followed, entries[i].user.ID, entries[j].user.ID)
+	})
+
+	entryPage := paginate(entries, page, func(e followEntry) store.Cursor {
+		return store.Cursor{CreatedAt: e.followed, ID: e.user.ID}
+	})
+	users := make([]models.User, 0, len(entryPage.Items))
+	for _, e := range entryPage.Items {
+		users = append(users, e.user)
+	}
+	return store.Page[models.User]{Items: users, Next: entryPage.Next}
+}
+
+func (s *followStore) Followers(userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.related(func(key pair) (uint, bool) { return key.a, key.b == userID }, page), nil
+}
+
+func (s *followStore) Following(userID uint, page store.PageRequest) (store.Page[models.User], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.related(func(key pair) (uint, bool) { return key.b, key.a == userID }, page), nil
+}
+
+func (s *followStore) FollowerIDs(userID, afterID uint, limit int) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []uint
+	for key := range s.follows {
+		if key.b == userID && key.a > afterID {
+			ids = append(ids, key.a)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+func (s *followStore) PopularFollowingIDs(userID uint, minFollowers int) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []uint
+	for key := range s.follows {
+		if key.a != userID {
+			continue
+		}
+		if user, ok := s.users[key.b]; ok && user.FollowersCount >= minFollowers {
+			ids = append(ids, key.b)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *followStore) Relationships(userID uint, otherIDs []uint) (map[uint]store.Relationship, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	relationships := map[uint]store.Relationship{}
+	for _, id := range otherIDs {
+		_, following := s.follows[pair{userID, id}]
+		_, followedBy := s.follows[pair{id, userID}]
+		if following || followedBy {
+			relationships[id] = store.Relationship{Following: following, FollowedBy: followedBy}
+		}
+	}
+	return relationships, nil
+}
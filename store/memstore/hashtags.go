@@ -0,0 +1,331 @@
+package memstore
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type hashtagStore struct {
+	*state
+}
+
+func (s *hashtagStore) GetByName(name string) (*models.Hashtag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, hashtag := range s.hashtags {
+		if hashtag.Name == name {
+			return &hashtag, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *hashtagStore) Activity(now time.Time, window, halfLife time.Duration) ([]store.HashtagActivity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start, since := now.Add(-window), now.Add(-2*window)
+	byHashtag := map[uint]*store.HashtagActivity{}
+	count := func(hashtagID uint, usedAt time.Time) {
+		if usedAt.Before(since) || usedAt.After(now) {
+			return
+		}
+		activity, ok := byHashtag[hashtagID]
+		if !ok {
+			activity = &store.HashtagActivity{HashtagID: hashtagID}
+			byHashtag[hashtagID] = activity
+		}
+		if usedAt.Before(start) {
+			activity.PreviousUses++
+			return
+		}
+		activity.Uses++
+		activity.Decayed += math.Pow(0.5, now.Sub(usedAt).Seconds()/halfLife.Seconds())
+	}
+
+	for key, usedAt := range s.postHashtags {
+		if s.publicPost(key.a) {
+			count(key.b, usedAt)
+		}
+	}
+	for key, usedAt := range s.commentHashtags {
+		if s.publicComment(key.a) {
+			count(key.b, usedAt)
+		}
+	}
+
+	activity := make([]store.HashtagActivity, 0, len(byHashtag))
+	for _, a := range byHashtag {
+		activity = append(activity, *a)
+	}
+	sort.Slice(activity, func(i, j int) bool { return activity[i].HashtagID < activity[j].HashtagID })
+	return activity, nil
+}
+
+func (s *hashtagStore) ReplaceTrending(window string, entries []models.TrendingHashtag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make([]models.TrendingHashtag, len(entries))
+	copy(snapshot, entries)
+	s.trending[window] = snapshot
+	return nil
+}
+
+func (s *hashtagStore) Trending(window string, limit int) ([]models.TrendingHashtag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trending := []models.TrendingHashtag{}
+	for _, entry := range s.trending[window] {
+		hashtag, ok := s.hashtags[entry.HashtagID]
+		if !ok {
+			continue
+		}
+		entry.Hashtag = hashtag
+		trending = append(trending, entry)
+	}
+	sort.Slice(trending, func(i, j int) bool { return trending[i].Rank < trending[j].Rank })
+	if len(trending) > limit {
+		trending = trending[:limit]
+	}
+	return trending, nil
+}
+
+// use is one post or comment using a hashtag.
+type use struct {
+	userID uint
+	usedAt time.Time
+	post   bool
+}
+
+// publicPost reports whether the post's hashtag uses count towards the
+// hashtag aggregates: it must be live, public, not hidden and by a public
+// author, as in gormstore.
+func (s *hashtagStore) publicPost(postID uint) bool {
+	post, ok := s.posts[postID]
+	return ok && post.Visibility == models.PublicVisibility && !post.IsHidden && !s.users[post.UserID].IsPrivate
+}
+
+// publicComment reports the same for a comment, which must not be hidden, be
+// by a public author and be on a post that counts.
+func (s *hashtagStore) publicComment(commentID uint) bool {
+	comment, ok := s.comments[commentID]
+	return ok && !comment.IsHidden && !s.users[comment.UserID].IsPrivate &&
+		comment.PostID != nil && s.publicPost(*comment.PostID)
+}
+
+// uses returns the uses of the hashtag by public posts and comments, along
+// with the other hashtags those posts and comments carry.
+func (s *hashtagStore) uses(hashtagID uint) ([]use, map[uint]int) {
+	var uses []use
+	related := map[uint]int{}
+	for key, usedAt := range s.postHashtags {
+		if key.b != hashtagID || !s.publicPost(key.a) {
+			continue
+		}
+		post := s.posts[key.a]
+		uses = append(uses, use{post.UserID, usedAt, true})
+		for _, other := range joinedIDs(s.postHashtags, post.ID) {
+			if other != hashtagID {
+				related[other]++
+			}
+		}
+	}
+	for key, usedAt := range s.commentHashtags {
+		if key.b != hashtagID || !s.publicComment(key.a) {
+			continue
+		}
+		comment := s.comments[key.a]
+		uses = append(uses, use{comment.UserID, usedAt, false})
+		for _, other := range joinedIDs(s.commentHashtags, comment.ID) {
+			if other != hashtagID {
+				related[other]++
+			}
+		}
+	}
+	return uses, related
+}
+
+func (s *hashtagStore) Details(hashtagID uint, limit int) (*store.HashtagDetails, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.hashtags[hashtagID]; !ok {
+		return nil, store.ErrNotFound
+	}
+
+	uses, related := s.uses(hashtagID)
+	details := &store.HashtagDetails{TopContributors: []store.HashtagContributor{}, Related: []store.RelatedHashtag{}}
+	byUser := map[uint]int{}
+	for _, u := range uses {
+		if u.post {
+			details.PostCount++
+		} else {
+			details.CommentCount++
+		}
+		if details.FirstUsedAt == nil || u.usedAt.Before(*details.FirstUsedAt) {
+			usedAt := u.usedAt
+			details.FirstUsedAt = &usedAt
+		}
+		byUser[u.userID]++
+	}
+
+	for _, id := range topCounts(byUser, limit) {
+		if user, ok := s.users[id]; ok {
+			details.TopContributors = append(details.TopContributors, store.HashtagContributor{User: user, Uses: byUser[id]})
+		}
+	}
+	for _, id := range topCounts(related, limit) {
+		if hashtag, ok := s.hashtags[id]; ok {
+			details.Related = append(details.Related, store.RelatedHashtag{Hashtag: hashtag, Uses: related[id]})
+		}
+	}
+	return details, nil
+}
+
+// topCounts returns up to limit keys with the highest counts, breaking ties
+// by the lower ID.
+func topCounts(counts map[uint]int, limit int) []uint {
+	ids := make([]uint, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if counts[ids[i]] != counts[ids[j]] {
+			return counts[ids[i]] > counts[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+func (s *hashtagStore) ListContent(hashtagID uint, page store.PageRequest) (store.Page[store.HashtagItem], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts, comments := &postStore{s.state}, &commentStore{s.state}
+	var items []store.HashtagItem
+	for key := range s.postHashtags {
+		if post, ok := s.posts[key.a]; ok && key.b == hashtagID {
+			post = posts.hydrate(post)
+			items = append(items, store.HashtagItem{Post: &post})
+		}
+	}
+	for key := range s.commentHashtags {
+		if comment, ok := s.comments[key.a]; ok && key.b == hashtagID {
+			comment = comments.hydrate(comment)
+			items = append(items, store.HashtagItem{Comment: &comment})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i].Cursor(), items[j].Cursor()
+		return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+	})
+
+	return paginate(items, page, store.HashtagItem.Cursor), nil
+}
+
+func (s *hashtagStore) ToggleFollow(userID, hashtagID uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return false, store.ErrNotFound
+	}
+	if _, ok := s.hashtags[hashtagID]; !ok {
+		return false, store.ErrNotFound
+	}
+
+	key := pair{userID, hashtagID}
+	if _, exists := s.hashtagFollows[key]; exists {
+		delete(s.hashtagFollows, key)
+		return false, nil
+	}
+	s.hashtagFollows[key] = time.Now()
+	return true, nil
+}
+
+func (s *hashtagStore) FollowedIDs(userID uint) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := joinedIDs(s.hashtagFollows, userID)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *hashtagStore) Followed(userID uint, page store.PageRequest) (store.Page[models.Hashtag], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type followed struct {
+		hashtag    models.Hashtag
+		followedAt time.Time
+	}
+	var entries []followed
+	for key, followedAt := range s.hashtagFollows {
+		if hashtag, ok := s.hashtags[key.b]; ok && key.a == userID {
+			entries = append(entries, followed{hashtag, followedAt})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return newestFirst(entries[i].followedAt, entries[j].followedAt, entries[i].hashtag.ID, entries[j].hashtag.ID)
+	})
+
+	entryPage := paginate(entries, page, func(e followed) store.Cursor {
+		return store.Cursor{CreatedAt: e.followedAt, ID: e.hashtag.ID}
+	})
+	hashtags := make([]models.Hashtag, 0, len(entryPage.Items))
+	for _, e := range entryPage.Items {
+		hashtags = append(hashtags, e.hashtag)
+	}
+	return store.Page[models.Hashtag]{Items: hashtags, Next: entryPage.Next}, nil
+}
+
+func (s *hashtagStore) Suggest(prefix string, since time.Time, limit int) ([]store.HashtagSuggestion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recent := map[uint]int{}
+	for key, usedAt := range s.postHashtags {
+		if s.publicPost(key.a) && !usedAt.Before(since) {
+			recent[key.b]++
+		}
+	}
+	for key, usedAt := range s.commentHashtags {
+		if s.publicComment(key.a) && !usedAt.Before(since) {
+			recent[key.b]++
+		}
+	}
+
+	suggestions := []store.HashtagSuggestion{}
+	for _, hashtag := range s.hashtags {
+		if strings.HasPrefix(hashtag.Name, prefix) {
+			suggestions = append(suggestions, store.HashtagSuggestion{Hashtag: hashtag, RecentUses: recent[hashtag.ID]})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.RecentUses != b.RecentUses {
+			return a.RecentUses > b.RecentUses
+		}
+		if a.Hashtag.Counter != b.Hashtag.Counter {
+			return a.Hashtag.Counter > b.Hashtag.Counter
+		}
+		return a.Hashtag.Name < b.Hashtag.Name
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,84 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type likeStore struct {
+	*state
+}
+
+func (s *likeStore) Toggle(userID, parentID uint, likeType models.LikeType) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delta := 1
+	var existing *models.Like
+	for _, like := range s.likes {
+		if like.UserID == userID && like.ParentID == parentID && like.Type == likeType {
+			existing = &like
+			break
+		}
+	}
+
+	if existing == nil {
+		like := models.Like{ID: s.nextID("likes"), UserID: userID, ParentID: parentID, Type: likeType, CreatedAt: time.Now()}
+		s.likes[like.ID] = like
+	} else {
+		delete(s.likes, existing.ID)
+		delta = -1
+	}
+
+	if likeType == models.PostLike {
+		if post, ok := s.posts[parentID]; ok {
+			post.LikeCount += delta
+			s.posts[parentID] = post
+		}
+	} else if comment, ok := s.comments[parentID]; ok {
+		comment.LikeCount += delta
+		s.comments[parentID] = comment
+	}
+
+	return existing == nil, nil
+}
+
+func (s *likeStore) List(parentID uint, likeType models.LikeType, page store.PageRequest) (store.Page[models.Like], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	likes := []models.Like{}
+	for _, like := range s.likes {
+		if like.ParentID == parentID && like.Type == likeType {
+			like.User = s.users[like.UserID]
+			likes = append(likes, like)
+		}
+	}
+	sort.Slice(likes, func(i, j int) bool {
+		return newestFirst(likes[i].CreatedAt, likes[j].CreatedAt, likes[i].ID, likes[j].ID)
+	})
+	return paginate(likes, page, func(like models.Like) store.Cursor {
+		return store.Cursor{CreatedAt: like.CreatedAt, ID: like.ID}
+	}), nil
+}
+
+func (s *likeStore) Liked(userID uint, likeType models.LikeType, parentIDs []uint) (map[uint]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := make(map[uint]bool, len(parentIDs))
+	for _, id := range parentIDs {
+		wanted[id] = true
+	}
+
+	liked := map[uint]bool{}
+	for _, like := range s.likes {
+		if like.UserID == userID && like.Type == likeType && wanted[like.ParentID] {
+			liked[like.ParentID] = true
+		}
+	}
+	return liked, nil
+}
@@ -0,0 +1,294 @@
+// Package memstore is an in-memory implementation of the store interfaces,
+// intended for tests and local development without Postgres.
+package memstore
+
+import (
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+// pair keys the join tables and follows by their two IDs.
+type pair struct {
+	a, b uint
+}
+
+type state struct {
+	mu sync.RWMutex
+	// txMu serializes transactions
+	txMu sync.Mutex
+
+	tables
+}
+
+// tables holds every record. Each field is a map, so that a transaction can
+// copy them all and put them back when it fails.
+type tables struct {
+	lastID map[string]uint
+
+	users    map[uint]models.User
+	posts    map[uint]models.Post
+	hashtags map[uint]models.Hashtag
+	comments map[uint]models.Comment
+	likes    map[uint]models.Like
+	follows  map[pair]models.Follow
+
+	postHashtags    map[pair]time.Time
+	postTags        map[pair]time.Time
+	commentTags     map[pair]time.Time
+	commentHashtags map[pair]time.Time
+
+	// hashtagFollows is keyed by (user, hashtag)
+	hashtagFollows map[pair]time.Time
+
+	// timeline is keyed by (user, post)
+	timeline map[pair]models.TimelineEntry
+
+	sessions map[uint]models.Session
+
+	// trending holds the snapshot of each window
+	trending map[string][]models.TrendingHashtag
+
+	notifications map[uint]models.Notification
+	// notificationActors is keyed by (notification, actor)
+	notificationActors map[pair]time.Time
+
+	conversations map[uint]models.Conversation
+	// members is keyed by (conversation, user)
+	members  map[pair]models.ConversationMember
+	messages map[uint]models.Message
+
+	// blocks is keyed by (blocker, blocked) and mutes by (user, muted)
+	blocks map[pair]models.Block
+	mutes  map[pair]models.Mute
+
+	// followRequests is keyed by (requester, target)
+	followRequests map[pair]models.FollowRequest
+
+	reports           map[uint]models.Report
+	moderationActions map[uint]models.ModerationAction
+}
+
+// New returns an empty in-memory Store.
+func New() *store.Store {
+	s := &state{tables: tables{
+		lastID:             map[string]uint{},
+		users:              map[uint]models.User{},
+		posts:              map[uint]models.Post{},
+		hashtags:           map[uint]models.Hashtag{},
+		comments:           map[uint]models.Comment{},
+		likes:              map[uint]models.Like{},
+		follows:            map[pair]models.Follow{},
+		postHashtags:       map[pair]time.Time{},
+		postTags:           map[pair]time.Time{},
+		commentTags:        map[pair]time.Time{},
+		commentHashtags:    map[pair]time.Time{},
+		hashtagFollows:     map[pair]time.Time{},
+		timeline:           map[pair]models.TimelineEntry{},
+		sessions:           map[uint]models.Session{},
+		trending:           map[string][]models.TrendingHashtag{},
+		notifications:      map[uint]models.Notification{},
+		notificationActors: map[pair]time.Time{},
+		conversations:      map[uint]models.Conversation{},
+		members:            map[pair]models.ConversationMember{},
+		messages:           map[uint]models.Message{},
+		blocks:             map[pair]models.Block{},
+		mutes:              map[pair]models.Mute{},
+		followRequests:     map[pair]models.FollowRequest{},
+		reports:            map[uint]models.Report{},
+		moderationActions:  map[uint]models.ModerationAction{},
+	}}
+	return newStore(s)
+}
+
+func newStore(s *state) *store.Store {
+	return &store.Store{
+		Users:          &userStore{s},
+		Posts:          &postStore{s},
+		Comments:       &commentStore{s},
+		Likes:          &likeStore{s},
+		Follows:        &followStore{s},
+		Hashtags:       &hashtagStore{s},
+		Timelines:      &timelineStore{s},
+		Sessions:       &sessionStore{s},
+		Search:         &searchStore{s},
+		Notifications:  &notificationStore{s},
+		Messages:       &messageStore{s},
+		Blocks:         &blockStore{s},
+		FollowRequests: &followRequestStore{s},
+		Moderation:     &moderationStore{s},
+	}
+}
+
+// clone copies every table. Records are values, so copying the maps is
+// enough to keep later writes from reaching the copy.
+func (t *tables) clone() tables {
+	return tables{
+		lastID:             maps.Clone(t.lastID),
+		users:              maps.Clone(t.users),
+		posts:              maps.Clone(t.posts),
+		hashtags:           maps.Clone(t.hashtags),
+		comments:           maps.Clone(t.comments),
+		likes:              maps.Clone(t.likes),
+		follows:            maps.Clone(t.follows),
+		postHashtags:       maps.Clone(t.postHashtags),
+		postTags:           maps.Clone(t.postTags),
+		commentTags:        maps.Clone(t.commentTags),
+		commentHashtags:    maps.Clone(t.commentHashtags),
+		hashtagFollows:     maps.Clone(t.hashtagFollows),
+		timeline:           maps.Clone(t.timeline),
+		sessions:           maps.Clone(t.sessions),
+		trending:           maps.Clone(t.trending),
+		notifications:      maps.Clone(t.notifications),
+		notificationActors: maps.Clone(t.notificationActors),
+		conversations:      maps.Clone(t.conversations),
+		members:            maps.Clone(t.members),
+		messages:           maps.Clone(t.messages),
+		blocks:             maps.Clone(t.blocks),
+		mutes:              maps.Clone(t.mutes),
+		followRequests:     maps.Clone(t.followRequests),
+		reports:            maps.Clone(t.reports),
+		moderationActions:  maps.Clone(t.moderationActions),
+	}
+}
+
+func (s *state) nextID(table string) uint {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+// findOrCreateHashtag mirrors FirstOrCreate on the hashtags table.
+func (s *state) findOrCreateHashtag(name string, now time.Time) models.Hashtag {
+	for _, hashtag := range s.hashtags {
+		if hashtag.Name == name {
+			return hashtag
+		}
+	}
+	hashtag := models.Hashtag{ID: s.nextID("hashtags"), Name: name, CreatedAt: now, UpdatedAt: now}
+	s.hashtags[hashtag.ID] = hashtag
+	return hashtag
+}
+
+// syncHashtags makes the hashtags joined to owner match names, creating
+// hashtags on first use, and returns the IDs it linked and unlinked.
+func (s *state) syncHashtags(join map[pair]time.Time, owner uint, names []string, now time.Time) (added, removed []uint) {
+	ids := make([]uint, 0, len(names))
+	for _, name := range names {
+		ids = append(ids, s.findOrCreateHashtag(name, now).ID)
+	}
+	return syncJoin(join, owner, ids, now)
+}
+
+// adjustHashtagCounters moves the usage counter of each hashtag by delta.
+func (s *state) adjustHashtagCounters(ids []uint, delta int) {
+	for _, id := range ids {
+		if hashtag, ok := s.hashtags[id]; ok {
+			hashtag.Counter += delta
+			s.hashtags[id] = hashtag
+		}
+	}
+}
+
+// joinedIDs returns the IDs joined to owner through the given join table.
+func joinedIDs(join map[pair]time.Time, owner uint) []uint {
+	var ids []uint
+	for key := range join {
+		if key.a == owner {
+			ids = append(ids, key.b)
+		}
+	}
+	return ids
+}
+
+// syncJoin makes the join rows of owner reference exactly the wanted IDs and
+// returns the IDs it added and removed.
+func syncJoin(join map[pair]time.Time, owner uint, wanted []uint, now time.Time) (added, removed []uint) {
+	want := make(map[uint]bool, len(wanted))
+	for _, id := range wanted {
+		if _, ok := join[pair{owner, id}]; !ok && !want[id] {
+			join[pair{owner, id}] = now
+			added = append(added, id)
+		}
+		want[id] = true
+	}
+	for key := range join {
+		if key.a == owner && !want[key.b] {
+			delete(join, key)
+			removed = append(removed, key.b)
+		}
+	}
+	return added, removed
+}
+
+// usersFor returns the users joined to owner through the given join table,
+// ordered by ID.
+func (s *state) usersFor(join map[pair]time.Time, owner uint) []models.User {
+	users := []models.User{}
+	for key := range join {
+		if key.a != owner {
+			continue
+		}
+		if user, ok := s.users[key.b]; ok {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// hashtagsFor returns the hashtags joined to owner through the given join
+// table, ordered by ID.
+func (s *state) hashtagsFor(join map[pair]time.Time, owner uint) []models.Hashtag {
+	hashtags := []models.Hashtag{}
+	for key := range join {
+		if key.a != owner {
+			continue
+		}
+		if hashtag, ok := s.hashtags[key.b]; ok {
+			hashtags = append(hashtags, hashtag)
+		}
+	}
+	sort.Slice(hashtags, func(i, j int) bool { return hashtags[i].ID < hashtags[j].ID })
+	return hashtags
+}
+
+// newestFirst orders records by creation time, then by ID, both descending.
+func newestFirst(createdA, createdB time.Time, idA, idB uint) bool {
+	if !createdA.Equal(createdB) {
+		return createdA.After(createdB)
+	}
+	return idA > idB
+}
+
+// paginate pages through newest-first items using the same keyset rules as
+// the SQL implementation.
+func paginate[T any](items []T, page store.PageRequest, key func(T) store.Cursor) store.Page[T] {
+	start := 0
+	if page.After != nil {
+		for start < len(items) {
+			k := key(items[start])
+			if page.After.Precedes(k) {
+				break
+			}
+			start++
+		}
+	}
+
+	end := start + page.Limit + 1
+	if end > len(items) {
+		end = len(items)
+	}
+	return store.NewPage(items[start:end], page.Limit, key)
+}
+
+func postCursor(post models.Post) store.Cursor {
+	return store.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+}
+
+func commentCursor(comment models.Comment) store.Cursor {
+	return store.Cursor{CreatedAt: comment.CreatedAt, ID: comment.ID}
+}
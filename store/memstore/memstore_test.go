@@ -0,0 +1,13 @@
+package memstore_test
+
+import (
+	"testing"
+
+	"sinkedin/store"
+	"sinkedin/store/memstore"
+	"sinkedin/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) *store.Store { return memstore.New() })
+}
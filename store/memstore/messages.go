@@ -0,0 +1,268 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type messageStore struct {
+	*state
+}
+
+// hydrateConversation fills in the members GORM would preload, ordered by
+// when they joined.
+func (s *messageStore) hydrateConversation(conversation models.Conversation) models.Conversation {
+	members := []models.ConversationMember{}
+	for key, member := range s.members {
+		if key.a == conversation.ID {
+			member.User = s.users[member.UserID]
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].JoinedAt.Equal(members[j].JoinedAt) {
+			return members[i].JoinedAt.Before(members[j].JoinedAt)
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	conversation.Members = members
+	return conversation
+}
+
+func (s *messageStore) hydrateMessage(message models.Message) models.Message {
+	message.Sender = s.users[message.SenderID]
+	return message
+}
+
+func (s *messageStore) CreateConversation(conversation *models.Conversation, memberIDs []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userID := range memberIDs {
+		if _, ok := s.users[userID]; !ok {
+			return store.ErrNotFound
+		}
+	}
+	if conversation.DirectKey != nil {
+		for _, existing := range s.conversations {
+			if existing.DirectKey != nil && *existing.DirectKey == *conversation.DirectKey {
+				return store.ErrConflict
+			}
+		}
+	}
+
+	now := time.Now()
+	record := *conversation
+	record.ID = s.nextID("conversations")
+	record.LastMessageAt = now
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	record.Members, record.LastMessage, record.UnreadCount = nil, nil, 0
+	s.conversations[record.ID] = record
+
+	for _, userID := range memberIDs {
+		s.members[pair{record.ID, userID}] = models.ConversationMember{
+			ConversationID: record.ID,
+			UserID:         userID,
+			JoinedAt:       now,
+		}
+	}
+
+	*conversation = s.hydrateConversation(record)
+	return nil
+}
+
+func (s *messageStore) GetConversation(id uint) (*models.Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conversation, ok := s.conversations[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	conversation = s.hydrateConversation(conversation)
+	return &conversation, nil
+}
+
+func (s *messageStore) GetDirect(directKey string) (*models.Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, conversation := range s.conversations {
+		if conversation.DirectKey != nil && *conversation.DirectKey == directKey {
+			conversation = s.hydrateConversation(conversation)
+			return &conversation, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *messageStore) Member(conversationID, userID uint) (*models.ConversationMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, ok := s.members[pair{conversationID, userID}]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	member.User = s.users[userID]
+	return &member, nil
+}
+
+// sortedMessages returns the conversation's messages, newest first.
+func (s *messageStore) sortedMessages(conversationID uint) []models.Message {
+	messages := []models.Message{}
+	for _, message := range s.messages {
+		if message.ConversationID == conversationID {
+			messages = append(messages, s.hydrateMessage(message))
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return newestFirst(messages[i].CreatedAt, messages[j].CreatedAt, messages[i].ID, messages[j].ID)
+	})
+	return messages
+}
+
+func (s *messageStore) ListConversations(userID uint, page store.PageRequest) (store.Page[models.Conversation], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	conversations := []models.Conversation{}
+	for key, member := range s.members {
+		if key.b != userID {
+			continue
+		}
+		conversation := s.hydrateConversation(s.conversations[key.a])
+		messages := s.sortedMessages(conversation.ID)
+		if len(messages) > 0 {
+			conversation.LastMessage = &messages[0]
+		}
+		for _, message := range messages {
+			if message.ID > member.LastReadMessageID && message.SenderID != userID {
+				conversation.UnreadCount++
+			}
+		}
+		conversations = append(conversations, conversation)
+	}
+	sort.Slice(conversations, func(i, j int) bool {
+		a, b := conversations[i], conversations[j]
+		return newestFirst(a.LastMessageAt, b.LastMessageAt, a.ID, b.ID)
+	})
+	return paginate(conversations, page, conversationCursor), nil
+}
+
+func (s *messageStore) Send(message *models.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conversation, ok := s.conversations[message.ConversationID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	member, ok := s.members[pair{message.ConversationID, message.SenderID}]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	now := time.Now()
+	record := *message
+	record.ID = s.nextID("messages")
+	record.EditedAt = nil
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	record.Sender = models.User{}
+	s.messages[record.ID] = record
+
+	conversation.LastMessageAt = now
+	conversation.UpdatedAt = now
+	s.conversations[conversation.ID] = conversation
+
+	member.LastReadMessageID = record.ID
+	member.LastReadAt = &now
+	s.members[pair{conversation.ID, member.UserID}] = member
+
+	*message = s.hydrateMessage(record)
+	return nil
+}
+
+func (s *messageStore) GetMessage(id uint) (*models.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	message, ok := s.messages[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	message = s.hydrateMessage(message)
+	return &message, nil
+}
+
+func (s *messageStore) ListMessages(conversationID uint, page store.PageRequest) (store.Page[models.Message], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return paginate(s.sortedMessages(conversationID), page, messageCursor), nil
+}
+
+func (s *messageStore) UpdateMessage(message *models.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.messages[message.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	now := time.Now()
+	record.Content = message.Content
+	record.EditedAt = &now
+	record.UpdatedAt = now
+	s.messages[record.ID] = record
+
+	*message = s.hydrateMessage(record)
+	return nil
+}
+
+func (s *messageStore) DeleteMessage(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.messages, id)
+	return nil
+}
+
+func (s *messageStore) MarkRead(conversationID, userID, messageID uint) (*models.ConversationMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.members[pair{conversationID, userID}]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if messageID == 0 {
+		for _, message := range s.messages {
+			if message.ConversationID == conversationID && message.ID > messageID {
+				messageID = message.ID
+			}
+		}
+	}
+
+	if messageID > member.LastReadMessageID {
+		now := time.Now()
+		member.LastReadMessageID = messageID
+		member.LastReadAt = &now
+		s.members[pair{conversationID, userID}] = member
+	}
+	member.User = s.users[userID]
+	return &member, nil
+}
+
+func conversationCursor(conversation models.Conversation) store.Cursor {
+	return store.Cursor{CreatedAt: conversation.LastMessageAt, ID: conversation.ID}
+}
+
+func messageCursor(message models.Message) store.Cursor {
+	return store.Cursor{CreatedAt: message.CreatedAt, ID: message.ID}
+}
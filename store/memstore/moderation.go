@@ -0,0 +1,237 @@
+package memstore
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type moderationStore struct {
+	*state
+}
+
+func (s *moderationStore) hydrateReport(report models.Report) models.Report {
+	report.Reporter = s.users[report.ReporterID]
+	return report
+}
+
+func (s *moderationStore) CreateReport(report *models.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[report.ReporterID]; !ok {
+		return store.ErrNotFound
+	}
+	for _, other := range s.reports {
+		if other.ReporterID == report.ReporterID && other.TargetType == report.TargetType &&
+			other.TargetID == report.TargetID && !other.Status.Resolved() {
+			return store.ErrConflict
+		}
+	}
+
+	now := time.Now()
+	record := *report
+	record.ID = s.nextID("reports")
+	record.Status = models.ReportOpen
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	record.Reporter = models.User{}
+	s.reports[record.ID] = record
+
+	*report = s.hydrateReport(record)
+	return nil
+}
+
+func (s *moderationStore) GetReport(id uint) (*models.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report, ok := s.reports[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	report = s.hydrateReport(report)
+	return &report, nil
+}
+
+func (s *moderationStore) Reports(filter store.ReportFilter, page store.PageRequest) (store.Page[models.Report], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var reports []models.Report
+	for _, report := range s.reports {
+		if filter.Status != "" && report.Status != filter.Status {
+			continue
+		}
+		if filter.ReporterID != 0 && report.ReporterID != filter.ReporterID {
+			continue
+		}
+		reports = append(reports, s.hydrateReport(report))
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return newestFirst(reports[i].CreatedAt, reports[j].CreatedAt, reports[i].ID, reports[j].ID)
+	})
+	return paginate(reports, page, reportCursor), nil
+}
+
+// move moves the report to status for the moderator. The caller must hold
+// the lock.
+func (s *moderationStore) move(report models.Report, status models.ReportStatus, moderatorID uint, now time.Time) models.Report {
+	report.Status = status
+	report.HandledByID = &moderatorID
+	report.UpdatedAt = now
+	if status.Resolved() {
+		report.ResolvedAt = &now
+	}
+	s.reports[report.ID] = report
+	return s.hydrateReport(report)
+}
+
+func (s *moderationStore) MoveReport(id uint, from []models.ReportStatus, status models.ReportStatus, moderatorID uint) (*models.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, ok := s.reports[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if !slices.Contains(from, report.Status) {
+		return nil, store.ErrConflict
+	}
+	report = s.move(report, status, moderatorID, time.Now())
+	return &report, nil
+}
+
+func (s *moderationStore) ResolveTarget(target models.ReportTargetType, targetID, moderatorID uint) ([]models.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	resolved := []models.Report{}
+	for _, report := range s.reports {
+		if report.TargetType == target && report.TargetID == targetID && !report.Status.Resolved() {
+			resolved = append(resolved, s.move(report, models.ReportActioned, moderatorID, now))
+		}
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].ID < resolved[j].ID })
+	return resolved, nil
+}
+
+func (s *moderationStore) Record(action *models.ModerationAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[action.ModeratorID]; !ok {
+		return store.ErrNotFound
+	}
+	record := *action
+	record.ID = s.nextID("moderation_actions")
+	record.CreatedAt = time.Now()
+	record.Moderator = models.User{}
+	s.moderationActions[record.ID] = record
+
+	record.Moderator = s.users[record.ModeratorID]
+	*action = record
+	return nil
+}
+
+func (s *moderationStore) Actions(filter store.ActionFilter, page store.PageRequest) (store.Page[models.ModerationAction], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var actions []models.ModerationAction
+	for _, action := range s.moderationActions {
+		if filter.ReportID != 0 && (action.ReportID == nil || *action.ReportID != filter.ReportID) {
+			continue
+		}
+		if filter.TargetType != "" && (action.TargetType != filter.TargetType || action.TargetID != filter.TargetID) {
+			continue
+		}
+		action.Moderator = s.users[action.ModeratorID]
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return newestFirst(actions[i].CreatedAt, actions[j].CreatedAt, actions[i].ID, actions[j].ID)
+	})
+	return paginate(actions, page, func(action models.ModerationAction) store.Cursor {
+		return store.Cursor{CreatedAt: action.CreatedAt, ID: action.ID}
+	}), nil
+}
+
+func (s *moderationStore) SetHidden(target models.ReportTargetType, targetID uint, hidden bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch target {
+	case models.ReportedPost:
+		post, ok := s.posts[targetID]
+		if !ok {
+			return store.ErrNotFound
+		}
+		post.IsHidden = hidden
+		s.posts[targetID] = post
+	case models.ReportedComment:
+		comment, ok := s.comments[targetID]
+		if !ok {
+			return store.ErrNotFound
+		}
+		comment.IsHidden = hidden
+		s.comments[targetID] = comment
+	default:
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *moderationStore) Suspend(userID uint, until *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	user.SuspendedUntil = until
+	s.users[userID] = user
+	return nil
+}
+
+func (s *moderationStore) SetRole(userID uint, role models.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	user.Role = role
+	s.users[userID] = user
+	return nil
+}
+
+func reportCursor(report models.Report) store.Cursor {
+	return store.Cursor{CreatedAt: report.CreatedAt, ID: report.ID}
+}
+
+// Transaction runs fn against this store and puts every table back as it was
+// when fn fails. Transactions run one at a time, but other writes are not
+// isolated from them and are lost too when one rolls back.
+func (s *moderationStore) Transaction(fn func(tx *store.Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.RLock()
+	saved := s.clone()
+	s.mu.RUnlock()
+
+	if err := fn(newStore(s.state)); err != nil {
+		s.mu.Lock()
+		s.tables = saved
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
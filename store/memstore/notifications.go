@@ -0,0 +1,182 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type notificationStore struct {
+	*state
+}
+
+// unread returns the ID of the user's unread notification of the group.
+func (s *notificationStore) unread(userID uint, groupKey string) (uint, bool) {
+	for id, n := range s.notifications {
+		if n.UserID == userID && n.GroupKey == groupKey && n.ReadAt == nil {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// actorsOf returns up to limit of the notification's actors, newest first.
+func (s *notificationStore) actorsOf(notificationID uint, limit int) []models.User {
+	var keys []pair
+	for key := range s.notificationActors {
+		if key.a == notificationID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		return newestFirst(s.notificationActors[a], s.notificationActors[b], a.b, b.b)
+	})
+
+	actors := []models.User{}
+	for _, key := range keys {
+		if len(actors) == limit {
+			break
+		}
+		if user, ok := s.users[key.b]; ok {
+			actors = append(actors, user)
+		}
+	}
+	return actors
+}
+
+func (s *notificationStore) Add(n models.Notification, actorID uint) (*models.Notification, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[n.UserID]; !ok {
+		return nil, false, store.ErrNotFound
+	}
+	if _, ok := s.users[actorID]; !ok {
+		return nil, false, store.ErrNotFound
+	}
+
+	now := time.Now()
+	id, ok := s.unread(n.UserID, n.GroupKey)
+	if !ok {
+		n.ID = s.nextID("notifications")
+		n.ActorCount = 0
+		n.ReadAt = nil
+		n.CreatedAt = now
+		n.Actors = nil
+		s.notifications[n.ID] = n
+		id = n.ID
+	}
+
+	record := s.notifications[id]
+	added := false
+	if _, ok := s.notificationActors[pair{id, actorID}]; !ok {
+		s.notificationActors[pair{id, actorID}] = now
+		record.ActorCount++
+		record.LastActorID = actorID
+		record.UpdatedAt = now
+		s.notifications[id] = record
+		added = true
+	}
+	return &record, added, nil
+}
+
+func (s *notificationStore) Retract(userID uint, groupKey string, actorID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.unread(userID, groupKey)
+	if !ok {
+		return nil
+	}
+	if _, ok := s.notificationActors[pair{id, actorID}]; !ok {
+		return nil
+	}
+	delete(s.notificationActors, pair{id, actorID})
+
+	record := s.notifications[id]
+	record.ActorCount--
+	if record.ActorCount <= 0 {
+		delete(s.notifications, id)
+		return nil
+	}
+	if record.LastActorID == actorID {
+		var latest time.Time
+		for key, at := range s.notificationActors {
+			if key.a == id && !at.Before(latest) {
+				latest, record.LastActorID = at, key.b
+			}
+		}
+	}
+	s.notifications[id] = record
+	return nil
+}
+
+func (s *notificationStore) List(userID uint, unreadOnly bool, actors int, page store.PageRequest) (store.Page[models.Notification], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notifications := []models.Notification{}
+	for _, n := range s.notifications {
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			n.Actors = s.actorsOf(n.ID, actors)
+			notifications = append(notifications, n)
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		a, b := notifications[i], notifications[j]
+		return newestFirst(a.UpdatedAt, b.UpdatedAt, a.ID, b.ID)
+	})
+	return paginate(notifications, page, notificationCursor), nil
+}
+
+func (s *notificationStore) MarkRead(userID uint, ids []uint) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	changed := 0
+	for _, id := range ids {
+		if n, ok := s.notifications[id]; ok && n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &now
+			s.notifications[id] = n
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (s *notificationStore) MarkAllRead(userID uint) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	changed := 0
+	for id, n := range s.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &now
+			s.notifications[id] = n
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (s *notificationStore) UnreadCount(userID uint) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, n := range s.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func notificationCursor(n models.Notification) store.Cursor {
+	return store.Cursor{CreatedAt: n.UpdatedAt, ID: n.ID}
+}
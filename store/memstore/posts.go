@@ -0,0 +1,217 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type postStore struct {
+	*state
+}
+
+// hydrate fills in the associations GORM would preload.
+func (s *postStore) hydrate(post models.Post) models.Post {
+	post.User = s.users[post.UserID]
+	post.Tags = s.usersFor(s.postTags, post.ID)
+	post.Hashtags = s.hashtagsFor(s.postHashtags, post.ID)
+	return post
+}
+
+func (s *postStore) Create(post *models.Post, hashtags []string, tagUserIDs []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[post.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	for _, userID := range tagUserIDs {
+		if _, ok := s.users[userID]; !ok {
+			return store.ErrNotFound
+		}
+	}
+
+	now := time.Now()
+	record := *post
+	record.ID = s.nextID("posts")
+	record.HasHashtag = len(hashtags) > 0
+	record.HasTag = len(tagUserIDs) > 0
+	// Mirror the column defaults
+	if record.Visibility == "" {
+		record.Visibility = models.PublicVisibility
+	}
+	if record.ReplyPolicy == "" {
+		record.ReplyPolicy = models.EveryoneReplies
+	}
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	record.User, record.Tags, record.Hashtags = models.User{}, nil, nil
+	s.posts[record.ID] = record
+
+	// Handle hashtags
+	for _, name := range hashtags {
+		hashtag := s.findOrCreateHashtag(name, now)
+		hashtag.Counter++
+		s.hashtags[hashtag.ID] = hashtag
+		s.postHashtags[pair{record.ID, hashtag.ID}] = now
+	}
+
+	// Handle tags
+	for _, userID := range tagUserIDs {
+		s.postTags[pair{record.ID, userID}] = now
+	}
+
+	*post = s.hydrate(record)
+	return nil
+}
+
+func (s *postStore) GetByID(id uint) (*models.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	post, ok := s.posts[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	post = s.hydrate(post)
+	return &post, nil
+}
+
+func (s *postStore) GetByIDs(ids []uint) ([]models.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := []models.Post{}
+	for _, id := range ids {
+		if post, ok := s.posts[id]; ok {
+			posts = append(posts, s.hydrate(post))
+		}
+	}
+	return posts, nil
+}
+
+func (s *postStore) sorted(keep func(models.Post) bool) []models.Post {
+	posts := []models.Post{}
+	for _, post := range s.posts {
+		if keep(post) {
+			posts = append(posts, s.hydrate(post))
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return newestFirst(posts[i].CreatedAt, posts[j].CreatedAt, posts[i].ID, posts[j].ID)
+	})
+	return posts
+}
+
+func (s *postStore) List(page store.PageRequest) (store.Page[models.Post], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := s.sorted(func(models.Post) bool { return true })
+	return paginate(posts, page, postCursor), nil
+}
+
+func (s *postStore) ListByHashtag(hashtagID uint, page store.PageRequest) (store.Page[models.Post], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := s.sorted(func(post models.Post) bool {
+		_, ok := s.postHashtags[pair{post.ID, hashtagID}]
+		return ok
+	})
+	return paginate(posts, page, postCursor), nil
+}
+
+func (s *postStore) ListByAuthor(authorID uint, page store.PageRequest) (store.Page[models.Post], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := s.sorted(func(post models.Post) bool { return post.UserID == authorID })
+	return paginate(posts, page, postCursor), nil
+}
+
+func (s *postStore) Timeline(userID uint, pullAuthorIDs, hashtagIDs []uint, includeTagged bool, page store.PageRequest) (store.Page[models.Post], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pulled := make(map[uint]bool, len(pullAuthorIDs)+1)
+	pulled[userID] = true
+	for _, id := range pullAuthorIDs {
+		pulled[id] = true
+	}
+
+	posts := s.sorted(func(post models.Post) bool {
+		if pulled[post.UserID] {
+			return true
+		}
+		if entry, ok := s.timeline[pair{userID, post.ID}]; ok {
+			// Entries only count while the follow that produced them exists
+			if _, following := s.follows[pair{userID, entry.AuthorID}]; following {
+				return true
+			}
+		}
+		for _, hashtagID := range hashtagIDs {
+			if _, ok := s.postHashtags[pair{post.ID, hashtagID}]; ok {
+				return true
+			}
+		}
+		_, tagged := s.postTags[pair{post.ID, userID}]
+		return includeTagged && tagged
+	})
+	return paginate(posts, page, postCursor), nil
+}
+
+func (s *postStore) Update(post *models.Post, hashtags []string, tagUserIDs []uint) ([]uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.posts[post.ID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	for _, userID := range tagUserIDs {
+		if _, ok := s.users[userID]; !ok {
+			return nil, store.ErrNotFound
+		}
+	}
+
+	now := time.Now()
+	if hashtags != nil {
+		added, removed := s.syncHashtags(s.postHashtags, post.ID, hashtags, now)
+		s.adjustHashtagCounters(added, 1)
+		s.adjustHashtagCounters(removed, -1)
+		record.HasHashtag = len(hashtags) > 0
+	}
+	var tagged []uint
+	if tagUserIDs != nil {
+		tagged, _ = syncJoin(s.postTags, post.ID, tagUserIDs, now)
+		record.HasTag = len(tagUserIDs) > 0
+	}
+
+	record.Content = post.Content
+	record.Entities = post.Entities
+	record.ImageURL = post.ImageURL
+	record.HasImage = post.HasImage
+	record.IsQuote = post.IsQuote
+	record.QuoteLines = post.QuoteLines
+	record.Visibility = post.Visibility
+	record.ReplyPolicy = post.ReplyPolicy
+	record.UpdatedAt = now
+	s.posts[post.ID] = record
+
+	*post = s.hydrate(record)
+	return tagged, nil
+}
+
+func (s *postStore) Delete(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[id]; ok {
+		s.adjustHashtagCounters(joinedIDs(s.postHashtags, id), -1)
+	}
+	delete(s.posts, id)
+	return nil
+}
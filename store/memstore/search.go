@@ -0,0 +1,322 @@
+package memstore
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+// The in-memory search approximates Postgres full-text search closely enough
+// for tests: words are matched exactly (there is no stemming), and ranks
+// count matching words rather than reproducing ts_rank.
+
+type searchStore struct {
+	*state
+}
+
+// snippetWords is the length of a snippet, like MaxWords for ts_headline.
+const snippetWords = 35
+
+// textQuery is a parsed web search query: every clause must match through
+// one of its phrases, and no excluded word may appear.
+type textQuery struct {
+	clauses  [][][]string
+	excluded []string
+}
+
+func parseTextQuery(text string) textQuery {
+	var q textQuery
+	alternative := false
+	for rest := strings.TrimSpace(text); rest != ""; rest = strings.TrimSpace(rest) {
+		var phrase []string
+		switch {
+		case rest[0] == '"':
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			phrase = words(rest[1 : end+1])
+			rest = rest[min(end+2, len(rest)):]
+		default:
+			field, remainder, _ := strings.Cut(rest, " ")
+			rest = remainder
+			if field == "OR" && len(q.clauses) > 0 {
+				alternative = true
+				continue
+			}
+			if strings.HasPrefix(field, "-") {
+				q.excluded = append(q.excluded, words(field[1:])...)
+				continue
+			}
+			phrase = words(field)
+		}
+		if len(phrase) == 0 {
+			continue
+		}
+		if alternative {
+			last := len(q.clauses) - 1
+			q.clauses[last] = append(q.clauses[last], phrase)
+			alternative = false
+		} else {
+			q.clauses = append(q.clauses, [][]string{phrase})
+		}
+	}
+	return q
+}
+
+// words splits text into lowercase words.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// match reports whether the text matches, returning its rank and the words
+// that matched.
+func (q textQuery) match(text string) (float64, map[string]bool) {
+	tokens := words(text)
+	if len(q.clauses) == 0 {
+		return 0, nil
+	}
+	for _, word := range q.excluded {
+		if indexOf(tokens, []string{word}) >= 0 {
+			return 0, nil
+		}
+	}
+
+	matched := map[string]bool{}
+	for _, clause := range q.clauses {
+		found := false
+		for _, phrase := range clause {
+			if indexOf(tokens, phrase) >= 0 {
+				found = true
+				for _, word := range phrase {
+					matched[word] = true
+				}
+			}
+		}
+		if !found {
+			return 0, nil
+		}
+	}
+
+	rank := 0.0
+	for _, token := range tokens {
+		if matched[token] {
+			rank++
+		}
+	}
+	return rank / float64(len(tokens)+1), matched
+}
+
+// indexOf returns where phrase starts in tokens, or -1.
+func indexOf(tokens, phrase []string) int {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		found := true
+		for j, word := range phrase {
+			if tokens[i+j] != word {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+	return -1
+}
+
+// snippet escapes the text and marks the matched words, trimming it to the
+// words around the first match.
+func snippet(text string, matched map[string]bool) string {
+	type span struct{ start, end int }
+	var spans []span
+	start := -1
+	for i, r := range text + " " {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+
+	first := 0
+	for i, s := range spans {
+		if matched[strings.ToLower(text[s.start:s.end])] {
+			first = i
+			break
+		}
+	}
+	from := max(0, min(first-snippetWords/3, len(spans)-snippetWords))
+	to := min(len(spans), from+snippetWords)
+
+	var b strings.Builder
+	pos := 0
+	if from > 0 {
+		b.WriteString("… ")
+		pos = spans[from].start
+	}
+	for _, s := range spans[from:to] {
+		b.WriteString(html.EscapeString(text[pos:s.start]))
+		word := html.EscapeString(text[s.start:s.end])
+		if matched[strings.ToLower(text[s.start:s.end])] {
+			word = "<mark>" + word + "</mark>"
+		}
+		b.WriteString(word)
+		pos = s.end
+	}
+	if to < len(spans) {
+		b.WriteString(" …")
+	} else {
+		b.WriteString(html.EscapeString(text[pos:]))
+	}
+	return b.String()
+}
+
+// sortHits orders hits by rank or recency and pages through them.
+func sortHits[T any](hits []store.SearchHit[T], sortBy store.SearchSort, page store.PageRequest, created func(T) store.Cursor) store.Page[store.SearchHit[T]] {
+	key := func(hit store.SearchHit[T]) store.Cursor {
+		k := created(hit.Item)
+		if sortBy == store.SortRecent {
+			return k
+		}
+		return store.Cursor{ID: k.ID, Rank: hit.Rank}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := key(hits[i]), key(hits[j])
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		return newestFirst(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+	})
+	return paginate(hits, page, key)
+}
+
+func (s *searchStore) Posts(query store.SearchQuery, page store.PageRequest) (store.Page[store.SearchHit[models.Post]], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q := parseTextQuery(query.Text)
+	posts := &postStore{s.state}
+	var hits []store.SearchHit[models.Post]
+	for _, post := range s.posts {
+		if !matchesFilters(query, post.UserID, post.CreatedAt) ||
+			(query.HasImage != nil && post.HasImage != *query.HasImage) ||
+			(query.IsQuote != nil && post.IsQuote != *query.IsQuote) {
+			continue
+		}
+		text := post.Content + " " + post.QuoteLines
+		rank, matched := q.match(text)
+		if rank == 0 {
+			continue
+		}
+		hits = append(hits, store.SearchHit[models.Post]{Item: posts.hydrate(post), Rank: rank, Snippet: snippet(post.Content, matched)})
+	}
+	return sortHits(hits, query.Sort, page, postCursor), nil
+}
+
+func (s *searchStore) Comments(query store.SearchQuery, page store.PageRequest) (store.Page[store.SearchHit[models.Comment]], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q := parseTextQuery(query.Text)
+	comments := &commentStore{s.state}
+	var hits []store.SearchHit[models.Comment]
+	for _, comment := range s.comments {
+		if !matchesFilters(query, comment.UserID, comment.CreatedAt) || query.HasImage != nil || query.IsQuote != nil {
+			continue
+		}
+		rank, matched := q.match(comment.Content)
+		if rank == 0 {
+			continue
+		}
+		hits = append(hits, store.SearchHit[models.Comment]{Item: comments.hydrate(comment), Rank: rank, Snippet: snippet(comment.Content, matched)})
+	}
+	return sortHits(hits, query.Sort, page, commentCursor), nil
+}
+
+// matchesFilters applies the author and date range filters.
+func matchesFilters(query store.SearchQuery, authorID uint, createdAt time.Time) bool {
+	return (query.AuthorID == nil || *query.AuthorID == authorID) &&
+		(query.From == nil || !createdAt.Before(*query.From)) &&
+		(query.To == nil || createdAt.Before(*query.To))
+}
+
+func (s *searchStore) Users(query store.SearchQuery, page store.PageRequest) (store.Page[store.SearchHit[models.User]], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q := parseTextQuery(query.Text)
+	var hits []store.SearchHit[models.User]
+	for _, user := range s.users {
+		// Names and usernames weigh more than the bio
+		nameRank, _ := q.match(user.Name + " " + user.Username)
+		bioRank, matched := q.match(user.Name + " " + user.Username + " " + user.Bio)
+		if bioRank == 0 {
+			continue
+		}
+		hit := store.SearchHit[models.User]{Item: user, Rank: nameRank + 0.4*bioRank}
+		if user.Bio != "" {
+			hit.Snippet = snippet(user.Bio, matched)
+		}
+		hits = append(hits, hit)
+	}
+	return sortHits(hits, store.SortRelevance, page, func(user models.User) store.Cursor {
+		return store.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	}), nil
+}
+
+// minSimilarity mirrors the default pg_trgm similarity threshold.
+const minSimilarity = 0.3
+
+func (s *searchStore) Hashtags(query store.SearchQuery, page store.PageRequest) (store.Page[store.SearchHit[models.Hashtag]], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(query.Text), "#"))
+	var hits []store.SearchHit[models.Hashtag]
+	for _, hashtag := range s.hashtags {
+		rank := similarity(hashtag.Name, name)
+		if rank < minSimilarity && !strings.HasPrefix(hashtag.Name, name) {
+			continue
+		}
+		hits = append(hits, store.SearchHit[models.Hashtag]{Item: hashtag, Rank: rank})
+	}
+	return sortHits(hits, store.SortRelevance, page, func(hashtag models.Hashtag) store.Cursor {
+		return store.Cursor{CreatedAt: hashtag.CreatedAt, ID: hashtag.ID}
+	}), nil
+}
+
+// similarity is pg_trgm's similarity: the share of trigrams two strings have
+// in common, each word padded with two leading spaces and one trailing space.
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range words(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,85 @@
+package memstore
+
+import (
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type sessionStore struct {
+	*state
+}
+
+func (s *sessionStore) Create(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[session.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	now := time.Now()
+	session.ID = s.nextID("sessions")
+	session.CreatedAt = now
+	if session.LastUsedAt.IsZero() {
+		session.LastUsedAt = now
+	}
+	session.User = models.User{}
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *sessionStore) GetByID(id uint) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &session, nil
+}
+
+func (s *sessionStore) Rotate(id uint, currentHash, newHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.RevokedAt != nil || session.RefreshTokenHash != currentHash {
+		return store.ErrConflict
+	}
+
+	session.PreviousTokenHash = currentHash
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+	session.LastUsedAt = time.Now()
+	s.sessions[id] = session
+	return nil
+}
+
+func (s *sessionStore) Revoke(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+		s.sessions[id] = session
+	}
+	return nil
+}
+
+func (s *sessionStore) RevokeAll(userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			s.sessions[id] = session
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package memstore
+
+import "sinkedin/models"
+
+type timelineStore struct {
+	*state
+}
+
+func (s *timelineStore) Add(entries []models.TimelineEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		key := pair{entry.UserID, entry.PostID}
+		if _, exists := s.timeline[key]; !exists {
+			s.timeline[key] = entry
+		}
+	}
+	return nil
+}
+
+func (s *timelineStore) RemovePost(postID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.timeline {
+		if key.b == postID {
+			delete(s.timeline, key)
+		}
+	}
+	return nil
+}
+
+func (s *timelineStore) RemoveAuthor(userID, authorID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.timeline {
+		if key.a == userID && entry.AuthorID == authorID {
+			delete(s.timeline, key)
+		}
+	}
+	return nil
+}
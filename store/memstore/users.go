@@ -0,0 +1,174 @@
+package memstore
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+type userStore struct {
+	*state
+}
+
+// uniqueTaken reports whether another user already uses the username or email.
+func (s *userStore) uniqueTaken(id uint, username, email string) bool {
+	for _, user := range s.users {
+		if user.ID != id && (user.Username == username || user.Email == email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *userStore) Create(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.uniqueTaken(0, user.Username, user.Email) {
+		return store.ErrConflict
+	}
+
+	now := time.Now()
+	user.ID = s.nextID("users")
+	// Mirror the column default
+	if user.Role == "" {
+		user.Role = models.UserRole
+	}
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (s *userStore) GetByID(id uint) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (s *userStore) GetByUsername(username string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *userStore) GetByEmail(email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *userStore) UpdateProfile(id uint, update store.ProfileUpdate) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	if update.Name != nil {
+		user.Name = *update.Name
+	}
+	if update.Username != nil {
+		user.Username = *update.Username
+	}
+	if update.Email != nil {
+		user.Email = *update.Email
+	}
+	if update.PhoneNumber != nil {
+		user.PhoneNumber = *update.PhoneNumber
+	}
+	if update.Bio != nil {
+		user.Bio = *update.Bio
+	}
+	if update.DOB != nil {
+		dob := *update.DOB
+		user.DOB = &dob
+	}
+	if update.PhotoURL != nil {
+		user.PhotoURL = *update.PhotoURL
+	}
+	if update.BannerURL != nil {
+		user.BannerURL = *update.BannerURL
+	}
+	if update.IsPrivate != nil {
+		user.IsPrivate = *update.IsPrivate
+	}
+
+	if s.uniqueTaken(id, user.Username, user.Email) {
+		return nil, store.ErrConflict
+	}
+
+	user.UpdatedAt = time.Now()
+	s.users[id] = user
+	return &user, nil
+}
+
+func (s *userStore) Delete(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.users, id)
+	return nil
+}
+
+func (s *userStore) Suggest(viewerID uint, prefix string, limit int) ([]store.UserSuggestion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	suggestions := []store.UserSuggestion{}
+	for _, user := range s.users {
+		if user.ID == viewerID || !(strings.HasPrefix(strings.ToLower(user.Username), prefix) ||
+			strings.HasPrefix(strings.ToLower(user.Name), prefix)) {
+			continue
+		}
+		_, following := s.follows[pair{viewerID, user.ID}]
+		_, followedBy := s.follows[pair{user.ID, viewerID}]
+		suggestions = append(suggestions, store.UserSuggestion{User: user, Relationship: store.Relationship{Following: following, FollowedBy: followedBy}})
+	}
+
+	group := func(s store.UserSuggestion) int {
+		switch {
+		case s.Following:
+			return 0
+		case s.FollowedBy:
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if group(a) != group(b) {
+			return group(a) < group(b)
+		}
+		if a.User.FollowersCount != b.User.FollowersCount {
+			return a.User.FollowersCount > b.User.FollowersCount
+		}
+		return a.User.Username < b.User.Username
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
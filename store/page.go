@@ -0,0 +1,111 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor is returned when a cursor string cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a newest-first listing keyed on (created_at, id).
+// Listings ordered by relevance, such as search results, key on (rank, id)
+// instead and leave CreatedAt zero.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+	Rank      float64
+}
+
+// Encode returns the opaque form of the cursor handed to clients.
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatUint(uint64(c.ID), 10)
+	if c.Rank != 0 {
+		raw += "|" + strconv.FormatFloat(c.Rank, 'g', -1, 64)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Precedes reports whether k comes after the cursor in a listing ordered by
+// rank and then newest first.
+func (c Cursor) Precedes(k Cursor) bool {
+	if k.Rank != c.Rank {
+		return k.Rank < c.Rank
+	}
+	return c.Before(k.CreatedAt, k.ID)
+}
+
+// Before reports whether a record keyed on (createdAt, id) comes after the
+// cursor in newest-first order.
+func (c Cursor) Before(createdAt time.Time, id uint) bool {
+	if !createdAt.Equal(c.CreatedAt) {
+		return createdAt.Before(c.CreatedAt)
+	}
+	return id < c.ID
+}
+
+// DecodeCursor parses a cursor produced by Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdPart, rest, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	idPart, rankPart, ranked := strings.Cut(rest, "|")
+	createdAt, err := time.Parse(time.RFC3339Nano, createdPart)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{CreatedAt: createdAt, ID: uint(id)}
+	if ranked {
+		if cursor.Rank, err = strconv.ParseFloat(rankPart, 64); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+// PageRequest asks for at most Limit records following After, or the first
+// page when After is nil.
+type PageRequest struct {
+	Limit int
+	After *Cursor
+}
+
+// Page is one slice of a listing; Next is nil on the last page.
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+}
+
+// NewPage builds a page from up to limit+1 newest-first records, using the
+// extra record only to detect whether another page follows.
+func NewPage[T any](items []T, limit int, key func(T) Cursor) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) <= limit {
+		return Page[T]{Items: items}
+	}
+
+	items = items[:limit]
+	next := key(items[len(items)-1])
+	return Page[T]{Items: items, Next: &next}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := Cursor{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC), ID: 42}
+
+	got, err := DecodeCursor(want.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestRankedCursor(t *testing.T) {
+	want := Cursor{ID: 7, Rank: 0.0607927}
+
+	got, err := DecodeCursor(want.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if got.Rank != want.Rank || got.ID != want.ID {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+
+	if !want.Precedes(Cursor{ID: 9, Rank: 0.05}) || !want.Precedes(Cursor{ID: 6, Rank: want.Rank}) ||
+		want.Precedes(Cursor{ID: 1, Rank: 0.5}) {
+		t.Error("ranked cursors out of order")
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, raw := range []string{"", "!!!", Cursor{}.Encode()[:4]} {
+		if _, err := DecodeCursor(raw); err == nil {
+			t.Errorf("DecodeCursor(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	key := func(n int) Cursor { return Cursor{ID: uint(n)} }
+
+	page := NewPage([]int{5, 4, 3}, 2, key)
+	if len(page.Items) != 2 || page.Next == nil || page.Next.ID != 4 {
+		t.Errorf("page = %+v", page)
+	}
+
+	page = NewPage([]int{5, 4}, 2, key)
+	if len(page.Items) != 2 || page.Next != nil {
+		t.Errorf("last page = %+v", page)
+	}
+
+	page = NewPage[int](nil, 2, key)
+	if page.Items == nil || page.Next != nil {
+		t.Errorf("empty page = %+v", page)
+	}
+}
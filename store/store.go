@@ -0,0 +1,451 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"sinkedin/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write would violate a uniqueness rule.
+	ErrConflict = errors.New("record already exists")
+)
+
+// Store groups every store the handlers depend on.
+type Store struct {
+	Users          UserStore
+	Posts          PostStore
+	Comments       CommentStore
+	Likes          LikeStore
+	Follows        FollowStore
+	Hashtags       HashtagStore
+	Timelines      TimelineStore
+	Sessions       SessionStore
+	Search         SearchStore
+	Notifications  NotificationStore
+	Messages       MessageStore
+	Blocks         BlockStore
+	FollowRequests FollowRequestStore
+	Moderation     ModerationStore
+}
+
+// ProfileUpdate holds the profile fields a user may change. Nil fields are
+// left untouched.
+type ProfileUpdate struct {
+	Name        *string
+	Username    *string
+	Email       *string
+	PhoneNumber *string
+	Bio         *string
+	DOB         *time.Time
+	PhotoURL    *string
+	BannerURL   *string
+	IsPrivate   *bool
+}
+
+type UserStore interface {
+	Create(user *models.User) error
+	GetByID(id uint) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	GetByEmail(email string) (*models.User, error)
+	UpdateProfile(id uint, update ProfileUpdate) (*models.User, error)
+	Delete(id uint) error
+	// Suggest returns up to limit other users whose username or name starts
+	// with prefix (lowercase), the accounts the viewer follows first, then
+	// their followers, then the rest by follower count.
+	Suggest(viewerID uint, prefix string, limit int) ([]UserSuggestion, error)
+}
+
+// UserSuggestion is a typeahead match with its relationship to the viewer.
+type UserSuggestion struct {
+	User models.User
+	Relationship
+}
+
+type PostStore interface {
+	// Create inserts the post together with its hashtags (created on first
+	// use) and tagged users, and reloads it with its associations.
+	Create(post *models.Post, hashtags []string, tagUserIDs []uint) error
+	GetByID(id uint) (*models.Post, error)
+	// GetByIDs returns the posts that exist among ids, in no particular
+	// order.
+	GetByIDs(ids []uint) ([]models.Post, error)
+	List(page PageRequest) (Page[models.Post], error)
+	ListByHashtag(hashtagID uint, page PageRequest) (Page[models.Post], error)
+	ListByAuthor(authorID uint, page PageRequest) (Page[models.Post], error)
+	// Timeline pages through the user's home timeline: their materialized
+	// timeline entries, their own posts, posts by pullAuthorIDs (accounts too
+	// large to fan out on write), posts using any of hashtagIDs and, when
+	// includeTagged is set, posts they are tagged in.
+	Timeline(userID uint, pullAuthorIDs, hashtagIDs []uint, includeTagged bool, page PageRequest) (Page[models.Post], error)
+	// Update saves the post's own columns, visibility and reply policy
+	// included, and, unless they are nil, replaces its hashtags and tagged
+	// users, keeping hashtag counters and the HasHashtag/HasTag flags in
+	// step. It returns the newly tagged users.
+	Update(post *models.Post, hashtags []string, tagUserIDs []uint) ([]uint, error)
+	Delete(id uint) error
+}
+
+type CommentStore interface {
+	// Create inserts the comment with its hashtags and tagged users and bumps
+	// the comment count on the post and parent comment.
+	Create(comment *models.Comment, hashtags []string, tagUserIDs []uint) error
+	GetByID(id uint) (*models.Comment, error)
+	ListByPost(postID uint, page PageRequest) (Page[models.Comment], error)
+	// ListReplies pages through the direct replies to a comment.
+	ListReplies(parentID uint, page PageRequest) (Page[models.Comment], error)
+	// Thread returns the comment with its replies nested as bounded by opts.
+	Thread(rootID uint, opts ThreadOptions) (*CommentThread, error)
+	// Update saves the comment's content, type and GIF payload and, unless
+	// they are nil, replaces its hashtags and tagged users, keeping the
+	// ContainsHashtag/ContainsTag flags in step. It returns the newly tagged
+	// users.
+	Update(comment *models.Comment, hashtags []string, tagUserIDs []uint) ([]uint, error)
+	// Delete removes the comment along with every reply below it, which could
+	// no longer be reached, and decrements the counts they contributed to.
+	Delete(comment *models.Comment) error
+}
+
+type LikeStore interface {
+	// Toggle likes the target if the user has not liked it yet and unlikes it
+	// otherwise, keeping LikeCount in step. It reports whether the target is
+	// now liked.
+	Toggle(userID, parentID uint, likeType models.LikeType) (bool, error)
+	List(parentID uint, likeType models.LikeType, page PageRequest) (Page[models.Like], error)
+	// Liked reports which of parentIDs the user has liked.
+	Liked(userID uint, likeType models.LikeType, parentIDs []uint) (map[uint]bool, error)
+}
+
+type FollowStore interface {
+	// Toggle follows or unfollows the target, keeping FollowersCount and
+	// FollowingCount in step. It reports whether the follow now exists.
+	Toggle(followerID, followingID uint) (bool, error)
+	// Followers and Following page through the other side of the follow,
+	// keyed on when the follow was created and the other user's ID.
+	Followers(userID uint, page PageRequest) (Page[models.User], error)
+	Following(userID uint, page PageRequest) (Page[models.User], error)
+	// FollowerIDs returns up to limit IDs of the user's followers greater than
+	// afterID, in ascending order, for batch processing.
+	FollowerIDs(userID, afterID uint, limit int) ([]uint, error)
+	// PopularFollowingIDs returns the IDs of the accounts the user follows
+	// that have at least minFollowers followers.
+	PopularFollowingIDs(userID uint, minFollowers int) ([]uint, error)
+	// Relationships reports, for each of otherIDs, whether the user follows
+	// them and whether they follow the user. Unrelated users are omitted.
+	Relationships(userID uint, otherIDs []uint) (map[uint]Relationship, error)
+}
+
+// Relationship is the follow state between a user and one other account.
+type Relationship struct {
+	Following  bool
+	FollowedBy bool
+}
+
+type HashtagStore interface {
+	GetByName(name string) (*models.Hashtag, error)
+	// Details summarizes the hashtag's uses by public posts and comments, with
+	// up to limit top contributors and related hashtags. Uses only count when
+	// anyone may see them: posts must be live, public, not hidden and by a
+	// public author, and comments not hidden, by a public author and on such
+	// a post. Suggest and Activity count the same uses.
+	Details(hashtagID uint, limit int) (*HashtagDetails, error)
+	// ListContent pages through the posts and comments using the hashtag,
+	// newest first.
+	ListContent(hashtagID uint, page PageRequest) (Page[HashtagItem], error)
+	// ToggleFollow follows the hashtag for the user if they do not follow it
+	// yet and unfollows it otherwise. It reports whether the follow now
+	// exists.
+	ToggleFollow(userID, hashtagID uint) (bool, error)
+	// FollowedIDs returns the IDs of every hashtag the user follows.
+	FollowedIDs(userID uint) ([]uint, error)
+	// Followed pages through the hashtags the user follows, keyed on when the
+	// follow was created and the hashtag ID.
+	Followed(userID uint, page PageRequest) (Page[models.Hashtag], error)
+	// Suggest returns up to limit hashtags whose name starts with prefix,
+	// the ones used most by public posts and comments since the given time
+	// first, then by their all-time counter.
+	Suggest(prefix string, since time.Time, limit int) ([]HashtagSuggestion, error)
+	// Activity summarizes the public post and comment uses of every hashtag
+	// used between now-2*window and now.
+	Activity(now time.Time, window, halfLife time.Duration) ([]HashtagActivity, error)
+	// ReplaceTrending swaps the snapshot for the window for entries.
+	ReplaceTrending(window string, entries []models.TrendingHashtag) error
+	// Trending returns the top of the window's snapshot by rank, with the
+	// hashtags loaded.
+	Trending(window string, limit int) ([]models.TrendingHashtag, error)
+}
+
+// HashtagDetails describes how a hashtag is used. FirstUsedAt is nil when
+// nothing public uses it.
+type HashtagDetails struct {
+	PostCount       int
+	CommentCount    int
+	FirstUsedAt     *time.Time
+	TopContributors []HashtagContributor
+	Related         []RelatedHashtag
+}
+
+// HashtagContributor is a user with the number of their posts and comments
+// using a hashtag.
+type HashtagContributor struct {
+	User models.User
+	Uses int
+}
+
+// RelatedHashtag is a hashtag with the number of posts and comments it shares
+// with another.
+type RelatedHashtag struct {
+	Hashtag models.Hashtag
+	Uses    int
+}
+
+// HashtagItem is one entry of a hashtag's mixed listing; exactly one of Post
+// and Comment is set.
+type HashtagItem struct {
+	Post    *models.Post
+	Comment *models.Comment
+}
+
+// Cursor returns the item's position in the listing.
+func (item HashtagItem) Cursor() Cursor {
+	if item.Post != nil {
+		return Cursor{CreatedAt: item.Post.CreatedAt, ID: item.Post.ID}
+	}
+	return Cursor{CreatedAt: item.Comment.CreatedAt, ID: item.Comment.ID}
+}
+
+// HashtagSuggestion is a typeahead match with its number of recent uses.
+type HashtagSuggestion struct {
+	Hashtag    models.Hashtag
+	RecentUses int
+}
+
+// HashtagActivity is a hashtag's usage around a trending window. Uses falls
+// inside the window and PreviousUses in the window before it. Decayed sums
+// 0.5^(age/halfLife) over the uses inside the window, so recent uses weigh
+// the most.
+type HashtagActivity struct {
+	HashtagID    uint
+	Uses         int
+	PreviousUses int
+	Decayed      float64
+}
+
+type TimelineStore interface {
+	// Add materializes the entries, ignoring ones that already exist.
+	Add(entries []models.TimelineEntry) error
+	RemovePost(postID uint) error
+	// RemoveAuthor drops every entry by authorID from the user's timeline.
+	RemoveAuthor(userID, authorID uint) error
+}
+
+type SessionStore interface {
+	Create(session *models.Session) error
+	GetByID(id uint) (*models.Session, error)
+	// Rotate replaces the refresh token hash, but only while the session is
+	// unrevoked and still holds currentHash; otherwise it returns ErrConflict.
+	// The replaced hash is kept so that its reuse can be detected.
+	Rotate(id uint, currentHash, newHash string, expiresAt time.Time) error
+	Revoke(id uint) error
+	// RevokeAll revokes every active session of the user.
+	RevokeAll(userID uint) error
+}
+
+// SearchSort orders search results.
+type SearchSort string
+
+const (
+	// SortRelevance ranks the best matches first.
+	SortRelevance SearchSort = "relevance"
+	// SortRecent lists matches newest first.
+	SortRecent SearchSort = "recent"
+)
+
+// SearchQuery describes a full-text search. Text uses web search syntax:
+// plain words must all match, "quoted phrases" match in order, OR offers
+// alternatives and a leading - excludes a word. Nil filters are not applied;
+// HasImage and IsQuote only apply to posts.
+type SearchQuery struct {
+	Text     string
+	AuthorID *uint
+	From     *time.Time
+	To       *time.Time
+	HasImage *bool
+	IsQuote  *bool
+	Sort     SearchSort
+}
+
+// SearchHit is a search result with its relevance and, for text content, a
+// snippet of the matching text. Snippets are HTML-escaped, with the matches
+// wrapped in <mark> tags.
+type SearchHit[T any] struct {
+	Item    T
+	Rank    float64
+	Snippet string
+}
+
+// SearchStore finds content by text. Users and hashtags are always sorted by
+// relevance and ignore the filters.
+type SearchStore interface {
+	Posts(query SearchQuery, page PageRequest) (Page[SearchHit[models.Post]], error)
+	Comments(query SearchQuery, page PageRequest) (Page[SearchHit[models.Comment]], error)
+	Users(query SearchQuery, page PageRequest) (Page[SearchHit[models.User]], error)
+	// Hashtags matches names by trigram similarity, so that misspellings and
+	// partial names still find them.
+	Hashtags(query SearchQuery, page PageRequest) (Page[SearchHit[models.Hashtag]], error)
+}
+
+// NotificationStore keeps each user's notifications, grouping actors that do
+// the same thing to the same target while the notification is unread.
+type NotificationStore interface {
+	// Add records that actorID did what n describes: the actor joins the
+	// recipient's unread notification with the same GroupKey, or a new one is
+	// started. It returns the notification as stored, and whether the actor
+	// is new to it.
+	Add(n models.Notification, actorID uint) (*models.Notification, bool, error)
+	// Retract withdraws the actor from the recipient's unread notification of
+	// the group, deleting it once no actors remain. Read notifications are
+	// left alone.
+	Retract(userID uint, groupKey string, actorID uint) error
+	// List pages through the user's notifications by latest activity, each
+	// with up to actors of its most recent actors.
+	List(userID uint, unreadOnly bool, actors int, page PageRequest) (Page[models.Notification], error)
+	// MarkRead marks the given notifications of the user as read, ignoring
+	// IDs that are not theirs, and returns how many changed.
+	MarkRead(userID uint, ids []uint) (int, error)
+	MarkAllRead(userID uint) (int, error)
+	UnreadCount(userID uint) (int, error)
+}
+
+// MessageStore keeps private conversations and their messages.
+type MessageStore interface {
+	// CreateConversation stores the conversation with the given members. A
+	// one-to-one conversation whose DirectKey is taken returns ErrConflict.
+	CreateConversation(conversation *models.Conversation, memberIDs []uint) error
+	// GetConversation returns the conversation with its members.
+	GetConversation(id uint) (*models.Conversation, error)
+	GetDirect(directKey string) (*models.Conversation, error)
+	// Member returns the user's membership, or ErrNotFound when they are not
+	// in the conversation.
+	Member(conversationID, userID uint) (*models.ConversationMember, error)
+	// ListConversations pages through the user's conversations by latest
+	// activity, each with its members, last live message and the number of
+	// messages from others the user has not read.
+	ListConversations(userID uint, page PageRequest) (Page[models.Conversation], error)
+
+	// Send stores the message, moves the conversation's activity time and
+	// marks it read for the sender.
+	Send(message *models.Message) error
+	GetMessage(id uint) (*models.Message, error)
+	// ListMessages pages through the conversation's live messages, newest
+	// first.
+	ListMessages(conversationID uint, page PageRequest) (Page[models.Message], error)
+	UpdateMessage(message *models.Message) error
+	DeleteMessage(id uint) error
+	// MarkRead moves the member's read marker up to messageID, or to the
+	// latest message when it is zero, and returns the updated membership.
+	// The marker never moves backwards.
+	MarkRead(conversationID, userID, messageID uint) (*models.ConversationMember, error)
+}
+
+// FollowRequestStore keeps the requests to follow private users.
+type FollowRequestStore interface {
+	// Create records a pending request from requesterID to targetID,
+	// reopening an earlier request between them if there is one.
+	Create(requesterID, targetID uint) (*models.FollowRequest, error)
+	GetByID(id uint) (*models.FollowRequest, error)
+	// Pending reports whether requesterID has a pending request to targetID.
+	Pending(requesterID, targetID uint) (bool, error)
+	// Cancel deletes the pending request from requesterID to targetID and
+	// reports whether there was one.
+	Cancel(requesterID, targetID uint) (bool, error)
+	// Incoming pages through the pending requests to the user, most recent
+	// first, with their requesters.
+	Incoming(userID uint, page PageRequest) (Page[models.FollowRequest], error)
+	// Accept marks the pending request accepted and creates the follow,
+	// keeping the follow counts in step. Decided requests return
+	// ErrConflict.
+	Accept(id uint) (*models.FollowRequest, error)
+	// Reject marks the pending request rejected. Decided requests return
+	// ErrConflict.
+	Reject(id uint) (*models.FollowRequest, error)
+	// AcceptAll accepts every pending request to the user and returns the
+	// requesters' IDs.
+	AcceptAll(userID uint) ([]uint, error)
+}
+
+// BlockStore keeps the accounts users have blocked or muted.
+type BlockStore interface {
+	// Block records the block, doing nothing if it exists, and removes the
+	// follows and follow requests between the two users in either
+	// direction, keeping the follow counts in step. It returns the follows
+	// it removed, as seen from the blocker.
+	Block(blockerID, blockedID uint) (Relationship, error)
+	// Unblock reports whether there was a block to remove.
+	Unblock(blockerID, blockedID uint) (bool, error)
+	// Blocked pages through the users the user has blocked, most recent
+	// first.
+	Blocked(userID uint, page PageRequest) (Page[models.User], error)
+	// Blocking returns the IDs among otherIDs that the user has blocked or
+	// been blocked by.
+	Blocking(userID uint, otherIDs []uint) (map[uint]bool, error)
+	// Mute records the mute, doing nothing if it exists.
+	Mute(userID, mutedID uint) error
+	// Unmute reports whether there was a mute to remove.
+	Unmute(userID, mutedID uint) (bool, error)
+	// Muted pages through the users the user has muted, most recent first.
+	Muted(userID uint, page PageRequest) (Page[models.User], error)
+	// HiddenIDs returns the users whose content is hidden from the user:
+	// those they muted or blocked and those who blocked them.
+	HiddenIDs(userID uint) ([]uint, error)
+}
+
+// ReportFilter narrows a listing of reports. Zero fields match every report.
+type ReportFilter struct {
+	Status     models.ReportStatus
+	ReporterID uint
+}
+
+// ActionFilter narrows the audit trail. Zero fields match every action.
+type ActionFilter struct {
+	ReportID   uint
+	TargetType models.ReportTargetType
+	TargetID   uint
+}
+
+// ModerationStore keeps the reports users file, the audit trail of what
+// moderators did, and the moderation state of users and content.
+type ModerationStore interface {
+	// CreateReport files the report as open. A reporter who already has an
+	// unresolved report on the same target gets ErrConflict.
+	CreateReport(report *models.Report) error
+	GetReport(id uint) (*models.Report, error)
+	// Reports pages through the reports matching filter, most recent first,
+	// with their reporters.
+	Reports(filter ReportFilter, page PageRequest) (Page[models.Report], error)
+	// MoveReport moves the report to status on behalf of moderatorID,
+	// provided it is in one of from; otherwise it returns ErrConflict.
+	MoveReport(id uint, from []models.ReportStatus, status models.ReportStatus, moderatorID uint) (*models.Report, error)
+	// ResolveTarget marks every unresolved report on the target actioned on
+	// behalf of moderatorID and returns them.
+	ResolveTarget(target models.ReportTargetType, targetID, moderatorID uint) ([]models.Report, error)
+	// Record appends the action to the audit trail.
+	Record(action *models.ModerationAction) error
+	// Actions pages through the audit trail entries matching filter, most
+	// recent first, with their moderators.
+	Actions(filter ActionFilter, page PageRequest) (Page[models.ModerationAction], error)
+	// SetHidden hides the post or comment from everyone but its author, or
+	// shows it again.
+	SetHidden(target models.ReportTargetType, targetID uint, hidden bool) error
+	// Suspend keeps the user from logging in until the given time, or lifts
+	// the suspension when until is nil.
+	Suspend(userID uint, until *time.Time) error
+	SetRole(userID uint, role models.Role) error
+	// Transaction runs fn with a Store whose writes, through any of its
+	// stores, are committed together if fn returns nil and rolled back
+	// otherwise.
+	Transaction(fn func(tx *Store) error) error
+}
@@ -0,0 +1,539 @@
+// Package storetest checks a store.Store implementation against the behavior
+// the handlers rely on. It covers what gormstore does in raw SQL and memstore
+// reimplements by hand, so that running it against both keeps the two in
+// step.
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+// Run runs the checks, calling open for an empty store in each of them.
+func Run(t *testing.T, open func(t *testing.T) *store.Store) {
+	t.Run("Thread", func(t *testing.T) { testThread(t, open(t)) })
+	t.Run("HashtagDetails", func(t *testing.T) { testHashtagDetails(t, open(t)) })
+	t.Run("HashtagActivity", func(t *testing.T) { testHashtagActivity(t, open(t)) })
+	t.Run("HashtagContent", func(t *testing.T) { testHashtagContent(t, open(t)) })
+	t.Run("HashtagPrivateUses", func(t *testing.T) { testHashtagPrivateUses(t, open(t)) })
+	t.Run("SearchPosts", func(t *testing.T) { testSearchPosts(t, open(t)) })
+	t.Run("SearchComments", func(t *testing.T) { testSearchComments(t, open(t)) })
+	t.Run("SearchUsers", func(t *testing.T) { testSearchUsers(t, open(t)) })
+	t.Run("SearchHashtags", func(t *testing.T) { testSearchHashtags(t, open(t)) })
+}
+
+// fixture creates records, failing the test on any error.
+type fixture struct {
+	t *testing.T
+	s *store.Store
+}
+
+func (f fixture) user(username, name, bio string) models.User {
+	f.t.Helper()
+	user := models.User{Name: name, Username: username, Email: username + "@example.com", Password: "x", Bio: bio}
+	if err := f.s.Users.Create(&user); err != nil {
+		f.t.Fatal(err)
+	}
+	return user
+}
+
+func (f fixture) post(authorID uint, content string, hashtags ...string) models.Post {
+	f.t.Helper()
+	post := models.Post{UserID: authorID, Content: content}
+	if err := f.s.Posts.Create(&post, hashtags, nil); err != nil {
+		f.t.Fatal(err)
+	}
+	return post
+}
+
+// comment creates a comment on the post, replying to parentID unless it is
+// zero.
+func (f fixture) comment(authorID, postID, parentID uint, content string, hashtags ...string) models.Comment {
+	f.t.Helper()
+	comment := models.Comment{UserID: authorID, PostID: &postID, Type: models.NormalComment, Content: content}
+	if parentID != 0 {
+		comment.ParentCommentID = &parentID
+	}
+	if err := f.s.Comments.Create(&comment, hashtags, nil); err != nil {
+		f.t.Fatal(err)
+	}
+	return comment
+}
+
+func (f fixture) hashtag(name string) models.Hashtag {
+	f.t.Helper()
+	hashtag, err := f.s.Hashtags.GetByName(name)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return *hashtag
+}
+
+// shape renders a thread as each comment's content, followed by + when it
+// has more replies than shown and its replies in parentheses.
+func shape(thread store.CommentThread) string {
+	var b strings.Builder
+	b.WriteString(thread.Comment.Content)
+	if thread.HasMore {
+		b.WriteString("+")
+	}
+	if len(thread.Replies) > 0 {
+		replies := make([]string, len(thread.Replies))
+		for i, reply := range thread.Replies {
+			replies[i] = shape(reply)
+		}
+		b.WriteString("(" + strings.Join(replies, " ") + ")")
+	}
+	return b.String()
+}
+
+func testThread(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	alice := f.user("alice", "Alice", "")
+	post := f.post(alice.ID, "post")
+	root := f.comment(alice.ID, post.ID, 0, "root")
+	a := f.comment(alice.ID, post.ID, root.ID, "a")
+	b := f.comment(alice.ID, post.ID, root.ID, "b")
+	c := f.comment(alice.ID, post.ID, root.ID, "c")
+	c1 := f.comment(alice.ID, post.ID, c.ID, "c1")
+	f.comment(alice.ID, post.ID, c.ID, "c2")
+	f.comment(alice.ID, post.ID, c1.ID, "c1a")
+	b1 := f.comment(alice.ID, post.ID, b.ID, "b1")
+
+	tests := []struct {
+		opts store.ThreadOptions
+		want string
+	}{
+		{store.ThreadOptions{Depth: 2, Replies: 2}, "root+(c(c2 c1+) b(b1))"},
+		{store.ThreadOptions{Depth: 1, Replies: 1}, "root+(c+)"},
+		{store.ThreadOptions{Depth: 0, Replies: 5}, "root+"},
+		{store.ThreadOptions{Depth: 3, Replies: 5}, "root(c(c2 c1(c1a)) b(b1) a)"},
+	}
+	for _, tt := range tests {
+		thread, err := s.Comments.Thread(root.ID, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := shape(*thread); got != tt.want {
+			t.Errorf("Thread(%+v) = %s, want %s", tt.opts, got, tt.want)
+		}
+	}
+
+	// Deleted replies drop out along with their subtree
+	if err := s.Comments.Delete(&b); err != nil {
+		t.Fatal(err)
+	}
+	thread, err := s.Comments.Thread(root.ID, store.ThreadOptions{Depth: 2, Replies: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := shape(*thread), "root(c(c2 c1+) a)"; got != want {
+		t.Errorf("thread after delete = %s, want %s", got, want)
+	}
+	if _, err := s.Comments.Thread(b.ID, store.ThreadOptions{Depth: 2, Replies: 2}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Thread(deleted) = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Comments.GetByID(b1.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetByID(reply of deleted) = %v, want ErrNotFound", err)
+	}
+	if got, err := s.Posts.GetByID(post.ID); err != nil || got.CommentCount != 6 {
+		t.Errorf("post after delete = %+v, %v, want 6 comments", got, err)
+	}
+	if thread, err := s.Comments.Thread(a.ID, store.ThreadOptions{Depth: 2, Replies: 2}); err != nil || shape(*thread) != "a" {
+		t.Errorf("Thread(leaf) = %+v, %v", thread, err)
+	}
+}
+
+// hashtagUses creates the uses of #go the hashtag tests share: two posts and
+// a comment left, and a post of carol's deleted again.
+func hashtagUses(f fixture) (alice, bob models.User, items []string) {
+	alice = f.user("alice", "Alice", "")
+	bob = f.user("bob", "Bob", "")
+	carol := f.user("carol", "Carol", "")
+
+	first := f.post(alice.ID, "first", "go", "gopher")
+	second := f.post(bob.ID, "second", "go")
+	comment := f.comment(bob.ID, first.ID, 0, "reply", "go", "rust")
+	deleted := f.post(carol.ID, "deleted", "go")
+	if err := f.s.Posts.Delete(deleted.ID); err != nil {
+		f.t.Fatal(err)
+	}
+	return alice, bob, []string{
+		fmt.Sprintf("comment %d", comment.ID),
+		fmt.Sprintf("post %d", second.ID),
+		fmt.Sprintf("post %d", first.ID),
+	}
+}
+
+func testHashtagDetails(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	alice, bob, _ := hashtagUses(f)
+	goTag, gopher, rust := f.hashtag("go"), f.hashtag("gopher"), f.hashtag("rust")
+
+	details, err := s.Hashtags.Details(goTag.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.PostCount != 2 || details.CommentCount != 1 || details.FirstUsedAt == nil {
+		t.Errorf("details = %+v, want 2 posts and 1 comment", details)
+	}
+	var contributors, related []string
+	for _, c := range details.TopContributors {
+		contributors = append(contributors, fmt.Sprintf("%d:%d", c.User.ID, c.Uses))
+	}
+	for _, r := range details.Related {
+		related = append(related, fmt.Sprintf("%s:%d", r.Hashtag.Name, r.Uses))
+	}
+	if got, want := strings.Join(contributors, " "), fmt.Sprintf("%d:2 %d:1", bob.ID, alice.ID); got != want {
+		t.Errorf("top contributors = %s, want %s", got, want)
+	}
+	if got, want := strings.Join(related, " "), "gopher:1 rust:1"; got != want {
+		t.Errorf("related = %s, want %s", got, want)
+	}
+
+	details, err = s.Hashtags.Details(goTag.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details.TopContributors) != 1 || details.TopContributors[0].User.ID != bob.ID ||
+		len(details.Related) != 1 || details.Related[0].Hashtag.ID != gopher.ID {
+		t.Errorf("details limited to 1 = %+v", details)
+	}
+
+	details, err = s.Hashtags.Details(rust.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.PostCount != 0 || details.CommentCount != 1 || len(details.Related) != 1 || details.Related[0].Hashtag.ID != goTag.ID {
+		t.Errorf("rust details = %+v", details)
+	}
+
+	if _, err := s.Hashtags.Details(rust.ID+1000, 10); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Details(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func testHashtagActivity(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	hashtagUses(f)
+	goTag, gopher := f.hashtag("go"), f.hashtag("gopher")
+	// Uses are timestamped by the store, so only compare at a minute's
+	// precision around them
+	now := time.Now().Add(time.Minute)
+
+	activity := func(at time.Time) map[uint]store.HashtagActivity {
+		t.Helper()
+		entries, err := s.Hashtags.Activity(at, time.Hour, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byID := map[uint]store.HashtagActivity{}
+		for _, entry := range entries {
+			byID[entry.HashtagID] = entry
+		}
+		return byID
+	}
+
+	current := activity(now)
+	if len(current) != 3 {
+		t.Errorf("activity = %+v, want go, gopher and rust", current)
+	}
+	if a := current[goTag.ID]; a.Uses != 3 || a.PreviousUses != 0 || a.Decayed < 2.9 || a.Decayed > 3 {
+		t.Errorf("go activity = %+v, want 3 recent uses", a)
+	}
+	if a := current[gopher.ID]; a.Uses != 1 || a.Decayed < 0.95 || a.Decayed > 1 {
+		t.Errorf("gopher activity = %+v, want 1 recent use", a)
+	}
+
+	// An hour and a half on, the uses fall in the previous window
+	later := activity(now.Add(90 * time.Minute))
+	if a := later[goTag.ID]; a.Uses != 0 || a.PreviousUses != 3 || a.Decayed != 0 {
+		t.Errorf("later go activity = %+v, want 3 previous uses", a)
+	}
+
+	if earlier := activity(now.Add(-time.Hour)); len(earlier) != 0 {
+		t.Errorf("activity before the uses = %+v", earlier)
+	}
+}
+
+func testHashtagContent(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	_, _, want := hashtagUses(f)
+	goTag := f.hashtag("go")
+
+	var got []string
+	page := store.PageRequest{Limit: 2}
+	for pages := 0; pages < len(want)+1; pages++ {
+		items, err := s.Hashtags.ListContent(goTag.ID, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items.Items {
+			if item.Post != nil {
+				got = append(got, fmt.Sprintf("post %d", item.Post.ID))
+			} else {
+				got = append(got, fmt.Sprintf("comment %d", item.Comment.ID))
+			}
+		}
+		if items.Next == nil {
+			break
+		}
+		page.After = items.Next
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("content = %v, want %v", got, want)
+	}
+
+	suggestions, err := s.Hashtags.Suggest("go", time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, suggestion := range suggestions {
+		names = append(names, fmt.Sprintf("%s:%d", suggestion.Hashtag.Name, suggestion.RecentUses))
+	}
+	if got, want := strings.Join(names, " "), "go:3 gopher:1"; got != want {
+		t.Errorf("suggestions = %s, want %s", got, want)
+	}
+}
+
+// testHashtagPrivateUses checks that uses not everyone may see add nothing
+// to the hashtag aggregates: drafts, followers-only posts, hidden posts and
+// comments, posts by private users and comments on any of those.
+func testHashtagPrivateUses(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	alice := f.user("alice", "Alice", "")
+	bob := f.user("bob", "Bob", "")
+	public := f.post(alice.ID, "public", "go")
+
+	for _, visibility := range []models.PostVisibility{models.PrivateVisibility, models.FollowersVisibility} {
+		post := models.Post{UserID: bob.ID, Content: "not for everyone", Visibility: visibility}
+		if err := s.Posts.Create(&post, []string{"go", "secret"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		f.comment(alice.ID, post.ID, 0, "on a post not for everyone", "go", "secret")
+	}
+	hidden := f.post(bob.ID, "hidden", "go", "secret")
+	comment := f.comment(bob.ID, public.ID, 0, "hidden", "go", "secret")
+	for _, target := range []struct {
+		typ models.ReportTargetType
+		id  uint
+	}{{models.ReportedPost, hidden.ID}, {models.ReportedComment, comment.ID}} {
+		if err := s.Moderation.SetHidden(target.typ, target.id, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	carol := f.user("carol", "Carol", "")
+	f.post(carol.ID, "private", "go", "secret")
+	private := true
+	if _, err := s.Users.UpdateProfile(carol.ID, store.ProfileUpdate{IsPrivate: &private}); err != nil {
+		t.Fatal(err)
+	}
+	goTag, secret := f.hashtag("go"), f.hashtag("secret")
+
+	details, err := s.Hashtags.Details(goTag.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.PostCount != 1 || details.CommentCount != 0 || len(details.Related) != 0 ||
+		len(details.TopContributors) != 1 || details.TopContributors[0].User.ID != alice.ID {
+		t.Errorf("go details = %+v, want alice's post alone", details)
+	}
+	if details, err := s.Hashtags.Details(secret.ID, 10); err != nil || details.PostCount != 0 ||
+		details.CommentCount != 0 || details.FirstUsedAt != nil || len(details.TopContributors) != 0 || len(details.Related) != 0 {
+		t.Errorf("secret details = %+v, %v, want nothing", details, err)
+	}
+
+	activity, err := s.Hashtags.Activity(time.Now().Add(time.Minute), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 1 || activity[0].HashtagID != goTag.ID || activity[0].Uses != 1 {
+		t.Errorf("activity = %+v, want alice's use of go alone", activity)
+	}
+
+	var names []string
+	for _, prefix := range []string{"go", "se"} {
+		suggestions, err := s.Hashtags.Suggest(prefix, time.Now().Add(-time.Hour), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, suggestion := range suggestions {
+			names = append(names, fmt.Sprintf("%s:%d", suggestion.Hashtag.Name, suggestion.RecentUses))
+		}
+	}
+	if got, want := strings.Join(names, " "), "go:1 secret:0"; got != want {
+		t.Errorf("suggestions = %s, want %s", got, want)
+	}
+}
+
+// hitIDs pages through every hit of search a page of size at a time,
+// returning the IDs in order.
+func hitIDs[T any](t *testing.T, size int, search func(store.PageRequest) (store.Page[store.SearchHit[T]], error), id func(T) uint) []uint {
+	t.Helper()
+	ids := []uint{}
+	page := store.PageRequest{Limit: size}
+	for pages := 0; pages < 100; pages++ {
+		hits, err := search(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, hit := range hits.Items {
+			ids = append(ids, id(hit.Item))
+		}
+		if hits.Next == nil {
+			return ids
+		}
+		page.After = hits.Next
+	}
+	t.Fatal("search pages never end")
+	return nil
+}
+
+func postID(post models.Post) uint { return post.ID }
+
+func testSearchPosts(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	alice := f.user("alice", "Alice", "")
+	bob := f.user("bob", "Bob", "")
+
+	many := f.post(alice.ID, "gopher gopher gopher")
+	lone := f.post(bob.ID, "a lone gopher crossed the river valley")
+	twin := f.post(alice.ID, "a lone gopher crossed the river valley")
+	badger := f.post(bob.ID, "a quiet badger")
+
+	tests := []struct {
+		name  string
+		query store.SearchQuery
+		want  []uint
+	}{
+		// Equal ranks fall back to the newest first
+		{"relevance", store.SearchQuery{Text: "gopher"}, []uint{many.ID, twin.ID, lone.ID}},
+		{"recent", store.SearchQuery{Text: "gopher", Sort: store.SortRecent}, []uint{twin.ID, lone.ID, many.ID}},
+		{"author", store.SearchQuery{Text: "gopher", AuthorID: &bob.ID}, []uint{lone.ID}},
+		{"until", store.SearchQuery{Text: "gopher", To: &lone.CreatedAt, Sort: store.SortRecent}, []uint{many.ID}},
+		{"since", store.SearchQuery{Text: "gopher", From: &lone.CreatedAt, Sort: store.SortRecent}, []uint{twin.ID, lone.ID}},
+		{"phrase", store.SearchQuery{Text: `"river valley"`, Sort: store.SortRecent}, []uint{twin.ID, lone.ID}},
+		{"excluded", store.SearchQuery{Text: "gopher -river"}, []uint{many.ID}},
+		{"alternative", store.SearchQuery{Text: "badger OR valley", Sort: store.SortRecent}, []uint{badger.ID, twin.ID, lone.ID}},
+		{"image", store.SearchQuery{Text: "gopher", HasImage: new(bool)}, []uint{many.ID, twin.ID, lone.ID}},
+		{"none", store.SearchQuery{Text: "wombat"}, []uint{}},
+	}
+	for _, tt := range tests {
+		for _, size := range []int{1, 10} {
+			got := hitIDs(t, size, func(page store.PageRequest) (store.Page[store.SearchHit[models.Post]], error) {
+				return s.Search.Posts(tt.query, page)
+			}, postID)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("%s by %d = %v, want %v", tt.name, size, got, tt.want)
+			}
+		}
+	}
+
+	hits, err := s.Search.Posts(store.SearchQuery{Text: "valley"}, store.PageRequest{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits.Items) != 1 || !strings.Contains(hits.Items[0].Snippet, "<mark>valley</mark>") || hits.Items[0].Rank <= 0 {
+		t.Errorf("hits = %+v, want the match marked", hits.Items)
+	}
+}
+
+func testSearchComments(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	alice := f.user("alice", "Alice", "")
+	post := f.post(alice.ID, "gopher")
+	first := f.comment(alice.ID, post.ID, 0, "the gopher replied & left")
+	second := f.comment(alice.ID, post.ID, first.ID, "another gopher")
+	f.comment(alice.ID, post.ID, 0, "unrelated")
+
+	commentID := func(comment models.Comment) uint { return comment.ID }
+	got := hitIDs(t, 1, func(page store.PageRequest) (store.Page[store.SearchHit[models.Comment]], error) {
+		return s.Search.Comments(store.SearchQuery{Text: "gopher", Sort: store.SortRecent}, page)
+	}, commentID)
+	if fmt.Sprint(got) != fmt.Sprint([]uint{second.ID, first.ID}) {
+		t.Errorf("comments = %v, want %d and %d", got, second.ID, first.ID)
+	}
+
+	hits, err := s.Search.Comments(store.SearchQuery{Text: "replied"}, store.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits.Items) != 1 || hits.Items[0].Item.ID != first.ID {
+		t.Fatalf("hits = %+v, want the first comment", hits.Items)
+	}
+	if snippet := hits.Items[0].Snippet; !strings.Contains(snippet, "<mark>replied</mark>") || !strings.Contains(snippet, "&amp;") {
+		t.Errorf("snippet = %q, want the match marked and the text escaped", snippet)
+	}
+
+	// Comments have no images
+	hits, err = s.Search.Comments(store.SearchQuery{Text: "gopher", HasImage: new(bool)}, store.PageRequest{Limit: 10})
+	if err != nil || len(hits.Items) != 0 {
+		t.Errorf("image filtered hits = %+v, %v", hits.Items, err)
+	}
+}
+
+func testSearchUsers(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	alice := f.user("alice", "Alice Smith", "I write gopher code")
+	bob := f.user("bob", "Bob Gopher", "")
+	f.user("carol", "Carol", "rust")
+
+	hits, err := s.Search.Users(store.SearchQuery{Text: "gopher"}, store.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Names rank above bios
+	if len(hits.Items) != 2 || hits.Items[0].Item.ID != bob.ID || hits.Items[1].Item.ID != alice.ID {
+		t.Fatalf("hits = %+v, want bob then alice", hits.Items)
+	}
+	if hits.Items[0].Snippet != "" || !strings.Contains(hits.Items[1].Snippet, "<mark>gopher</mark>") {
+		t.Errorf("snippets = %q, %q", hits.Items[0].Snippet, hits.Items[1].Snippet)
+	}
+
+	userID := func(user models.User) uint { return user.ID }
+	got := hitIDs(t, 1, func(page store.PageRequest) (store.Page[store.SearchHit[models.User]], error) {
+		return s.Search.Users(store.SearchQuery{Text: "gopher"}, page)
+	}, userID)
+	if fmt.Sprint(got) != fmt.Sprint([]uint{bob.ID, alice.ID}) {
+		t.Errorf("paged users = %v", got)
+	}
+}
+
+func testSearchHashtags(t *testing.T, s *store.Store) {
+	f := fixture{t, s}
+	alice := f.user("alice", "Alice", "")
+	f.post(alice.ID, "first", "gopher")
+	f.post(alice.ID, "second", "golang", "rust")
+	gopher, golang := f.hashtag("gopher"), f.hashtag("golang")
+
+	hashtagID := func(hashtag models.Hashtag) uint { return hashtag.ID }
+	tests := []struct {
+		text string
+		want []uint
+	}{
+		// A misspelling still finds it
+		{"gophr", []uint{gopher.ID}},
+		// Short prefixes fall below the similarity threshold but still match;
+		// both are as similar, so the newer comes first
+		{"#GO", []uint{golang.ID, gopher.ID}},
+		{"gopher", []uint{gopher.ID}},
+		{"python", []uint{}},
+	}
+	for _, tt := range tests {
+		for _, size := range []int{1, 10} {
+			got := hitIDs(t, size, func(page store.PageRequest) (store.Page[store.SearchHit[models.Hashtag]], error) {
+				return s.Search.Hashtags(store.SearchQuery{Text: tt.text}, page)
+			}, hashtagID)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("%q by %d = %v, want %v", tt.text, size, got, tt.want)
+			}
+		}
+	}
+}
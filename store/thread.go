@@ -0,0 +1,80 @@
+package store
+
+import (
+	"sort"
+
+	"sinkedin/models"
+)
+
+const (
+	DefaultThreadDepth   = 3
+	MaxThreadDepth       = 10
+	DefaultThreadReplies = 5
+	MaxThreadReplies     = 50
+)
+
+// ThreadOptions bounds a comment subtree: Depth levels of replies below the
+// root, with at most Replies of the newest replies under each comment.
+type ThreadOptions struct {
+	Depth   int
+	Replies int
+}
+
+// CommentThread is a comment with the newest of its replies nested below it.
+// HasMore is set when the comment has replies that were left out, either
+// because of the reply limit or because the depth limit was reached; Next
+// resumes the reply listing after the last included reply, when there is one.
+type CommentThread struct {
+	Comment models.Comment
+	Replies []CommentThread
+	HasMore bool
+	Next    *Cursor
+}
+
+// BuildThread assembles the thread rooted at rootID from a flat set of
+// comments holding up to opts.Replies+1 newest replies per comment, using the
+// extra reply only to detect that more remain. It reports false when the root
+// is not in the set.
+func BuildThread(rootID uint, comments []models.Comment, opts ThreadOptions) (CommentThread, bool) {
+	var root *models.Comment
+	children := map[uint][]models.Comment{}
+	for i, comment := range comments {
+		if comment.ID == rootID {
+			root = &comments[i]
+		} else if comment.ParentCommentID != nil {
+			children[*comment.ParentCommentID] = append(children[*comment.ParentCommentID], comment)
+		}
+	}
+	if root == nil {
+		return CommentThread{}, false
+	}
+
+	var build func(comment models.Comment, depth int) CommentThread
+	build = func(comment models.Comment, depth int) CommentThread {
+		thread := CommentThread{Comment: comment, Replies: []CommentThread{}}
+		if depth >= opts.Depth {
+			thread.HasMore = comment.CommentCount > 0
+			return thread
+		}
+
+		replies := children[comment.ID]
+		sort.Slice(replies, func(i, j int) bool {
+			if !replies[i].CreatedAt.Equal(replies[j].CreatedAt) {
+				return replies[i].CreatedAt.After(replies[j].CreatedAt)
+			}
+			return replies[i].ID > replies[j].ID
+		})
+
+		page := NewPage(replies, opts.Replies, func(reply models.Comment) Cursor {
+			return Cursor{CreatedAt: reply.CreatedAt, ID: reply.ID}
+		})
+		for _, reply := range page.Items {
+			thread.Replies = append(thread.Replies, build(reply, depth+1))
+		}
+		thread.Next = page.Next
+		thread.HasMore = page.Next != nil
+		return thread
+	}
+
+	return build(*root, 0), true
+}
@@ -0,0 +1,146 @@
+// Package textparse finds the #hashtags and @mentions in post and comment
+// content.
+package textparse
+
+import (
+	"strings"
+	"unicode"
+
+	"sinkedin/models"
+)
+
+// MaxLength is the longest hashtag name or username recognised, matching
+// the width of their columns.
+const MaxLength = 50
+
+// Extract returns the hashtags and mentions in content, in order. Text inside
+// backtick code spans and URLs is skipped, as are # and @ signs that follow a
+// word character, such as those in email addresses. Hashtag names are
+// lowercased; usernames are returned as written.
+func Extract(content string) []models.Entity {
+	runes := []rune(content)
+	skip := skipped(runes)
+
+	entities := []models.Entity{}
+	for i := 0; i < len(runes); i++ {
+		sign := runes[i]
+		if skip[i] || (sign != '#' && sign != '@') {
+			continue
+		}
+		if i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '#' || runes[i-1] == '@') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && !skip[end] && isWordRune(runes[end]) {
+			end++
+		}
+		word := string(runes[i+1 : end])
+		if word == "" || end-i-1 > MaxLength {
+			i = end - 1
+			continue
+		}
+
+		if sign == '#' {
+			if !hasNonDigit(word) {
+				i = end - 1
+				continue
+			}
+			entities = append(entities, models.Entity{Type: models.HashtagEntity, Text: strings.ToLower(word), Start: i, End: end})
+		} else {
+			entities = append(entities, models.Entity{Type: models.MentionEntity, Text: word, Start: i, End: end})
+		}
+		i = end - 1
+	}
+	return entities
+}
+
+// NormalizeHashtag turns a client-supplied hashtag into its stored form,
+// dropping a leading # and lowercasing it. It reports false when the result
+// is not a valid hashtag name.
+func NormalizeHashtag(name string) (string, bool) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "#")
+	if name == "" || len([]rune(name)) > MaxLength || !hasNonDigit(name) {
+		return "", false
+	}
+	for _, r := range name {
+		if !isWordRune(r) {
+			return "", false
+		}
+	}
+	return strings.ToLower(name), true
+}
+
+// skipped marks the runes inside code spans and URLs.
+func skipped(runes []rune) []bool {
+	skip := make([]bool, len(runes))
+
+	// Code spans open and close with backtick runs of the same length, which
+	// also covers ``` fenced blocks
+	for i := 0; i < len(runes); {
+		if runes[i] != '`' {
+			i++
+			continue
+		}
+		n := backtickRun(runes, i)
+		closing := -1
+		for j := i + n; j < len(runes); {
+			if runes[j] != '`' {
+				j++
+				continue
+			}
+			m := backtickRun(runes, j)
+			if m == n {
+				closing = j
+				break
+			}
+			j += m
+		}
+		if closing < 0 {
+			i += n
+			continue
+		}
+		for k := i; k < closing+n; k++ {
+			skip[k] = true
+		}
+		i = closing + n
+	}
+
+	// URLs run from their scheme or www. prefix to the next whitespace
+	for i := 0; i < len(runes); i++ {
+		if skip[i] || (i > 0 && !unicode.IsSpace(runes[i-1]) && runes[i-1] != '(') || !isURLStart(runes[i:]) {
+			continue
+		}
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			skip[i] = true
+			i++
+		}
+	}
+	return skip
+}
+
+func backtickRun(runes []rune, i int) int {
+	n := 0
+	for i+n < len(runes) && runes[i+n] == '`' {
+		n++
+	}
+	return n
+}
+
+func isURLStart(runes []rune) bool {
+	prefix := strings.ToLower(string(runes[:min(len(runes), 8)]))
+	return strings.HasPrefix(prefix, "http://") || strings.HasPrefix(prefix, "https://") || strings.HasPrefix(prefix, "www.")
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func hasNonDigit(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
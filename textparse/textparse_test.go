@@ -0,0 +1,76 @@
+package textparse
+
+import (
+	"reflect"
+	"testing"
+
+	"sinkedin/models"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []models.Entity
+	}{
+		{"Hello world! #FirstPost", []models.Entity{
+			{Type: models.HashtagEntity, Text: "firstpost", Start: 13, End: 23},
+		}},
+		{"@bob and @carol_1, see #go.", []models.Entity{
+			{Type: models.MentionEntity, Text: "bob", Start: 0, End: 4},
+			{Type: models.MentionEntity, Text: "carol_1", Start: 9, End: 17},
+			{Type: models.HashtagEntity, Text: "go", Start: 23, End: 26},
+		}},
+		{"Ünïcode #Café @José", []models.Entity{
+			{Type: models.HashtagEntity, Text: "café", Start: 8, End: 13},
+			{Type: models.MentionEntity, Text: "José", Start: 14, End: 19},
+		}},
+		{"日本語 #東京", []models.Entity{
+			{Type: models.HashtagEntity, Text: "東京", Start: 4, End: 7},
+		}},
+		{"mail me at alice@example.com or see a#b", nil},
+		{"https://example.com/#anchor and www.example.com/@user", nil},
+		{"(https://example.com/#x) #real", []models.Entity{
+			{Type: models.HashtagEntity, Text: "real", Start: 25, End: 30},
+		}},
+		{"run `git tag #v1` or ```\n@root #fenced\n``` then #done", []models.Entity{
+			{Type: models.HashtagEntity, Text: "done", Start: 48, End: 53},
+		}},
+		{"unclosed `code #tag", []models.Entity{
+			{Type: models.HashtagEntity, Text: "tag", Start: 15, End: 19},
+		}},
+		{"#123 is a number, ## and @ alone are nothing, ##double", nil},
+	}
+
+	for _, tt := range tests {
+		got := Extract(tt.content)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%q) = %+v, want %+v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"Go", "go", true},
+		{"#Rust", "rust", true},
+		{" tag_1 ", "tag_1", true},
+		{"", "", false},
+		{"#", "", false},
+		{"123", "", false},
+		{"two words", "", false},
+		{"dash-ed", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeHashtag(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeHashtag(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// Package timeline materializes home timelines by fanning new posts out to
+// followers on write, falling back to fan-out-on-read for accounts with too
+// many followers to copy every post to.
+package timeline
+
+import (
+	"log"
+	"sync"
+
+	"sinkedin/models"
+	"sinkedin/realtime"
+	"sinkedin/store"
+)
+
+type Config struct {
+	// Workers is the number of background fan-out workers. With zero workers
+	// every job runs synchronously on the caller's goroutine.
+	Workers int
+	// QueueSize bounds the pending job queue. When it is full the caller runs
+	// the job itself rather than dropping it.
+	QueueSize int
+	// FanOutLimit is the follower count at which an author's posts stop being
+	// fanned out on write and are pulled into timelines on read instead.
+	FanOutLimit int
+	// BatchSize is the number of followers written per batch during fan-out.
+	BatchSize int
+	// BackfillLimit is the number of recent posts copied into a timeline when
+	// its owner follows someone.
+	BackfillLimit int
+	// Events receives new posts for live feeds, along the same push and pull
+	// paths as the timelines: to each follower, or to the author's topic for
+	// authors over FanOutLimit. Nil disables publishing.
+	Events realtime.Publisher
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1024
+	}
+	if cfg.FanOutLimit <= 0 {
+		cfg.FanOutLimit = 10000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.BackfillLimit <= 0 {
+		cfg.BackfillLimit = 50
+	}
+	return cfg
+}
+
+type job struct {
+	name string
+	run  func() error
+}
+
+type Service struct {
+	users     store.UserStore
+	posts     store.PostStore
+	follows   store.FollowStore
+	hashtags  store.HashtagStore
+	timelines store.TimelineStore
+	cfg       Config
+
+	mu     sync.RWMutex
+	closed bool
+	jobs   chan job
+	wg     sync.WaitGroup
+}
+
+// New creates the service and starts its workers.
+func New(s *store.Store, cfg Config) *Service {
+	cfg = cfg.withDefaults()
+	svc := &Service{
+		users:     s.Users,
+		posts:     s.Posts,
+		follows:   s.Follows,
+		hashtags:  s.Hashtags,
+		timelines: s.Timelines,
+		cfg:       cfg,
+	}
+
+	if cfg.Workers > 0 {
+		svc.jobs = make(chan job, cfg.QueueSize)
+		for i := 0; i < cfg.Workers; i++ {
+			svc.wg.Add(1)
+			go svc.work()
+		}
+	}
+	return svc
+}
+
+func (svc *Service) work() {
+	defer svc.wg.Done()
+	for j := range svc.jobs {
+		svc.execute(j)
+	}
+}
+
+func (svc *Service) execute(j job) {
+	if err := j.run(); err != nil {
+		log.Printf("timeline: %s failed: %v", j.name, err)
+	}
+}
+
+// enqueue hands the job to a worker, running it inline when there are no
+// workers, the queue is full or the service is shutting down.
+func (svc *Service) enqueue(j job) {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	if svc.jobs != nil && !svc.closed {
+		select {
+		case svc.jobs <- j:
+			return
+		default:
+		}
+	}
+	svc.execute(j)
+}
+
+// Close stops accepting background work and waits for queued jobs to finish.
+func (svc *Service) Close() {
+	svc.mu.Lock()
+	if svc.closed {
+		svc.mu.Unlock()
+		return
+	}
+	svc.closed = true
+	if svc.jobs != nil {
+		close(svc.jobs)
+	}
+	svc.mu.Unlock()
+
+	svc.wg.Wait()
+}
+
+// PostCreated fans the post out to the author's followers.
+func (svc *Service) PostCreated(post models.Post) {
+	svc.enqueue(job{name: "fan-out", run: func() error { return svc.fanOut(post, true) }})
+}
+
+// PostPublished sends a draft that was made visible to the live feeds it
+// reaches now. Its timeline entries were copied when it was created.
+func (svc *Service) PostPublished(post models.Post) {
+	svc.enqueue(job{name: "publish", run: func() error { return svc.fanOut(post, false) }})
+}
+
+// PostDeleted removes the post from every timeline it was copied to.
+func (svc *Service) PostDeleted(postID uint) {
+	svc.enqueue(job{name: "remove post", run: func() error { return svc.timelines.RemovePost(postID) }})
+}
+
+// Followed backfills the follower's timeline with the author's recent posts.
+func (svc *Service) Followed(followerID, authorID uint) {
+	svc.enqueue(job{name: "backfill", run: func() error { return svc.backfill(followerID, authorID) }})
+}
+
+// Unfollowed removes the author's posts from the follower's timeline and
+// stops their live feed from receiving the author's new posts, or activity
+// on the posts they may no longer see.
+func (svc *Service) Unfollowed(followerID, authorID uint) {
+	if svc.cfg.Events != nil {
+		svc.cfg.Events.Drop(followerID, realtime.AuthorTopic(authorID))
+		svc.cfg.Events.Recheck(realtime.UserTopic(followerID))
+	}
+	svc.enqueue(job{name: "unfollow", run: func() error { return svc.timelines.RemoveAuthor(followerID, authorID) }})
+}
+
+// Feed pages through the user's home timeline, merging the materialized
+// entries with posts pulled from high-follower accounts and hashtags they
+// follow.
+func (svc *Service) Feed(userID uint, includeTagged bool, page store.PageRequest) (store.Page[models.Post], error) {
+	pullAuthorIDs, err := svc.follows.PopularFollowingIDs(userID, svc.cfg.FanOutLimit)
+	if err != nil {
+		return store.Page[models.Post]{}, err
+	}
+	// Followed hashtags are always pulled on read
+	hashtagIDs, err := svc.hashtags.FollowedIDs(userID)
+	if err != nil {
+		return store.Page[models.Post]{}, err
+	}
+	return svc.posts.Timeline(userID, pullAuthorIDs, hashtagIDs, includeTagged, page)
+}
+
+// FeedTopics lists the topics a live feed subscribes to besides the user's
+// own: the high-follower accounts they follow and the hashtags they follow.
+func (svc *Service) FeedTopics(userID uint) ([]string, error) {
+	authorIDs, err := svc.follows.PopularFollowingIDs(userID, svc.cfg.FanOutLimit)
+	if err != nil {
+		return nil, err
+	}
+	hashtagIDs, err := svc.hashtags.FollowedIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make([]string, 0, len(authorIDs)+len(hashtagIDs))
+	for _, id := range authorIDs {
+		topics = append(topics, realtime.AuthorTopic(id))
+	}
+	for _, id := range hashtagIDs {
+		topics = append(topics, realtime.HashtagTopic(id))
+	}
+	return topics, nil
+}
+
+func (svc *Service) publish(topic string, post models.Post) {
+	if svc.cfg.Events != nil {
+		svc.cfg.Events.PublishFrom(post.UserID, topic, realtime.FeedPostEvent, post)
+	}
+}
+
+func (svc *Service) isPulled(authorID uint) (bool, error) {
+	author, err := svc.users.GetByID(authorID)
+	if err != nil {
+		return false, err
+	}
+	return svc.pulled(author), nil
+}
+
+func (svc *Service) pulled(author *models.User) bool {
+	return author.FollowersCount >= svc.cfg.FanOutLimit
+}
+
+// fanOut publishes the post to the live feeds it reaches and, with
+// addEntries, adds it to the timelines of the author's followers.
+func (svc *Service) fanOut(post models.Post, addEntries bool) error {
+	author, err := svc.users.GetByID(post.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Timeline entries are filtered by visibility on read, but live events
+	// go out as they are: hashtag topics reach anyone, and author and
+	// follower topics reach every follower
+	forFollowers := post.Visibility == models.PublicVisibility || post.Visibility == models.FollowersVisibility
+	svc.publish(realtime.UserTopic(post.UserID), post)
+	if !author.IsPrivate && post.Visibility == models.PublicVisibility {
+		for _, hashtag := range post.Hashtags {
+			svc.publish(realtime.HashtagTopic(hashtag.ID), post)
+		}
+	}
+	if svc.pulled(author) {
+		if forFollowers {
+			svc.publish(realtime.AuthorTopic(post.UserID), post)
+		}
+		return nil
+	}
+
+	var afterID uint
+	for {
+		followerIDs, err := svc.follows.FollowerIDs(post.UserID, afterID, svc.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(followerIDs) == 0 {
+			return nil
+		}
+
+		if addEntries {
+			entries := make([]models.TimelineEntry, 0, len(followerIDs))
+			for _, followerID := range followerIDs {
+				entries = append(entries, models.TimelineEntry{
+					UserID:    followerID,
+					PostID:    post.ID,
+					AuthorID:  post.UserID,
+					CreatedAt: post.CreatedAt,
+				})
+			}
+			if err := svc.timelines.Add(entries); err != nil {
+				return err
+			}
+		}
+		if forFollowers {
+			for _, followerID := range followerIDs {
+				svc.publish(realtime.UserTopic(followerID), post)
+			}
+		}
+
+		if len(followerIDs) < svc.cfg.BatchSize {
+			return nil
+		}
+		afterID = followerIDs[len(followerIDs)-1]
+	}
+}
+
+func (svc *Service) backfill(followerID, authorID uint) error {
+	pulled, err := svc.isPulled(authorID)
+	if err != nil || pulled {
+		return err
+	}
+
+	recent, err := svc.posts.ListByAuthor(authorID, store.PageRequest{Limit: svc.cfg.BackfillLimit})
+	if err != nil {
+		return err
+	}
+
+	entries := make([]models.TimelineEntry, 0, len(recent.Items))
+	for _, post := range recent.Items {
+		entries = append(entries, models.TimelineEntry{
+			UserID:    followerID,
+			PostID:    post.ID,
+			AuthorID:  authorID,
+			CreatedAt: post.CreatedAt,
+		})
+	}
+	return svc.timelines.Add(entries)
+}
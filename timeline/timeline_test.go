@@ -0,0 +1,192 @@
+package timeline
+
+import (
+	"fmt"
+	"testing"
+
+	"sinkedin/models"
+	"sinkedin/store"
+	"sinkedin/store/memstore"
+)
+
+type fixture struct {
+	t     *testing.T
+	store *store.Store
+	svc   *Service
+}
+
+func newFixture(t *testing.T, cfg Config) *fixture {
+	s := memstore.New()
+	return &fixture{t: t, store: s, svc: New(s, cfg)}
+}
+
+func (f *fixture) user(username string) uint {
+	f.t.Helper()
+	user := models.User{Name: username, Username: username, Email: username + "@example.com", Password: "x"}
+	if err := f.store.Users.Create(&user); err != nil {
+		f.t.Fatal(err)
+	}
+	return user.ID
+}
+
+func (f *fixture) follow(followerID, authorID uint) {
+	f.t.Helper()
+	following, err := f.store.Follows.Toggle(followerID, authorID)
+	if err != nil || !following {
+		f.t.Fatalf("follow: %v %v", following, err)
+	}
+	f.svc.Followed(followerID, authorID)
+}
+
+func (f *fixture) unfollow(followerID, authorID uint) {
+	f.t.Helper()
+	following, err := f.store.Follows.Toggle(followerID, authorID)
+	if err != nil || following {
+		f.t.Fatalf("unfollow: %v %v", following, err)
+	}
+	f.svc.Unfollowed(followerID, authorID)
+}
+
+func (f *fixture) post(authorID uint, content string) uint {
+	f.t.Helper()
+	post := models.Post{UserID: authorID, Content: content}
+	if err := f.store.Posts.Create(&post, nil, nil); err != nil {
+		f.t.Fatal(err)
+	}
+	f.svc.PostCreated(post)
+	return post.ID
+}
+
+func (f *fixture) feed(userID uint) string {
+	f.t.Helper()
+	page, err := f.svc.Feed(userID, false, store.PageRequest{Limit: 50})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	ids := make([]uint, 0, len(page.Items))
+	for _, post := range page.Items {
+		ids = append(ids, post.ID)
+	}
+	return fmt.Sprint(ids)
+}
+
+// materialized counts the posts that reach the user through timeline entries
+// rather than being pulled in on read.
+func (f *fixture) materialized(userID uint) int {
+	f.t.Helper()
+	page, err := f.store.Posts.Timeline(userID, nil, nil, false, store.PageRequest{Limit: 100})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	count := 0
+	for _, post := range page.Items {
+		if post.UserID != userID {
+			count++
+		}
+	}
+	return count
+}
+
+func TestFanOutOnWrite(t *testing.T) {
+	f := newFixture(t, Config{})
+	alice, bob, carol := f.user("alice"), f.user("bob"), f.user("carol")
+
+	f.follow(alice, bob)
+	first := f.post(bob, "first")
+	f.post(carol, "not followed")
+	own := f.post(alice, "mine")
+
+	if got, want := f.feed(alice), fmt.Sprint([]uint{own, first}); got != want {
+		t.Errorf("feed = %s, want %s", got, want)
+	}
+	if got := f.materialized(alice); got != 1 {
+		t.Errorf("materialized entries = %d, want 1", got)
+	}
+}
+
+func TestBackfillAndUnfollow(t *testing.T) {
+	f := newFixture(t, Config{BackfillLimit: 2})
+	alice, bob := f.user("alice"), f.user("bob")
+
+	f.post(bob, "old")
+	second := f.post(bob, "second")
+	third := f.post(bob, "third")
+
+	f.follow(alice, bob)
+	if got, want := f.feed(alice), fmt.Sprint([]uint{third, second}); got != want {
+		t.Errorf("backfilled feed = %s, want %s", got, want)
+	}
+
+	f.unfollow(alice, bob)
+	if got := f.feed(alice); got != "[]" {
+		t.Errorf("feed after unfollow = %s", got)
+	}
+}
+
+func TestDeletedPostsLeaveTimelines(t *testing.T) {
+	f := newFixture(t, Config{})
+	alice, bob := f.user("alice"), f.user("bob")
+
+	f.follow(alice, bob)
+	postID := f.post(bob, "regret")
+	if err := f.store.Posts.Delete(postID); err != nil {
+		t.Fatal(err)
+	}
+	f.svc.PostDeleted(postID)
+
+	if got := f.feed(alice); got != "[]" {
+		t.Errorf("feed after delete = %s", got)
+	}
+	if got := f.materialized(alice); got != 0 {
+		t.Errorf("materialized entries = %d after delete, want 0", got)
+	}
+}
+
+func TestPopularAuthorsArePulledOnRead(t *testing.T) {
+	f := newFixture(t, Config{FanOutLimit: 2})
+	alice, bob, star := f.user("alice"), f.user("bob"), f.user("star")
+
+	f.follow(alice, star)
+	f.follow(bob, star)
+	postID := f.post(star, "hello fans")
+
+	// Nothing is materialized for the popular account, yet both fans see it
+	for _, fan := range []uint{alice, bob} {
+		if got := f.materialized(fan); got != 0 {
+			t.Errorf("user %d has %d materialized entries, want 0", fan, got)
+		}
+		if got, want := f.feed(fan), fmt.Sprint([]uint{postID}); got != want {
+			t.Errorf("user %d feed = %s, want %s", fan, got, want)
+		}
+	}
+}
+
+func TestBackgroundWorkersDrainOnClose(t *testing.T) {
+	s := memstore.New()
+	f := &fixture{t: t, store: s, svc: New(s, Config{Workers: 3, QueueSize: 4})}
+	author := f.user("author")
+
+	var followers []uint
+	for i := 0; i < 10; i++ {
+		follower := f.user(fmt.Sprintf("fan%d", i))
+		f.follow(follower, author)
+		followers = append(followers, follower)
+	}
+	var postIDs []uint
+	for i := 0; i < 5; i++ {
+		postIDs = append(postIDs, f.post(author, fmt.Sprintf("post %d", i)))
+	}
+
+	f.svc.Close()
+	// Work submitted after Close runs inline instead of being lost
+	f.svc.PostDeleted(postIDs[0])
+	if err := s.Posts.Delete(postIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, follower := range followers {
+		if got := f.materialized(follower); got != 4 {
+			t.Errorf("follower %d has %d entries, want 4", follower, got)
+		}
+	}
+}
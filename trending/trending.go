@@ -0,0 +1,183 @@
+// Package trending ranks hashtags by how much they are being used right now.
+// Uses on posts and comments are counted over sliding windows, decayed by age
+// and weighted by how fast usage is growing compared with the window before.
+// The rankings are recomputed periodically into a snapshot that reads serve.
+package trending
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+)
+
+// Window is a sliding period that hashtag usage is ranked over.
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Windows are the supported windows, shortest first.
+var Windows = []Window{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// DefaultWindow is used when a request does not name one.
+const DefaultWindow = "24h"
+
+// ParseWindow looks up a window by name.
+func ParseWindow(name string) (Window, error) {
+	for _, w := range Windows {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+	return Window{}, fmt.Errorf("unknown trending window %q", name)
+}
+
+const (
+	// minVelocity and maxVelocity bound how much growth or decline relative to
+	// the previous window can move a score, so that a tag going from one use
+	// to three does not outrank one used steadily by hundreds.
+	minVelocity = 0.25
+	maxVelocity = 4
+)
+
+type Config struct {
+	// Interval is how often the snapshots are recomputed. With a zero
+	// interval nothing runs in the background and every read recomputes its
+	// window first.
+	Interval time.Duration
+	// Limit is the number of hashtags kept in each window's snapshot.
+	Limit int
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Limit <= 0 {
+		cfg.Limit = 50
+	}
+	return cfg
+}
+
+type Service struct {
+	hashtags store.HashtagStore
+	cfg      Config
+	now      func() time.Time
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// New creates the service. With a non-zero interval it computes the snapshots
+// straight away and then keeps them fresh in the background.
+func New(s *store.Store, cfg Config) *Service {
+	cfg = cfg.withDefaults()
+	svc := &Service{hashtags: s.Hashtags, cfg: cfg, now: time.Now, stop: make(chan struct{})}
+
+	if cfg.Interval > 0 {
+		svc.wg.Add(1)
+		go svc.loop()
+	}
+	return svc
+}
+
+func (svc *Service) loop() {
+	defer svc.wg.Done()
+
+	ticker := time.NewTicker(svc.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := svc.Recompute(); err != nil {
+			log.Printf("trending: recompute failed: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-svc.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background recomputation.
+func (svc *Service) Close() {
+	svc.once.Do(func() { close(svc.stop) })
+	svc.wg.Wait()
+}
+
+// Recompute rebuilds the snapshot of every window.
+func (svc *Service) Recompute() error {
+	now := svc.now()
+	for _, w := range Windows {
+		if err := svc.recompute(w, now); err != nil {
+			return fmt.Errorf("window %s: %w", w.Name, err)
+		}
+	}
+	return nil
+}
+
+func (svc *Service) recompute(w Window, now time.Time) error {
+	// Uses lose half their weight every quarter of the window
+	activity, err := svc.hashtags.Activity(now, w.Duration, w.Duration/4)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]models.TrendingHashtag, 0, len(activity))
+	for _, a := range activity {
+		score := Score(a)
+		if score <= 0 {
+			continue
+		}
+		entries = append(entries, models.TrendingHashtag{
+			Window:       w.Name,
+			HashtagID:    a.HashtagID,
+			Score:        score,
+			Uses:         a.Uses,
+			PreviousUses: a.PreviousUses,
+			ComputedAt:   now,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		if entries[i].Uses != entries[j].Uses {
+			return entries[i].Uses > entries[j].Uses
+		}
+		return entries[i].HashtagID < entries[j].HashtagID
+	})
+	if len(entries) > svc.cfg.Limit {
+		entries = entries[:svc.cfg.Limit]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	return svc.hashtags.ReplaceTrending(w.Name, entries)
+}
+
+// Score combines the decayed uses in the window with the growth over the
+// previous window of the same length.
+func Score(a store.HashtagActivity) float64 {
+	velocity := float64(a.Uses+1) / float64(a.PreviousUses+1)
+	velocity = min(max(velocity, minVelocity), maxVelocity)
+	return a.Decayed * velocity
+}
+
+// Trending returns the top hashtags of the window from its latest snapshot.
+func (svc *Service) Trending(w Window, limit int) ([]models.TrendingHashtag, error) {
+	if svc.cfg.Interval == 0 {
+		if err := svc.recompute(w, svc.now()); err != nil {
+			return nil, err
+		}
+	}
+	return svc.hashtags.Trending(w.Name, limit)
+}
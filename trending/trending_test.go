@@ -0,0 +1,84 @@
+package trending
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store"
+	"sinkedin/store/memstore"
+)
+
+func TestScore(t *testing.T) {
+	steady := Score(store.HashtagActivity{Uses: 10, PreviousUses: 10, Decayed: 8})
+	rising := Score(store.HashtagActivity{Uses: 10, PreviousUses: 1, Decayed: 8})
+	falling := Score(store.HashtagActivity{Uses: 10, PreviousUses: 40, Decayed: 8})
+	if !(rising > steady && steady > falling) {
+		t.Errorf("rising = %v, steady = %v, falling = %v", rising, steady, falling)
+	}
+
+	// Growth is capped so a handful of uses cannot outrank sustained usage
+	burst := Score(store.HashtagActivity{Uses: 3, Decayed: 3})
+	busy := Score(store.HashtagActivity{Uses: 100, PreviousUses: 100, Decayed: 80})
+	if burst >= busy {
+		t.Errorf("burst = %v, busy = %v", burst, busy)
+	}
+	if got := Score(store.HashtagActivity{PreviousUses: 5}); got != 0 {
+		t.Errorf("score without uses = %v", got)
+	}
+}
+
+func TestWindowsAndDecay(t *testing.T) {
+	s := memstore.New()
+	user := models.User{Name: "alice", Username: "alice", Email: "alice@example.com", Password: "x"}
+	if err := s.Users.Create(&user); err != nil {
+		t.Fatal(err)
+	}
+	post := models.Post{UserID: user.ID, Content: "hello"}
+	if err := s.Posts.Create(&post, []string{"go"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	comment := models.Comment{UserID: user.ID, PostID: &post.ID, Type: models.NormalComment, Content: "hi"}
+	if err := s.Comments.Create(&comment, []string{"go", "rust"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := New(s, Config{})
+	start := time.Now()
+	trendingAt := func(offset time.Duration, window string) []models.TrendingHashtag {
+		t.Helper()
+		svc.now = func() time.Time { return start.Add(offset) }
+		w, err := ParseWindow(window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := svc.Trending(w, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+
+	// A quarter of the way through the hour each use carries half its weight,
+	// and two uses against none before triple the score
+	entries := trendingAt(15*time.Minute, "1h")
+	if len(entries) != 2 || entries[0].Hashtag.Name != "go" || entries[0].Uses != 2 || entries[0].Rank != 1 {
+		t.Fatalf("1h entries = %+v", entries)
+	}
+	if want := 2 * 0.5 * 3.0; math.Abs(entries[0].Score-want) > 0.01 {
+		t.Errorf("go score = %v, want %v", entries[0].Score, want)
+	}
+
+	// Once the uses fall out of the hour they only count as previous uses
+	if entries := trendingAt(90*time.Minute, "1h"); len(entries) != 0 {
+		t.Errorf("1h entries after an hour = %+v", entries)
+	}
+	if entries := trendingAt(90*time.Minute, "24h"); len(entries) != 2 {
+		t.Errorf("24h entries = %+v", entries)
+	}
+
+	if _, err := ParseWindow("30d"); err == nil {
+		t.Error("ParseWindow accepted 30d")
+	}
+}
@@ -0,0 +1,130 @@
+// Package typeahead suggests @users and #hashtags for a prefix while a post
+// or comment is being written. Suggestions are cached in process for a short
+// time, since clients ask again on every keystroke.
+package typeahead
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"sinkedin/store"
+)
+
+type Config struct {
+	// TTL is how long suggestions are cached. Zero disables the cache.
+	TTL time.Duration
+	// MaxEntries bounds the cache; the least recently used entries are
+	// evicted first.
+	MaxEntries int
+	// RecentWindow is how far back hashtag usage counts as recent.
+	RecentWindow time.Duration
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.RecentWindow <= 0 {
+		cfg.RecentWindow = 7 * 24 * time.Hour
+	}
+	return cfg
+}
+
+type Service struct {
+	users    store.UserStore
+	hashtags store.HashtagStore
+	cfg      Config
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func New(s *store.Store, cfg Config) *Service {
+	return &Service{
+		users:    s.Users,
+		hashtags: s.Hashtags,
+		cfg:      cfg.withDefaults(),
+		now:      time.Now,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Users suggests accounts for the viewer (0 when anonymous) whose username or
+// name starts with prefix, which must already be lowercase.
+func (svc *Service) Users(viewerID uint, prefix string, limit int) ([]store.UserSuggestion, error) {
+	key := fmt.Sprintf("u|%d|%d|%s", viewerID, limit, prefix)
+	value, err := svc.cached(key, func() (interface{}, error) {
+		return svc.users.Suggest(viewerID, prefix, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]store.UserSuggestion), nil
+}
+
+// Hashtags suggests hashtags starting with prefix, most used recently first.
+func (svc *Service) Hashtags(prefix string, limit int) ([]store.HashtagSuggestion, error) {
+	key := fmt.Sprintf("h|%d|%s", limit, prefix)
+	value, err := svc.cached(key, func() (interface{}, error) {
+		return svc.hashtags.Suggest(prefix, svc.now().Add(-svc.cfg.RecentWindow), limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]store.HashtagSuggestion), nil
+}
+
+// cached returns the unexpired value for key, computing and storing it when
+// there is none. Errors are not cached.
+func (svc *Service) cached(key string, compute func() (interface{}, error)) (interface{}, error) {
+	if svc.cfg.TTL <= 0 {
+		return compute()
+	}
+
+	now := svc.now()
+	svc.mu.Lock()
+	if el, ok := svc.entries[key]; ok {
+		e := el.Value.(*entry)
+		if now.Before(e.expiresAt) {
+			svc.order.MoveToFront(el)
+			svc.mu.Unlock()
+			return e.value, nil
+		}
+		svc.remove(el)
+	}
+	svc.mu.Unlock()
+
+	// Compute outside the lock; concurrent misses for the same key may both
+	// query the store, which is harmless
+	value, err := compute()
+	if err != nil {
+		return nil, err
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if el, ok := svc.entries[key]; ok {
+		svc.remove(el)
+	}
+	svc.entries[key] = svc.order.PushFront(&entry{key: key, value: value, expiresAt: now.Add(svc.cfg.TTL)})
+	for svc.order.Len() > svc.cfg.MaxEntries {
+		svc.remove(svc.order.Back())
+	}
+	return value, nil
+}
+
+func (svc *Service) remove(el *list.Element) {
+	svc.order.Remove(el)
+	delete(svc.entries, el.Value.(*entry).key)
+}
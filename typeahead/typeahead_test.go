@@ -0,0 +1,53 @@
+package typeahead
+
+import (
+	"testing"
+	"time"
+
+	"sinkedin/models"
+	"sinkedin/store/memstore"
+)
+
+func TestCache(t *testing.T) {
+	s := memstore.New()
+	create := func(username string) {
+		t.Helper()
+		user := models.User{Name: username, Username: username, Email: username + "@example.com", Password: "x"}
+		if err := s.Users.Create(&user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	create("alice")
+
+	svc := New(s, Config{TTL: time.Minute, MaxEntries: 2})
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	suggest := func(prefix string) int {
+		t.Helper()
+		users, err := svc.Users(0, prefix, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(users)
+	}
+
+	if n := suggest("al"); n != 1 {
+		t.Fatalf("suggestions = %d", n)
+	}
+	create("alan")
+	if n := suggest("al"); n != 1 {
+		t.Errorf("cached suggestions = %d, want the stale 1", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if n := suggest("al"); n != 2 {
+		t.Errorf("suggestions after expiry = %d", n)
+	}
+
+	// Filling the cache evicts the least recently used prefix
+	suggest("a")
+	suggest("b")
+	if _, ok := svc.entries["u|0|10|al"]; ok || len(svc.entries) != 2 {
+		t.Errorf("cache holds %d entries, al cached = %v", len(svc.entries), ok)
+	}
+}